@@ -0,0 +1,696 @@
+package geui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/css/scanner"
+)
+
+// An Easing maps the progress of an animation, from 0 to 1, to how far
+// its values moved from the start to the end.
+type Easing func(t float64) float64
+
+// The easings of CSS.
+var (
+	Linear    Easing = func(t float64) float64 { return t }
+	Ease             = CubicBezier(0.25, 0.1, 0.25, 1)
+	EaseIn           = CubicBezier(0.42, 0, 1, 1)
+	EaseOut          = CubicBezier(0, 0, 0.58, 1)
+	EaseInOut        = CubicBezier(0.42, 0, 0.58, 1)
+)
+
+// CubicBezier returns the easing of the cubic Bézier curve from 0, 0 to
+// 1, 1 with the control points x1, y1 and x2, y2, as cubic-bezier() in
+// CSS. x1 and x2 are between 0 and 1.
+func CubicBezier(x1, y1, x2, y2 float64) Easing {
+	bezier := func(a, b, t float64) float64 {
+		return 3*a*t*(1-t)*(1-t) + 3*b*t*t*(1-t) + t*t*t
+	}
+	return func(x float64) float64 {
+		if x <= 0 || x >= 1 {
+			return x
+		}
+		// the curve goes right, find t for x by bisection
+		lo, hi, t := 0.0, 1.0, x
+		for i := 0; i < 32; i++ {
+			t = (lo + hi) / 2
+			if bezier(x1, x2, t) < x {
+				lo = t
+			} else {
+				hi = t
+			}
+		}
+		return bezier(y1, y2, t)
+	}
+}
+
+// parseEasing parses a CSS timing function, like "ease-in" or
+// "cubic-bezier(0.1, 0.7, 1, 0.1)".
+func parseEasing(v string) (Easing, bool) {
+	switch v {
+	case "linear":
+		return Linear, true
+	case "ease":
+		return Ease, true
+	case "ease-in":
+		return EaseIn, true
+	case "ease-out":
+		return EaseOut, true
+	case "ease-in-out":
+		return EaseInOut, true
+	}
+	if strings.HasPrefix(v, "cubic-bezier(") && strings.HasSuffix(v, ")") {
+		args := colorArgs(v[len("cubic-bezier(") : len(v)-1])
+		if len(args) == 4 {
+			var p [4]float64
+			for i, a := range args {
+				p[i], _ = strconv.ParseFloat(a, 64)
+			}
+			return CubicBezier(p[0], p[1], p[2], p[3]), true
+		}
+	}
+	return nil, false
+}
+
+// parseDuration parses a CSS time, like "200ms" or "1.5s".
+func parseDuration(v string) (time.Duration, bool) {
+	unit := time.Second
+	switch {
+	case strings.HasSuffix(v, "ms"):
+		v, unit = v[:len(v)-2], time.Millisecond
+	case strings.HasSuffix(v, "s"):
+		v = v[:len(v)-1]
+	default:
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(f * float64(unit)), true
+}
+
+// animProperty is a property transitions and animations can change.
+type animProperty struct {
+	color bool                     // a colour, else a number
+	value func(st *CSStyle) string // value in the style
+}
+
+// animProperties are the properties that can be animated, by CSS name.
+// Sizes are animated in the stacking and grid layouts.
+var animProperties = map[string]animProperty{
+	"background-color": {true, func(st *CSStyle) string { return st.BackgroundColor }},
+	"font-color":       {true, func(st *CSStyle) string { return st.FontColor }},
+	"opacity":          {false, func(st *CSStyle) string { return formatLength(st.Opacity) }},
+	"width":            {false, func(st *CSStyle) string { return formatLength(st.Width) }},
+	"height":           {false, func(st *CSStyle) string { return formatLength(st.Height) }},
+	"transform":        {false, func(st *CSStyle) string { return formatTransforms(st.Transform) }},
+}
+
+func formatLength(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// A Transition changes a property smoothly when its value changes,
+// declared with transition in CSS.
+type Transition struct {
+	Property string // a property, or all
+	Duration time.Duration
+	Delay    time.Duration
+	Easing   Easing
+}
+
+// An Animation runs the @keyframes with its name, declared with animation
+// in CSS.
+type Animation struct {
+	Name       string
+	Duration   time.Duration
+	Delay      time.Duration
+	Easing     Easing
+	Iterations float64 // number of cycles, +Inf for infinite
+	Direction  string  // normal, reverse, alternate or alternate-reverse
+	FillMode   string  // none, or forwards to keep the last values
+}
+
+// cssGroups splits the values of a declaration at its commas.
+func cssGroups(values []string) (groups [][]string) {
+	var g []string
+	for _, v := range append(values, ",") {
+		if v == "," {
+			if len(g) > 0 {
+				groups = append(groups, g)
+			}
+			g = nil
+			continue
+		}
+		g = append(g, v)
+	}
+	return groups
+}
+
+// parseTransitions parses a transition declaration, like
+// "background-color 200ms ease-in-out, opacity 1s".
+func parseTransitions(values []string) (ts []Transition) {
+	for _, g := range cssGroups(values) {
+		t := Transition{Property: "all", Easing: Ease}
+		durations := 0
+		for _, v := range g {
+			if d, ok := parseDuration(v); ok {
+				if durations == 0 {
+					t.Duration = d
+				} else {
+					t.Delay = d
+				}
+				durations++
+			} else if e, ok := parseEasing(v); ok {
+				t.Easing = e
+			} else {
+				t.Property = v
+			}
+		}
+		ts = append(ts, t)
+	}
+	return ts
+}
+
+// parseAnimations parses an animation declaration, like
+// "pulse 1s ease-in-out infinite alternate".
+func parseAnimations(values []string) (as []Animation) {
+	for _, g := range cssGroups(values) {
+		a := Animation{Easing: Ease, Iterations: 1, Direction: "normal", FillMode: "none"}
+		durations := 0
+		for _, v := range g {
+			if d, ok := parseDuration(v); ok {
+				if durations == 0 {
+					a.Duration = d
+				} else {
+					a.Delay = d
+				}
+				durations++
+				continue
+			}
+			if e, ok := parseEasing(v); ok {
+				a.Easing = e
+				continue
+			}
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				a.Iterations = n
+				continue
+			}
+			switch v {
+			case "infinite":
+				a.Iterations = math.Inf(1)
+			case "normal", "reverse", "alternate", "alternate-reverse":
+				a.Direction = v
+			case "none", "forwards", "backwards", "both":
+				a.FillMode = v
+			default:
+				a.Name = v
+			}
+		}
+		as = append(as, a)
+	}
+	return as
+}
+
+// keyframe is a step of @keyframes: the values of properties at an
+// offset of the cycle, from 0 to 1.
+type keyframe struct {
+	offset float64
+	props  map[string]string
+}
+
+// parseKeyframes returns the @keyframes rules of the style sheet css, by
+// name, with their steps in order.
+func parseKeyframes(css string) map[string][]keyframe {
+	rules := map[string][]keyframe{}
+	for {
+		i := strings.Index(css, "@keyframes")
+		if i < 0 {
+			return rules
+		}
+		css = css[i+len("@keyframes"):]
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			return rules
+		}
+		name := strings.TrimSpace(css[:open])
+		css = css[open+1:]
+		var frames []keyframe
+		for {
+			css = strings.TrimSpace(css)
+			open, end := strings.IndexByte(css, '{'), strings.IndexByte(css, '}')
+			if end < 0 || open < 0 || end < open {
+				// end of the rule
+				if end >= 0 {
+					css = css[end+1:]
+				}
+				break
+			}
+			props := parseDeclarations(css[open+1 : end])
+			for _, sel := range strings.Split(css[:open], ",") {
+				switch sel = strings.TrimSpace(sel); sel {
+				case "from":
+					frames = append(frames, keyframe{0, props})
+				case "to":
+					frames = append(frames, keyframe{1, props})
+				default:
+					if v, err := strconv.ParseFloat(strings.TrimSuffix(sel, "%"), 64); err == nil {
+						frames = append(frames, keyframe{v / 100, props})
+					}
+				}
+			}
+			css = css[end+1:]
+		}
+		sort.SliceStable(frames, func(i, j int) bool { return frames[i].offset < frames[j].offset })
+		rules[name] = frames
+	}
+}
+
+// parseDeclarations returns the values of the declarations css, like
+// "opacity: 0; width: 10px", by property.
+func parseDeclarations(css string) map[string]string {
+	props := map[string]string{}
+	s := scanner.New(css)
+	for {
+		tok := s.Next()
+		if tok.Type == scanner.TokenEOF || tok.Type == scanner.TokenError {
+			return props
+		}
+		if tok.Type == scanner.TokenIdent {
+			props[tok.Value] = styleText(s)
+		}
+	}
+}
+
+// animation runs keyframes on the properties of a node.
+type animation struct {
+	key        string // what started it, like "transition opacity"
+	frames     []keyframe
+	start      time.Time // set on the first frame it runs
+	delay      time.Duration
+	duration   time.Duration
+	easing     Easing
+	iterations float64
+	direction  string
+	fill       bool // keeps the last values when finished
+	commit     bool // sets the last values in the style when finished
+}
+
+// animState holds the animations of a node.
+type animState struct {
+	runs    []*animation
+	targets map[string]string // values the transitioned properties go to
+	css     string            // names of the CSS animations started
+	values  map[string]string // animated values, used instead of the style
+}
+
+// baseValue returns the value of the animatable property p of n without
+// its animations. The background is the hover or selected colour in
+// those states.
+func (n *Node) baseValue(p string) string {
+	if p == "background-color" {
+		return n.stateBackground()
+	}
+	return animProperties[p].value(n.Style)
+}
+
+// animated returns the animated value of the property p of n.
+func (n *Node) animated(p string) (string, bool) {
+	if n.anim == nil {
+		return "", false
+	}
+	v, ok := n.anim.values[p]
+	return v, ok
+}
+
+// animatedNumber returns the animated value of the numeric property p of
+// n, or v.
+func (n *Node) animatedNumber(p string, v float64) float64 {
+	if s, ok := n.animated(p); ok {
+		return parseLength(s)
+	}
+	return v
+}
+
+// stateBackground returns the background colour of n for its state.
+func (n *Node) stateBackground() string {
+	switch {
+	case n.selected:
+		return n.Style.SelectedColor
+	case n.hovered:
+		return n.Style.HoverColor
+	}
+	return n.Style.BackgroundColor
+}
+
+// background returns the colour the background of n is painted with.
+func (n *Node) background() string {
+	if v, ok := n.animated("background-color"); ok {
+		return v
+	}
+	return n.stateBackground()
+}
+
+// fontColor returns the colour the text of n is painted with.
+func (n *Node) fontColor() string {
+	if v, ok := n.animated("font-color"); ok {
+		return v
+	}
+	return n.Style.FontColor
+}
+
+// size returns the width and height of the style of n, as animated.
+func (n *Node) size() (w, h float64) {
+	return n.animatedNumber("width", n.Style.Width), n.animatedNumber("height", n.Style.Height)
+}
+
+// Animate changes the properties of n, by CSS name, from their values to
+// the values props over the duration d with the given easing, or Ease
+// when nil, and then sets them in the style. Colours, opacity, sizes and
+// transforms can be animated.
+func (n *Node) Animate(props map[string]string, d time.Duration, easing Easing) {
+	if easing == nil {
+		easing = Ease
+	}
+	from, to := map[string]string{}, map[string]string{}
+	for p, v := range props {
+		if _, ok := animProperties[p]; !ok {
+			continue
+		}
+		from[p], to[p] = n.baseValue(p), v
+		if v, ok := n.animated(p); ok {
+			from[p] = v
+		}
+	}
+	a := n.animations()
+	a.runs = append(a.runs, &animation{
+		key:        "animate",
+		frames:     []keyframe{{0, from}, {1, to}},
+		duration:   d,
+		easing:     easing,
+		iterations: 1,
+		commit:     true,
+	})
+	n.Invalidate()
+}
+
+// animations returns the animations of n.
+func (n *Node) animations() *animState {
+	if n.anim == nil {
+		n.anim = &animState{targets: map[string]string{}}
+	}
+	return n.anim
+}
+
+// replace runs r instead of the animations started like it.
+func (a *animState) replace(r *animation) {
+	a.remove(r.key)
+	a.runs = append(a.runs, r)
+}
+
+// remove stops the animations with the given key, or starting with it
+// when it ends with a space.
+func (a *animState) remove(key string) {
+	runs := a.runs[:0]
+	for _, r := range a.runs {
+		if r.key != key && !(strings.HasSuffix(key, " ") && strings.HasPrefix(r.key, key)) {
+			runs = append(runs, r)
+		}
+	}
+	a.runs = runs
+}
+
+// startTransitions starts the transitions of the properties of n whose
+// value changed since the last frame, from their shown value.
+func (n *Node) startTransitions() {
+	a := n.anim
+	for _, t := range n.Style.Transition {
+		props := []string{t.Property}
+		if t.Property == "all" {
+			props = props[:0]
+			for p := range animProperties {
+				props = append(props, p)
+			}
+			sort.Strings(props)
+		}
+		for _, p := range props {
+			if _, ok := animProperties[p]; !ok {
+				continue
+			}
+			target := n.baseValue(p)
+			old, seen := a.targets[p]
+			a.targets[p] = target
+			if !seen || old == target {
+				continue
+			}
+			from, ok := a.values[p]
+			if !ok {
+				from = old
+			}
+			key := "transition " + p
+			if from == target {
+				a.remove(key)
+				continue
+			}
+			a.replace(&animation{
+				key:        key,
+				frames:     []keyframe{{0, map[string]string{p: from}}, {1, map[string]string{p: target}}},
+				delay:      t.Delay,
+				duration:   t.Duration,
+				easing:     t.Easing,
+				iterations: 1,
+			})
+		}
+	}
+}
+
+// startCSSAnimations starts the animations of the style of n when they
+// changed, with the @keyframes of the sheets.
+func (n *Node) startCSSAnimations(sheets map[string][]keyframe) {
+	a := n.anim
+	var names []string
+	for _, an := range n.Style.Animation {
+		names = append(names, an.Name)
+	}
+	if css := strings.Join(names, ","); css != a.css {
+		a.css = css
+		a.remove("animation ")
+		for _, an := range n.Style.Animation {
+			frames, ok := sheets[an.Name]
+			if !ok {
+				continue
+			}
+			a.runs = append(a.runs, &animation{
+				key:        "animation " + an.Name,
+				frames:     frames,
+				delay:      an.Delay,
+				duration:   an.Duration,
+				easing:     an.Easing,
+				iterations: an.Iterations,
+				direction:  an.Direction,
+				fill:       an.FillMode == "forwards" || an.FillMode == "both",
+			})
+		}
+	}
+}
+
+// stepAnimations starts the transitions and animations of n and moves
+// them on to the time now, marking n dirty when its animated values
+// changed. Finished animations leave the properties to the style unless
+// they fill forwards. It reports whether some are still running.
+func (n *Node) stepAnimations(now time.Time, sheets map[string][]keyframe) bool {
+	if n.anim == nil {
+		if len(n.Style.Transition) == 0 && len(n.Style.Animation) == 0 {
+			return false
+		}
+		n.animations()
+	}
+	a := n.anim
+	n.startTransitions()
+	n.startCSSAnimations(sheets)
+	values := map[string]string{}
+	running := false
+	runs := a.runs[:0]
+	for _, r := range a.runs {
+		if r.start.IsZero() {
+			r.start = now
+		}
+		at := map[string]string{}
+		switch done := r.apply(n, now, at); {
+		case !done:
+			running = true
+		case r.commit:
+			for p, v := range r.frames[len(r.frames)-1].props {
+				parseInlineStyle(n, p+":"+v)
+			}
+			n.markDirty()
+			continue
+		case !r.fill:
+			continue
+		}
+		runs = append(runs, r)
+		for p, v := range at {
+			values[p] = v
+		}
+	}
+	a.runs = runs
+	if !sameValues(values, a.values) {
+		switch {
+		case values["width"] != a.values["width"] || values["height"] != a.values["height"]:
+			// the box of n changes
+			n.markDirty()
+		case values["transform"] != a.values["transform"]:
+			// the descendants move with n
+			for _, c := range n.GetNodes() {
+				c.markRepaint()
+			}
+		default:
+			n.markRepaint()
+		}
+		a.values = values
+	}
+	return running
+}
+
+// sameValues reports whether the animated values a and b are the same.
+func sameValues(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for p, v := range a {
+		if w, ok := b[p]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
+
+// apply sets the values of the properties r animates on n at the time
+// now in values, and reports whether r finished.
+func (r *animation) apply(n *Node, now time.Time, values map[string]string) bool {
+	elapsed := now.Sub(r.start) - r.delay
+	if elapsed < 0 {
+		return false
+	}
+	iter := r.iterations
+	if r.duration > 0 {
+		iter = math.Min(iter, float64(elapsed)/float64(r.duration))
+	}
+	done := iter >= r.iterations
+	cycle := math.Floor(iter)
+	k := iter - cycle
+	if done && k == 0 && iter > 0 {
+		// the end of the last cycle
+		cycle, k = cycle-1, 1
+	}
+	odd := math.Mod(cycle, 2) == 1
+	switch r.direction {
+	case "reverse":
+		k = 1 - k
+	case "alternate":
+		if odd {
+			k = 1 - k
+		}
+	case "alternate-reverse":
+		if !odd {
+			k = 1 - k
+		}
+	}
+	for _, f := range r.frames {
+		for p := range f.props {
+			if _, ok := animProperties[p]; ok {
+				values[p] = r.value(n, p, k)
+			}
+		}
+	}
+	return done
+}
+
+// value returns the value of the property p at the offset k of the
+// cycle, between the keyframes around it. Where no keyframe sets p at
+// the start or end, the value of the style is used.
+func (r *animation) value(n *Node, p string, k float64) string {
+	base := n.baseValue(p)
+	from, to := keyframe{0, map[string]string{p: base}}, keyframe{1, map[string]string{p: base}}
+	for _, f := range r.frames {
+		if _, ok := f.props[p]; !ok {
+			continue
+		}
+		if f.offset <= k {
+			from = f
+		} else {
+			to = f
+			break
+		}
+	}
+	t := 0.0
+	if to.offset > from.offset {
+		t = (k - from.offset) / (to.offset - from.offset)
+	}
+	return interpolate(p, from.props[p], to.props[p], r.easing(t))
+}
+
+// interpolate returns the value of the property p a fraction t from a
+// to b.
+func interpolate(p, a, b string, t float64) string {
+	if p == "transform" {
+		return interpolateTransforms(a, b, t)
+	}
+	if !animProperties[p].color {
+		x, y := parseLength(a), parseLength(b)
+		return formatLength(x + (y-x)*t)
+	}
+	x, y := parseColor(a), parseColor(b)
+	mix := func(u, v uint8) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(255, float64(u)+(float64(v)-float64(u))*t))))
+	}
+	return formatColor(color.NRGBA{mix(x.R, y.R), mix(x.G, y.G), mix(x.B, y.B), mix(x.A, y.A)})
+}
+
+// formatColor returns c as a hexadecimal colour with alpha.
+func formatColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// animate moves the animations of the trees of the window on to the time
+// of the frame, and the indeterminate progress bars, and wakes the window
+// up for another frame while any run.
+func (w *Window) animate() {
+	now := w.clock.Now()
+	var nodes []*Node
+	for _, t := range w.trees() {
+		nodes = append(nodes, t.GetNodes()...)
+	}
+	sheets := map[string][]keyframe{}
+	for _, n := range nodes {
+		if n.Type == ElementNode && n.Data == "style" {
+			for name, frames := range n.stylesheet().keyframes {
+				sheets[name] = frames
+			}
+		}
+	}
+	busy := false
+	for _, n := range nodes {
+		if n.Type != ElementNode {
+			continue
+		}
+		if n.stepAnimations(now, sheets) {
+			busy = true
+		}
+		if n.sweeping() {
+			n.markRepaint()
+			busy = true
+		}
+	}
+	if busy && !w.ticking {
+		w.ticking = true
+		w.AfterFunc(frameInterval, func() { w.ticking = false })
+	}
+}
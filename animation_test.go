@@ -0,0 +1,161 @@
+package geui
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEasing(t *testing.T) {
+	for name, e := range map[string]Easing{"linear": Linear, "ease": Ease, "ease-in-out": EaseInOut} {
+		if e(0) != 0 || e(1) != 1 {
+			t.Errorf("%s does not go from 0 to 1", name)
+		}
+	}
+	if v := EaseInOut(0.5); math.Abs(v-0.5) > 1e-6 {
+		t.Errorf("ease-in-out(0.5) = %v, want 0.5", v)
+	}
+	if v := EaseIn(0.25); v >= 0.25 {
+		t.Errorf("ease-in(0.25) = %v, want it slower than linear", v)
+	}
+	if e, ok := parseEasing("cubic-bezier(0, 0, 1, 1)"); !ok || math.Abs(e(0.3)-0.3) > 1e-6 {
+		t.Error("cubic-bezier(0, 0, 1, 1) is not linear")
+	}
+}
+
+func TestParseAnimationStyles(t *testing.T) {
+	n := element("box", "transition: background-color 200ms ease-in, opacity .5s 1s; animation: pulse 2s linear infinite alternate forwards")
+	tr := n.Style.Transition
+	if len(tr) != 2 || tr[0].Property != "background-color" || tr[0].Duration != 200*time.Millisecond ||
+		tr[1].Property != "opacity" || tr[1].Duration != 500*time.Millisecond || tr[1].Delay != time.Second {
+		t.Errorf("transitions = %+v", tr)
+	}
+	an := n.Style.Animation
+	if len(an) != 1 || an[0].Name != "pulse" || an[0].Duration != 2*time.Second || !math.IsInf(an[0].Iterations, 1) ||
+		an[0].Direction != "alternate" || an[0].FillMode != "forwards" {
+		t.Errorf("animations = %+v", an)
+	}
+	rules := parseKeyframes(`@keyframes pulse { from { opacity: 0 } 50%, 75% { opacity: 0.5; width: 10px } to { opacity: 1 } }
+		@keyframes grow { to { height: 100px } }`)
+	p := rules["pulse"]
+	if len(p) != 4 || p[1].offset != 0.5 || p[2].offset != 0.75 || p[1].props["width"] != "10px" || p[3].props["opacity"] != "1" {
+		t.Errorf("pulse = %+v", p)
+	}
+	if g := rules["grow"]; len(g) != 1 || g[0].offset != 1 || g[0].props["height"] != "100px" {
+		t.Errorf("grow = %+v", g)
+	}
+}
+
+func newAnimationWindow(t *testing.T, src string) (*Window, *ManualClock) {
+	clock := NewManualClock(time.Unix(0, 0))
+	return newXMLWindow(t, src, WithClock(clock)), clock
+}
+
+func TestTransition(t *testing.T) {
+	w, clock := newAnimationWindow(t, `<window style="width: 300px; height: 300px; hover-color: #ffffff">
+	<box id="b" style="margin: 0; background-color: #000000; hover-color: #ffffff; transition: background-color 200ms linear"></box>
+</window>`)
+	b := w.node.GetNodeByID("b")
+	w.dispatch(MouseMove{X: 10, Y: 10})
+	w.repaint()
+	if c := b.background(); c != "#000000ff" {
+		t.Fatalf("background when hovered = %s, want the transition starting", c)
+	}
+	clock.Advance(100 * time.Millisecond)
+	w.repaint()
+	if c := w.renderer.Image().RGBAAt(10, 10); c.R != 0x80 || c.G != 0x80 || c.B != 0x80 {
+		t.Errorf("pixel half way = %v, want grey", c)
+	}
+	clock.Advance(100 * time.Millisecond)
+	w.repaint()
+	if c := b.background(); c != "#ffffff" || len(b.anim.runs) != 0 {
+		t.Errorf("background at the end = %s, want the hover colour", c)
+	}
+	// leaving goes back from where the transition is
+	w.dispatch(MouseMove{X: 10, Y: 100})
+	w.repaint()
+	clock.Advance(50 * time.Millisecond)
+	w.repaint()
+	if c := b.background(); c != "#bfbfbfff" {
+		t.Errorf("background a quarter of the way back = %s", c)
+	}
+}
+
+func TestKeyframes(t *testing.T) {
+	w, clock := newAnimationWindow(t, `<window style="width: 300px; height: 300px; hover-color: #ffffff">
+	<style>@keyframes fade { from { opacity: 0 } to { opacity: 1 } }</style>
+	<box id="b" style="animation: fade 1s linear 2 alternate; opacity: 0.2"></box>
+</window>`)
+	b := w.node.GetNodeByID("b")
+	for _, step := range []struct {
+		at      time.Duration
+		opacity float64
+	}{{0, 0}, {500 * time.Millisecond, 0.5}, {time.Second, 1}, {1500 * time.Millisecond, 0.5}, {1900 * time.Millisecond, 0.1}, {2 * time.Second, 0.2}} {
+		clock.Advance(step.at - clock.Now().Sub(time.Unix(0, 0)))
+		w.repaint()
+		if o := b.opacity(); math.Abs(o-step.opacity) > 1e-9 {
+			t.Errorf("opacity at %v = %v, want %v", step.at, o, step.opacity)
+		}
+	}
+	if b.Style.Opacity != 0.2 {
+		t.Errorf("animation changed the style")
+	}
+}
+
+func TestAnimate(t *testing.T) {
+	w, clock := newAnimationWindow(t, `<window style="width: 300px; height: 300px; hover-color: #ffffff">
+	<box id="b" style="width: 200px; height: 20px"></box>
+</window>`)
+	b := w.node.GetNodeByID("b")
+	b.Animate(map[string]string{"width": "100px", "opacity": "0"}, time.Second, Linear)
+	w.repaint()
+	clock.Advance(250 * time.Millisecond)
+	w.repaint()
+	if b.Model.Width != 175 || b.opacity() != 0.75 {
+		t.Errorf("a quarter of the way, width = %v and opacity = %v", b.Model.Width, b.opacity())
+	}
+	clock.Advance(time.Second)
+	w.repaint()
+	if b.Style.Width != 100 || b.Style.Opacity != 0 || b.Model.Width != 100 {
+		t.Errorf("at the end, style width = %v and opacity = %v", b.Style.Width, b.Style.Opacity)
+	}
+}
+
+func TestAnimateTransform(t *testing.T) {
+	w, clock := newAnimationWindow(t, `<window style="width: 300px; height: 300px; hover-color: #ffffff">
+	<box id="b" style="margin: 0; width: 100px; height: 20px; transform-origin: left top"></box>
+</window>`)
+	b := w.node.GetNodeByID("b")
+	b.Animate(map[string]string{"transform": "translate(100px, 40px)"}, time.Second, Linear)
+	w.repaint()
+	clock.Advance(250 * time.Millisecond)
+	w.repaint()
+	if x, y := b.local(35, 20); x != 10 || y != 10 {
+		t.Errorf("a quarter of the way, 35, 20 maps to %v, %v in the box, want 10, 10", x, y)
+	}
+	clock.Advance(time.Second)
+	w.repaint()
+	if got := formatTransforms(b.Style.Transform); got != "translate(100px, 40px)" {
+		t.Errorf("at the end, style transform = %q", got)
+	}
+}
+
+func TestAnimateGrid(t *testing.T) {
+	w, clock := newAnimationWindow(t, `<window style="width: 300px; height: 300px; hover-color: #ffffff">
+	<grid style="margin: 0; grid-template-columns: auto 1fr">
+		<box id="b" style="margin: 0; width: 100px; height: 20px"></box>
+		<box id="c" style="margin: 0"></box>
+	</grid>
+</window>`)
+	b, c := w.node.GetNodeByID("b"), w.node.GetNodeByID("c")
+	b.Animate(map[string]string{"width": "60px", "height": "40px"}, time.Second, Linear)
+	w.repaint()
+	clock.Advance(250 * time.Millisecond)
+	w.repaint()
+	if m := b.Model; m.Width != 90 || m.Height != 25 {
+		t.Errorf("a quarter of the way, grid item is %vx%v, want 90x25", m.Width, m.Height)
+	}
+	if x := c.Model.RelativeX; x != 90 {
+		t.Errorf("next item at %v, want at 90", x)
+	}
+}
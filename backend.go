@@ -0,0 +1,111 @@
+package geui
+
+import (
+	"image"
+	"sync"
+)
+
+// A Cursor is the shape of the mouse pointer.
+type Cursor uint8
+
+const (
+	ArrowCursor Cursor = iota
+	IBeamCursor
+	HandCursor
+	CrosshairCursor
+	HResizeCursor
+	VResizeCursor
+)
+
+// Surface describes the surface a Backend creates for a Window.
+type Surface struct {
+	Title         string
+	Width, Height float64
+	Resizable     bool
+	Borderless    bool
+	Maximized     bool
+}
+
+// A Backend connects a Window to a platform. It creates the surface the
+// canvas is presented on and turns platform input into events.
+//
+// Sizes and event coordinates are in logical units; the canvas has
+// ContentScale device pixels per logical unit.
+type Backend interface {
+	// Create opens the surface and delivers the input events to handle
+	// from PollEvents and WaitEvents.
+	Create(s Surface, handle func(Event)) error
+	// Size returns the logical size of the surface.
+	Size() (width, height float64)
+	// ContentScale returns the number of device pixels per logical unit.
+	// A change of the scale is reported as a Resize event.
+	ContentScale() float64
+	// Present shows the canvas img on the surface. Only the region r of
+	// the image changed since the last call.
+	Present(img *image.RGBA, r image.Rectangle)
+	// PollEvents handles the pending events and returns immediately.
+	PollEvents()
+	// WaitEvents blocks until events arrive or Wake is called, then
+	// handles them.
+	WaitEvents()
+	// Wake interrupts WaitEvents. It may be called from any goroutine.
+	Wake()
+	Clipboard() string
+	SetClipboard(s string)
+	SetCursor(c Cursor)
+	// ShouldClose reports whether the user asked to close the surface.
+	ShouldClose() bool
+	// Close destroys the surface.
+	Close()
+}
+
+// eventQueue collects the events sent from other goroutines until the
+// window handles them in PollEvents or WaitEvents.
+type eventQueue struct {
+	mu     sync.Mutex
+	handle func(Event)
+	events []Event
+	wake   chan struct{}
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{wake: make(chan struct{}, 1)}
+}
+
+// Send queues an event for the next PollEvents or WaitEvents. It may be
+// called from any goroutine.
+func (q *eventQueue) Send(e Event) {
+	q.mu.Lock()
+	q.events = append(q.events, e)
+	q.mu.Unlock()
+	q.Wake()
+}
+
+func (q *eventQueue) PollEvents() {
+	q.mu.Lock()
+	events := q.events
+	q.events = nil
+	q.mu.Unlock()
+	for _, e := range events {
+		q.handle(e)
+	}
+}
+
+func (q *eventQueue) WaitEvents() {
+	<-q.wake
+	q.PollEvents()
+}
+
+func (q *eventQueue) Wake() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pending returns the number of events not handled yet.
+func (q *eventQueue) pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.events)
+}
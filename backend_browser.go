@@ -0,0 +1,490 @@
+package geui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"html"
+	"image"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/websocket"
+)
+
+// Browser is a Backend showing the window in web browsers. It is an
+// http.Handler serving a page that draws the frames streamed over a
+// WebSocket and sends the mouse and keyboard events of the browser back:
+//
+//	b := geui.NewBrowser()
+//	go http.ListenAndServe(":8080", b)
+//	w, err := geui.NewWindow(node, geui.WithBackend(b))
+//
+// Every connected browser shows the same window, at the size and scale of
+// the first one still connected. Connections are only accepted from the
+// pages the Browser serves.
+type Browser struct {
+	*eventQueue
+	mux           *http.ServeMux
+	surface       Surface
+	frame         *image.RGBA // copy of the presented canvas
+	width, height float64
+	scale         float64
+	clipboard     string
+	cursor        Cursor
+	clients       map[*browserClient]bool
+	primary       *browserClient // client whose resizes are applied
+	closed        bool
+}
+
+// browserClient is the state of a connected browser that is not sent yet.
+type browserClient struct {
+	ws        *websocket.Conn
+	changed   chan struct{}
+	damage    image.Rectangle // region of the frame to send
+	size      image.Point     // size of the frame the browser has
+	scale     float64         // scale the browser knows of
+	cursor    Cursor
+	clipboard string
+}
+
+// NewBrowser returns a browser backend.
+func NewBrowser() *Browser {
+	b := &Browser{
+		eventQueue: newEventQueue(),
+		mux:        http.NewServeMux(),
+		scale:      1,
+		clients:    make(map[*browserClient]bool),
+	}
+	b.mux.HandleFunc("/", b.servePage)
+	b.mux.Handle("/ws", websocket.Server{Handler: b.serveSocket, Handshake: checkOrigin})
+	return b
+}
+
+// checkOrigin refuses the WebSocket connections of pages from other hosts,
+// which could otherwise drive the window.
+func checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	if origin == nil || origin.Host != r.Host {
+		return errors.New("geui: websocket connection from another origin")
+	}
+	config.Origin = origin
+	return nil
+}
+
+func (b *Browser) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mux.ServeHTTP(w, r)
+}
+
+func (b *Browser) servePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	b.mu.Lock()
+	title := b.surface.Title
+	b.mu.Unlock()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(strings.Replace(browserPage, "{{title}}", html.EscapeString(title), 1)))
+}
+
+// serveSocket streams the frames to a browser and reads its events until
+// the connection is closed.
+func (b *Browser) serveSocket(ws *websocket.Conn) {
+	c := &browserClient{
+		ws:      ws,
+		changed: make(chan struct{}, 1),
+		cursor:  ArrowCursor,
+	}
+	b.mu.Lock()
+	if b.frame != nil {
+		c.damage = b.frame.Bounds()
+	}
+	b.clients[c] = true
+	if b.primary == nil {
+		b.primary = c
+	}
+	b.mu.Unlock()
+	c.changed <- struct{}{}
+	done := make(chan struct{})
+	go func() {
+		b.write(c, done)
+		_ = ws.Close()
+	}()
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, c)
+		if b.primary == c {
+			b.primary = nil
+			for o := range b.clients {
+				b.primary = o
+				break
+			}
+		}
+		b.mu.Unlock()
+		close(done)
+	}()
+	for {
+		var m browserMessage
+		if err := websocket.JSON.Receive(ws, &m); err != nil {
+			return
+		}
+		b.input(c, m)
+	}
+}
+
+// write sends the changes of the frame, cursor and clipboard to the browser
+// whenever they happen. Changes made while sending are coalesced.
+func (b *Browser) write(c *browserClient, done chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-c.changed:
+		}
+		b.mu.Lock()
+		var msgs []interface{}
+		var patch *image.RGBA
+		if b.frame != nil && (b.frame.Bounds().Size() != c.size || b.scale != c.scale) {
+			c.size, c.scale = b.frame.Bounds().Size(), b.scale
+			c.damage = b.frame.Bounds()
+			msgs = append(msgs, browserMessage{Type: "size", Width: b.width, Height: b.height, Scale: b.scale})
+		}
+		if b.cursor != c.cursor {
+			c.cursor = b.cursor
+			msgs = append(msgs, browserMessage{Type: "cursor", Cursor: int(c.cursor)})
+		}
+		if b.clipboard != c.clipboard {
+			c.clipboard = b.clipboard
+			msgs = append(msgs, browserMessage{Type: "clipboard", Text: c.clipboard})
+		}
+		if b.frame != nil && !c.damage.Empty() {
+			patch = image.NewRGBA(c.damage)
+			draw.Draw(patch, c.damage, b.frame, c.damage.Min, draw.Src)
+			c.damage = image.Rectangle{}
+		}
+		b.mu.Unlock()
+
+		for _, m := range msgs {
+			if websocket.JSON.Send(c.ws, m) != nil {
+				return
+			}
+		}
+		if patch != nil {
+			var buf bytes.Buffer
+			header := make([]byte, 8)
+			binary.LittleEndian.PutUint32(header, uint32(patch.Rect.Min.X))
+			binary.LittleEndian.PutUint32(header[4:], uint32(patch.Rect.Min.Y))
+			buf.Write(header)
+			if png.Encode(&buf, patch) != nil || websocket.Message.Send(c.ws, buf.Bytes()) != nil {
+				return
+			}
+		}
+	}
+}
+
+// notify wakes the writers of the browsers. b.mu must be held.
+func (b *Browser) notify() {
+	for c := range b.clients {
+		select {
+		case c.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (b *Browser) Create(s Surface, handle func(Event)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handle = handle
+	b.surface = s
+	b.width, b.height = s.Width, s.Height
+	return nil
+}
+
+func (b *Browser) Size() (width, height float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.width, b.height
+}
+
+func (b *Browser) ContentScale() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.scale
+}
+
+func (b *Browser) Present(img *image.RGBA, r image.Rectangle) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.frame == nil || b.frame.Bounds() != img.Bounds() {
+		b.frame = image.NewRGBA(img.Bounds())
+		r = img.Bounds()
+	}
+	draw.Draw(b.frame, r, img, r.Min, draw.Src)
+	for c := range b.clients {
+		c.damage = c.damage.Union(r)
+	}
+	b.notify()
+}
+
+func (b *Browser) Clipboard() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.clipboard
+}
+
+func (b *Browser) SetClipboard(s string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clipboard = s
+	b.notify()
+}
+
+func (b *Browser) SetCursor(c Cursor) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cursor = c
+	b.notify()
+}
+
+func (b *Browser) ShouldClose() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// Close disconnects the browsers.
+func (b *Browser) Close() {
+	b.mu.Lock()
+	b.closed = true
+	for c := range b.clients {
+		_ = c.ws.Close()
+	}
+	b.mu.Unlock()
+	b.Wake()
+}
+
+// browserMessage is a message exchanged with the page as JSON.
+type browserMessage struct {
+	Type   string  `json:"type"`
+	X      float64 `json:"x,omitempty"`
+	Y      float64 `json:"y,omitempty"`
+	Button int     `json:"button,omitempty"`
+	Shift  bool    `json:"shift,omitempty"`
+	Ctrl   bool    `json:"ctrl,omitempty"`
+	Alt    bool    `json:"alt,omitempty"`
+	Meta   bool    `json:"meta,omitempty"`
+	Code   string  `json:"code,omitempty"`
+	Text   string  `json:"text,omitempty"`
+	Width  float64 `json:"width,omitempty"`
+	Height float64 `json:"height,omitempty"`
+	Scale  float64 `json:"scale,omitempty"`
+	Cursor int     `json:"cursor,omitempty"`
+}
+
+func (m browserMessage) modifier() (mod Modifier) {
+	if m.Shift {
+		mod |= ModShift
+	}
+	if m.Ctrl {
+		mod |= ModControl
+	}
+	if m.Alt {
+		mod |= ModAlt
+	}
+	if m.Meta {
+		mod |= ModSuper
+	}
+	return
+}
+
+// input turns a message of the page of the client c into events. Only
+// the primary client resizes the window.
+func (b *Browser) input(c *browserClient, m browserMessage) {
+	button := MouseLeft
+	switch m.Button {
+	case 1:
+		button = MouseMiddle
+	case 2:
+		button = MouseRight
+	}
+	switch m.Type {
+	case "mousemove":
+		b.Send(MouseMove{X: m.X, Y: m.Y})
+	case "mousedown":
+		b.Send(MouseDown{X: m.X, Y: m.Y, MouseButton: button, Modifier: m.modifier()})
+	case "mouseup":
+		b.Send(MouseUp{X: m.X, Y: m.Y, MouseButton: button, Modifier: m.modifier()})
+	case "wheel":
+		b.Send(MouseScroll{X: m.X, Y: m.Y})
+	case "keydown":
+		b.Send(KbDown{browserKey(m.Code), m.modifier()})
+	case "keyrepeat":
+		b.Send(KbRepeat{browserKey(m.Code), m.modifier()})
+	case "keyup":
+		b.Send(KbUp{browserKey(m.Code), m.modifier()})
+	case "char":
+		for _, r := range m.Text {
+			b.Send(KbType{r})
+		}
+	case "paste":
+		b.mu.Lock()
+		b.clipboard = m.Text
+		b.mu.Unlock()
+		b.Send(KbDown{KeyV, ModControl})
+	case "resize":
+		b.mu.Lock()
+		if c != b.primary {
+			b.mu.Unlock()
+			return
+		}
+		if m.Scale > 0 {
+			b.scale = m.Scale
+		}
+		if b.surface.Resizable && m.Width > 0 && m.Height > 0 {
+			b.width, b.height = m.Width, m.Height
+		}
+		width, height := b.width, b.height
+		b.mu.Unlock()
+		b.Send(Resize{Width: width, Height: height})
+	}
+}
+
+var browserKeys = map[string]Key{
+	"Space":        KeySpace,
+	"Enter":        KeyEnter,
+	"NumpadEnter":  KeyEnter,
+	"Tab":          KeyTab,
+	"Backspace":    KeyBackspace,
+	"Delete":       KeyDelete,
+	"Insert":       KeyInsert,
+	"Escape":       KeyEscape,
+	"ArrowLeft":    KeyLeft,
+	"ArrowRight":   KeyRight,
+	"ArrowUp":      KeyUp,
+	"ArrowDown":    KeyDown,
+	"Home":         KeyHome,
+	"End":          KeyEnd,
+	"PageUp":       KeyPageUp,
+	"PageDown":     KeyPageDown,
+	"Minus":        KeyMinus,
+	"Equal":        KeyEqual,
+	"Comma":        KeyComma,
+	"Period":       KeyPeriod,
+	"Slash":        KeySlash,
+	"Semicolon":    KeySemicolon,
+	"Quote":        KeyApostrophe,
+	"ShiftLeft":    KeyLeftShift,
+	"ShiftRight":   KeyRightShift,
+	"ControlLeft":  KeyLeftControl,
+	"ControlRight": KeyRightControl,
+	"AltLeft":      KeyLeftAlt,
+	"AltRight":     KeyRightAlt,
+	"MetaLeft":     KeyLeftSuper,
+	"MetaRight":    KeyRightSuper,
+}
+
+// browserKey returns the key of a KeyboardEvent.code.
+func browserKey(code string) Key {
+	switch {
+	case len(code) == 4 && strings.HasPrefix(code, "Key") && code[3] >= 'A' && code[3] <= 'Z':
+		return KeyA + Key(code[3]-'A')
+	case len(code) == 6 && strings.HasPrefix(code, "Digit") && code[5] >= '0' && code[5] <= '9':
+		return Key0 + Key(code[5]-'0')
+	}
+	for k := KeyF1; k <= KeyF12; k++ {
+		if code == k.String() {
+			return k
+		}
+	}
+	return browserKeys[code]
+}
+
+const browserPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{title}}</title>
+<style>
+html, body { margin: 0; overflow: hidden; }
+canvas { display: block; outline: none; }
+</style>
+</head>
+<body>
+<canvas id="canvas" tabindex="0"></canvas>
+<script>
+const canvas = document.getElementById("canvas");
+const ctx = canvas.getContext("2d");
+const cursors = ["default", "text", "pointer", "crosshair", "ew-resize", "ns-resize"];
+const ws = new WebSocket((location.protocol === "https:" ? "wss://" : "ws://") + location.host + location.pathname.replace(/\/?$/, "/ws"));
+ws.binaryType = "arraybuffer";
+let drawing = Promise.resolve();
+
+function send(m) {
+	if (ws.readyState === WebSocket.OPEN) ws.send(JSON.stringify(m));
+}
+function mods(e) {
+	return {shift: e.shiftKey, ctrl: e.ctrlKey, alt: e.altKey, meta: e.metaKey};
+}
+function resize() {
+	send({type: "resize", width: innerWidth, height: innerHeight, scale: devicePixelRatio});
+}
+
+ws.onopen = resize;
+ws.onmessage = e => {
+	if (typeof e.data !== "string") {
+		const v = new DataView(e.data);
+		const x = v.getUint32(0, true), y = v.getUint32(4, true);
+		const png = new Blob([e.data.slice(8)], {type: "image/png"});
+		drawing = drawing.then(() => createImageBitmap(png)).then(img => ctx.drawImage(img, x, y));
+		return;
+	}
+	const m = JSON.parse(e.data);
+	switch (m.type) {
+	case "size":
+		drawing = drawing.then(() => {
+			canvas.width = Math.ceil(m.width * m.scale);
+			canvas.height = Math.ceil(m.height * m.scale);
+			canvas.style.width = m.width + "px";
+			canvas.style.height = m.height + "px";
+		});
+		break;
+	case "cursor":
+		canvas.style.cursor = cursors[m.cursor || 0];
+		break;
+	case "clipboard":
+		if (navigator.clipboard) navigator.clipboard.writeText(m.text || "").catch(() => {});
+		break;
+	}
+};
+
+addEventListener("resize", resize);
+canvas.addEventListener("mousemove", e => send({type: "mousemove", x: e.offsetX, y: e.offsetY}));
+canvas.addEventListener("mousedown", e => {
+	canvas.focus();
+	send(Object.assign({type: "mousedown", x: e.offsetX, y: e.offsetY, button: e.button}, mods(e)));
+});
+canvas.addEventListener("mouseup", e => send(Object.assign({type: "mouseup", x: e.offsetX, y: e.offsetY, button: e.button}, mods(e))));
+canvas.addEventListener("contextmenu", e => e.preventDefault());
+canvas.addEventListener("wheel", e => {
+	e.preventDefault();
+	send({type: "wheel", x: -Math.sign(e.deltaX), y: -Math.sign(e.deltaY)});
+});
+canvas.addEventListener("keydown", e => {
+	if ((e.ctrlKey || e.metaKey) && e.code === "KeyV") return; // sent with the paste event
+	e.preventDefault();
+	send(Object.assign({type: e.repeat ? "keyrepeat" : "keydown", code: e.code}, mods(e)));
+	if ([...e.key].length === 1 && !e.ctrlKey && !e.metaKey) send({type: "char", text: e.key});
+});
+canvas.addEventListener("keyup", e => send(Object.assign({type: "keyup", code: e.code}, mods(e))));
+document.addEventListener("paste", e => send({type: "paste", text: e.clipboardData.getData("text")}));
+</script>
+</body>
+</html>
+`
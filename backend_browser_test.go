@@ -0,0 +1,148 @@
+package geui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// receivePatch reads a frame region sent to the browser.
+func receivePatch(t *testing.T, ws *websocket.Conn) image.Image {
+	var msg []byte
+	if err := websocket.Message.Receive(ws, &msg); err != nil {
+		t.Fatal(err)
+	}
+	x := binary.LittleEndian.Uint32(msg)
+	y := binary.LittleEndian.Uint32(msg[4:])
+	img, err := png.Decode(bytes.NewReader(msg[8:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Min != image.Pt(0, 0) {
+		t.Fatalf("patch bounds start at %v", img.Bounds().Min)
+	}
+	return shiftedImage{img, image.Pt(int(x), int(y))}
+}
+
+// shiftedImage moves an image to another origin.
+type shiftedImage struct {
+	image.Image
+	at image.Point
+}
+
+func (s shiftedImage) Bounds() image.Rectangle { return s.Image.Bounds().Add(s.at) }
+func (s shiftedImage) At(x, y int) color.Color { return s.Image.At(x-s.at.X, y-s.at.Y) }
+
+func TestBrowser(t *testing.T) {
+	node := LoadXML("testdata/main.xml")
+	b := NewBrowser()
+	w, err := NewWindow(node, Title("Hello"), Size(300, 175), WithBackend(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.LastChild.SetStyle("background-color:#ff0000")
+	// pointer enters the window but no child
+	w.dispatch(MouseMove{X: 1, Y: 1})
+	w.update()
+
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if !strings.Contains(string(page), "<title>Hello</title>") {
+		t.Fatal("page has no title")
+	}
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	var size browserMessage
+	if err := websocket.JSON.Receive(ws, &size); err != nil {
+		t.Fatal(err)
+	}
+	if size.Type != "size" || size.Width != 300 || size.Height != 175 || size.Scale != 1 {
+		t.Fatalf("size message = %+v", size)
+	}
+	frame := receivePatch(t, ws)
+	if frame.Bounds() != image.Rect(0, 0, 300, 175) {
+		t.Fatalf("first frame bounds = %v", frame.Bounds())
+	}
+	button := node.LastChild.Bounds()
+	if r, g, _, _ := frame.At(button.Min.X+1, button.Min.Y+1).RGBA(); r != 0xffff || g != 0 {
+		t.Fatal("button is not red in the first frame")
+	}
+
+	msg, _ := json.Marshal(browserMessage{Type: "mousemove", X: 20, Y: 20})
+	if err := websocket.Message.Send(ws, string(msg)); err != nil {
+		t.Fatal(err)
+	}
+	msg, _ = json.Marshal(browserMessage{Type: "keydown", Code: "KeyS", Ctrl: true})
+	if err := websocket.Message.Send(ws, string(msg)); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); b.pending() < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	var events []Event
+	b.handle = func(e Event) {
+		events = append(events, e)
+		w.dispatch(e)
+	}
+	b.PollEvents()
+	if len(events) != 2 || events[1] != (KbDown{KeyS, ModControl}) {
+		t.Fatalf("events = %v", events)
+	}
+	if !node.FirstChild.hovered {
+		t.Fatal("label is not hovered")
+	}
+
+	w.update()
+	patch := receivePatch(t, ws)
+	if patch.Bounds() != node.FirstChild.Bounds() {
+		t.Fatalf("patch bounds = %v, want the label bounds %v", patch.Bounds(), node.FirstChild.Bounds())
+	}
+
+	// pages of other hosts cannot connect
+	if _, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", "", "http://example.com"); err == nil {
+		t.Error("connected from another origin")
+	}
+	// the resizes of another browser are ignored
+	other, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	msg, _ = json.Marshal(browserMessage{Type: "resize", Width: 100, Height: 100, Scale: 2})
+	if err := websocket.Message.Send(other, string(msg)); err != nil {
+		t.Fatal(err)
+	}
+	msg, _ = json.Marshal(browserMessage{Type: "mousemove", X: 1, Y: 1})
+	if err := websocket.Message.Send(other, string(msg)); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); b.pending() < 1 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	b.PollEvents()
+	if len(events) != 3 || b.ContentScale() != 1 {
+		t.Errorf("events = %v, scale %v: want the resize ignored", events, b.ContentScale())
+	}
+}
@@ -0,0 +1,407 @@
+package geui
+
+import (
+	"encoding/binary"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// Framebuffer is a Backend for Linux machines without a GPU. It writes the
+// canvas to a framebuffer device such as /dev/fb0 and reads keyboard,
+// mouse and touch input from evdev devices.
+type Framebuffer struct {
+	*eventQueue
+
+	// Device is the framebuffer device.
+	Device string
+	// Inputs are the evdev devices input is read from.
+	Inputs []string
+	// Width, Height and BitsPerPixel describe the framebuffer when it
+	// can't be queried, e.g. when Device is a plain file standing in for
+	// the device. The pixels are stored as XRGB in little-endian order.
+	Width, Height, BitsPerPixel int
+	// Scale is the number of device pixels per logical unit.
+	Scale float64
+
+	device           *os.File
+	inputs           []*os.File
+	stride           int         // bytes per row
+	depth            int         // bytes per pixel
+	red, green, blue fbBitfield  // position of the channels in a pixel
+	mu               sync.Mutex  // guards the input state below
+	mouseX, mouseY   float64     // pointer position in logical units
+	moved            bool        // pointer moved since the last report
+	mods             Modifier    // modifier keys held down
+	abs              [2]absRange // ranges of the absolute axes
+	clipboard        string
+	closed           bool
+}
+
+// NewFramebuffer returns a framebuffer backend drawing to device, e.g.
+// /dev/fb0. Input is read from the given evdev devices, or from all of
+// /dev/input/event* when none are given.
+func NewFramebuffer(device string, inputs ...string) *Framebuffer {
+	if len(inputs) == 0 {
+		inputs, _ = filepath.Glob("/dev/input/event*")
+	}
+	return &Framebuffer{
+		eventQueue: newEventQueue(),
+		Device:     device,
+		Inputs:     inputs,
+		Scale:      1,
+	}
+}
+
+const (
+	fbioGetVScreenInfo = 0x4600
+	fbioGetFScreenInfo = 0x4602
+)
+
+type fbBitfield struct {
+	Offset, Length, MsbRight uint32
+}
+
+// fbVarScreenInfo is struct fb_var_screeninfo of linux/fb.h.
+type fbVarScreenInfo struct {
+	XRes, YRes, XResVirtual, YResVirtual uint32
+	XOffset, YOffset                     uint32
+	BitsPerPixel, Grayscale              uint32
+	Red, Green, Blue, Transp             fbBitfield
+	_                                    [18]uint32
+}
+
+// fbFixScreenInfo is struct fb_fix_screeninfo of linux/fb.h.
+type fbFixScreenInfo struct {
+	ID                             [16]byte
+	SmemStart                      uintptr
+	SmemLen, Type, TypeAux, Visual uint32
+	XPanStep, YPanStep, YWrapStep  uint16
+	LineLength                     uint32
+	MmioStart                      uintptr
+	MmioLen, Accel                 uint32
+	Capabilities                   uint16
+	_                              [2]uint16
+}
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (fb *Framebuffer) Create(s Surface, handle func(Event)) error {
+	var err error
+	fb.device, err = os.OpenFile(fb.Device, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	fb.handle = handle
+
+	var vinfo fbVarScreenInfo
+	var finfo fbFixScreenInfo
+	if ioctl(fb.device, fbioGetVScreenInfo, unsafe.Pointer(&vinfo)) == nil &&
+		ioctl(fb.device, fbioGetFScreenInfo, unsafe.Pointer(&finfo)) == nil {
+		fb.Width, fb.Height = int(vinfo.XRes), int(vinfo.YRes)
+		fb.BitsPerPixel = int(vinfo.BitsPerPixel)
+		fb.stride = int(finfo.LineLength)
+		fb.red, fb.green, fb.blue = vinfo.Red, vinfo.Green, vinfo.Blue
+	} else {
+		if fb.BitsPerPixel == 0 {
+			fb.BitsPerPixel = 32
+		}
+		fb.red = fbBitfield{Offset: 16, Length: 8}
+		fb.green = fbBitfield{Offset: 8, Length: 8}
+		fb.blue = fbBitfield{Offset: 0, Length: 8}
+		if fb.BitsPerPixel == 16 {
+			fb.red = fbBitfield{Offset: 11, Length: 5}
+			fb.green = fbBitfield{Offset: 5, Length: 6}
+			fb.blue = fbBitfield{Offset: 0, Length: 5}
+		}
+		if fb.Width == 0 || fb.Height == 0 {
+			fb.Width, fb.Height = int(s.Width*fb.Scale), int(s.Height*fb.Scale)
+		}
+		fb.stride = fb.Width * fb.BitsPerPixel / 8
+	}
+	fb.depth = fb.BitsPerPixel / 8
+	fb.mouseX, fb.mouseY = fb.Size()
+	fb.mouseX, fb.mouseY = fb.mouseX/2, fb.mouseY/2
+
+	for _, name := range fb.Inputs {
+		f, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		fb.inputs = append(fb.inputs, f)
+		go fb.readInput(f)
+	}
+	return nil
+}
+
+func (fb *Framebuffer) Size() (width, height float64) {
+	return float64(fb.Width) / fb.Scale, float64(fb.Height) / fb.Scale
+}
+
+func (fb *Framebuffer) ContentScale() float64 {
+	return fb.Scale
+}
+
+// Present converts the region r of the canvas to the pixel format of the
+// framebuffer and writes it to the device row by row.
+func (fb *Framebuffer) Present(img *image.RGBA, r image.Rectangle) {
+	r = r.Intersect(image.Rect(0, 0, fb.Width, fb.Height))
+	if r.Empty() {
+		return
+	}
+	row := make([]byte, r.Dx()*fb.depth)
+	var pixel [4]byte
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			v := fb.red.pack(c.R) | fb.green.pack(c.G) | fb.blue.pack(c.B)
+			binary.LittleEndian.PutUint32(pixel[:], v)
+			copy(row[(x-r.Min.X)*fb.depth:], pixel[:fb.depth])
+		}
+		_, _ = fb.device.WriteAt(row, int64(y*fb.stride+r.Min.X*fb.depth))
+	}
+}
+
+// pack places the 8 bit channel value v into the bitfield.
+func (b fbBitfield) pack(v uint8) uint32 {
+	return uint32(v) >> (8 - b.Length) << b.Offset
+}
+
+const (
+	evSyn = 0x00
+	evKey = 0x01
+	evRel = 0x02
+	evAbs = 0x03
+
+	relX     = 0x00
+	relY     = 0x01
+	relWheel = 0x08
+	absX     = 0x00
+	absY     = 0x01
+
+	btnLeft   = 0x110
+	btnRight  = 0x111
+	btnMiddle = 0x112
+	btnTouch  = 0x14a
+)
+
+// absRange is struct input_absinfo of linux/input.h.
+type absRange struct {
+	Value, Minimum, Maximum, Fuzz, Flat, Resolution int32
+}
+
+// evioGetAbs returns the EVIOCGABS request for the axis.
+func evioGetAbs(axis int) uintptr {
+	return 2<<30 | uintptr(unsafe.Sizeof(absRange{}))<<16 | 'E'<<8 | uintptr(0x40+axis)
+}
+
+// inputEventSize is the size of struct input_event, which starts with a
+// struct timeval of two longs.
+const inputEventSize = 2*unsafe.Sizeof(uintptr(0)) + 8
+
+// readInput turns the events of an evdev device into geui events until the
+// device is closed.
+func (fb *Framebuffer) readInput(f *os.File) {
+	for axis := range fb.abs {
+		fb.mu.Lock()
+		if ioctl(f, evioGetAbs(axis), unsafe.Pointer(&fb.abs[axis])) != nil {
+			fb.abs[axis] = absRange{}
+		}
+		fb.mu.Unlock()
+	}
+	buf := make([]byte, inputEventSize)
+	for {
+		if _, err := io.ReadFull(f, buf); err != nil {
+			return
+		}
+		typ := binary.LittleEndian.Uint16(buf[inputEventSize-8:])
+		code := binary.LittleEndian.Uint16(buf[inputEventSize-6:])
+		value := int32(binary.LittleEndian.Uint32(buf[inputEventSize-4:]))
+		fb.input(typ, code, value)
+	}
+}
+
+// input handles a single evdev event.
+func (fb *Framebuffer) input(typ, code uint16, value int32) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	switch typ {
+	case evSyn:
+		if fb.moved {
+			fb.moved = false
+			fb.Send(MouseMove{X: fb.mouseX, Y: fb.mouseY})
+		}
+	case evRel:
+		switch code {
+		case relX:
+			fb.movePointer(fb.mouseX+float64(value)/fb.Scale, fb.mouseY)
+		case relY:
+			fb.movePointer(fb.mouseX, fb.mouseY+float64(value)/fb.Scale)
+		case relWheel:
+			fb.Send(MouseScroll{Y: float64(value)})
+		}
+	case evAbs:
+		if code != absX && code != absY {
+			return
+		}
+		v := float64(value)
+		if a := fb.abs[code]; a.Maximum > a.Minimum {
+			size := float64(fb.Width)
+			if code == absY {
+				size = float64(fb.Height)
+			}
+			v = float64(value-a.Minimum) / float64(a.Maximum-a.Minimum) * size
+		}
+		if code == absX {
+			fb.movePointer(v/fb.Scale, fb.mouseY)
+		} else {
+			fb.movePointer(fb.mouseX, v/fb.Scale)
+		}
+	case evKey:
+		switch code {
+		case btnLeft, btnTouch:
+			fb.button(MouseLeft, value)
+		case btnRight:
+			fb.button(MouseRight, value)
+		case btnMiddle:
+			fb.button(MouseMiddle, value)
+		default:
+			fb.key(code, value)
+		}
+	}
+}
+
+// movePointer moves the pointer, keeping it on the surface.
+func (fb *Framebuffer) movePointer(x, y float64) {
+	width, height := fb.Size()
+	fb.mouseX = clamp(x, 0, width-1)
+	fb.mouseY = clamp(y, 0, height-1)
+	fb.moved = true
+}
+
+func (fb *Framebuffer) button(b MouseButton, value int32) {
+	if value == 1 {
+		fb.Send(MouseDown{X: fb.mouseX, Y: fb.mouseY, MouseButton: b, Modifier: fb.mods})
+	} else if value == 0 {
+		fb.Send(MouseUp{X: fb.mouseX, Y: fb.mouseY, MouseButton: b, Modifier: fb.mods})
+	}
+}
+
+func (fb *Framebuffer) key(code uint16, value int32) {
+	k := evdevKeys[code]
+	var mod Modifier
+	switch k {
+	case KeyLeftShift, KeyRightShift:
+		mod = ModShift
+	case KeyLeftControl, KeyRightControl:
+		mod = ModControl
+	case KeyLeftAlt, KeyRightAlt:
+		mod = ModAlt
+	case KeyLeftSuper, KeyRightSuper:
+		mod = ModSuper
+	}
+	switch value {
+	case 0:
+		fb.mods &^= mod
+		fb.Send(KbUp{k, fb.mods})
+		return
+	case 1:
+		fb.mods |= mod
+		fb.Send(KbDown{k, fb.mods})
+	case 2:
+		fb.Send(KbRepeat{k, fb.mods})
+	}
+	if fb.mods&^ModShift != 0 {
+		return
+	}
+	if chars, ok := evdevChars[code]; ok {
+		fb.Send(KbType{chars[fb.mods&ModShift]})
+	}
+}
+
+func (fb *Framebuffer) Clipboard() string {
+	return fb.clipboard
+}
+
+func (fb *Framebuffer) SetClipboard(s string) {
+	fb.clipboard = s
+}
+
+// SetCursor does nothing, framebuffers have no pointer.
+func (fb *Framebuffer) SetCursor(c Cursor) {}
+
+func (fb *Framebuffer) ShouldClose() bool {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	return fb.closed
+}
+
+func (fb *Framebuffer) Close() {
+	fb.mu.Lock()
+	fb.closed = true
+	fb.mu.Unlock()
+	for _, f := range fb.inputs {
+		_ = f.Close()
+	}
+	if fb.device != nil {
+		_ = fb.device.Close()
+	}
+	fb.Wake()
+}
+
+func clamp(v, min, max float64) float64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// evdevKeys maps the key codes of linux/input-event-codes.h to keys.
+var evdevKeys = map[uint16]Key{
+	1: KeyEscape, 2: Key1, 3: Key2, 4: Key3, 5: Key4, 6: Key5, 7: Key6,
+	8: Key7, 9: Key8, 10: Key9, 11: Key0, 12: KeyMinus, 13: KeyEqual,
+	14: KeyBackspace, 15: KeyTab, 16: KeyQ, 17: KeyW, 18: KeyE, 19: KeyR,
+	20: KeyT, 21: KeyY, 22: KeyU, 23: KeyI, 24: KeyO, 25: KeyP,
+	28: KeyEnter, 29: KeyLeftControl, 30: KeyA, 31: KeyS, 32: KeyD,
+	33: KeyF, 34: KeyG, 35: KeyH, 36: KeyJ, 37: KeyK, 38: KeyL,
+	39: KeySemicolon, 40: KeyApostrophe, 42: KeyLeftShift, 44: KeyZ,
+	45: KeyX, 46: KeyC, 47: KeyV, 48: KeyB, 49: KeyN, 50: KeyM,
+	51: KeyComma, 52: KeyPeriod, 53: KeySlash, 54: KeyRightShift,
+	56: KeyLeftAlt, 57: KeySpace, 59: KeyF1, 60: KeyF2, 61: KeyF3,
+	62: KeyF4, 63: KeyF5, 64: KeyF6, 65: KeyF7, 66: KeyF8, 67: KeyF9,
+	68: KeyF10, 87: KeyF11, 88: KeyF12, 96: KeyEnter, 97: KeyRightControl,
+	100: KeyRightAlt, 102: KeyHome, 103: KeyUp, 104: KeyPageUp,
+	105: KeyLeft, 106: KeyRight, 107: KeyEnd, 108: KeyDown,
+	109: KeyPageDown, 110: KeyInsert, 111: KeyDelete, 125: KeyLeftSuper,
+	126: KeyRightSuper,
+}
+
+// evdevChars maps the key codes to the runes they type on a US keyboard,
+// without and with shift.
+var evdevChars = map[uint16][2]rune{
+	2: {'1', '!'}, 3: {'2', '@'}, 4: {'3', '#'}, 5: {'4', '$'},
+	6: {'5', '%'}, 7: {'6', '^'}, 8: {'7', '&'}, 9: {'8', '*'},
+	10: {'9', '('}, 11: {'0', ')'}, 12: {'-', '_'}, 13: {'=', '+'},
+	16: {'q', 'Q'}, 17: {'w', 'W'}, 18: {'e', 'E'}, 19: {'r', 'R'},
+	20: {'t', 'T'}, 21: {'y', 'Y'}, 22: {'u', 'U'}, 23: {'i', 'I'},
+	24: {'o', 'O'}, 25: {'p', 'P'}, 26: {'[', '{'}, 27: {']', '}'},
+	30: {'a', 'A'}, 31: {'s', 'S'}, 32: {'d', 'D'}, 33: {'f', 'F'},
+	34: {'g', 'G'}, 35: {'h', 'H'}, 36: {'j', 'J'}, 37: {'k', 'K'},
+	38: {'l', 'L'}, 39: {';', ':'}, 40: {'\'', '"'}, 41: {'`', '~'},
+	43: {'\\', '|'}, 44: {'z', 'Z'}, 45: {'x', 'X'}, 46: {'c', 'C'},
+	47: {'v', 'V'}, 48: {'b', 'B'}, 49: {'n', 'N'}, 50: {'m', 'M'},
+	51: {',', '<'}, 52: {'.', '>'}, 53: {'/', '?'}, 57: {' ', ' '},
+}
@@ -0,0 +1,113 @@
+package geui
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// inputEvent encodes a struct input_event as written by evdev devices.
+func inputEvent(typ, code uint16, value int32) []byte {
+	buf := make([]byte, inputEventSize)
+	binary.LittleEndian.PutUint16(buf[inputEventSize-8:], typ)
+	binary.LittleEndian.PutUint16(buf[inputEventSize-6:], code)
+	binary.LittleEndian.PutUint32(buf[inputEventSize-4:], uint32(value))
+	return buf
+}
+
+func TestFramebufferPresent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	device := filepath.Join(dir, "fb0")
+	if err := ioutil.WriteFile(device, make([]byte, 300*175*4), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fb := NewFramebuffer(device, filepath.Join(dir, "none"))
+	fb.Width, fb.Height = 300, 175
+	node := LoadXML("testdata/main.xml")
+	node.LastChild.SetStyle("background-color:#102030")
+	w, err := NewWindow(node, WithBackend(fb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.update()
+	w.Close()
+
+	pix, err := ioutil.ReadFile(device)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pixels are stored as BGRX
+	button := node.LastChild.Bounds()
+	i := (button.Min.Y+1)*300*4 + (button.Min.X+1)*4
+	if got := pix[i : i+3]; got[0] != 0x30 || got[1] != 0x20 || got[2] != 0x10 {
+		t.Fatalf("button pixel = % x, want 30 20 10", got)
+	}
+}
+
+func TestFramebufferInput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	device := filepath.Join(dir, "fb0")
+	if err := ioutil.WriteFile(device, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var events []byte
+	for _, e := range [][]byte{
+		inputEvent(evRel, relX, -140),
+		inputEvent(evRel, relY, 100),
+		inputEvent(evSyn, 0, 0),
+		inputEvent(evKey, btnLeft, 1),
+		inputEvent(evKey, 42, 1), // left shift
+		inputEvent(evKey, 30, 1), // a
+		inputEvent(evKey, 30, 0),
+		inputEvent(evKey, 42, 0),
+	} {
+		events = append(events, e...)
+	}
+	input := filepath.Join(dir, "event0")
+	if err := ioutil.WriteFile(input, events, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fb := NewFramebuffer(device, input)
+	var got []Event
+	if err := fb.Create(Surface{Width: 300, Height: 200}, func(e Event) {
+		got = append(got, e)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer fb.Close()
+	for deadline := time.Now().Add(time.Second); fb.pending() < 7 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	fb.PollEvents()
+
+	want := []Event{
+		MouseMove{X: 10, Y: 199},
+		MouseDown{X: 10, Y: 199, MouseButton: MouseLeft},
+		KbDown{KeyLeftShift, ModShift},
+		KbDown{KeyA, ModShift},
+		KbType{'A'},
+		KbUp{KeyA, ModShift},
+		KbUp{KeyLeftShift, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got events %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
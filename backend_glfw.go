@@ -0,0 +1,361 @@
+//go:build !nogl
+// +build !nogl
+
+package geui
+
+import (
+	"image"
+	"runtime"
+	"unsafe"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+func init() {
+	runtime.LockOSThread()
+}
+
+// GLFW is the Backend presenting the canvas with OpenGL in a GLFW window.
+type GLFW struct {
+	ctx         *glfw.Window
+	handle      func(Event)
+	cursors     map[Cursor]*glfw.Cursor
+	unit        float64     // logical units per window unit
+	mouseX      float64     // pointer position in logical units
+	mouseY      float64     // pointer position in logical units
+	texture     uint32      // texture the canvas is streamed to
+	textureSize image.Point // size the texture was allocated with
+}
+
+func defaultBackend() Backend {
+	return NewGLFW()
+}
+
+// NewGLFW returns the GLFW backend.
+func NewGLFW() *GLFW {
+	return &GLFW{
+		cursors: make(map[Cursor]*glfw.Cursor),
+		unit:    1,
+	}
+}
+
+func (g *GLFW) Create(s Surface, handle func(Event)) error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+	glfw.WindowHint(glfw.ScaleToMonitor, glfw.True)
+	if s.Resizable {
+		glfw.WindowHint(glfw.Resizable, glfw.True)
+	} else {
+		glfw.WindowHint(glfw.Resizable, glfw.False)
+	}
+	if s.Borderless {
+		glfw.WindowHint(glfw.Decorated, glfw.False)
+	}
+	if s.Maximized {
+		glfw.WindowHint(glfw.Maximized, glfw.True)
+	}
+	g.ctx, err = glfw.CreateWindow(int(s.Width), int(s.Height), s.Title, nil, nil)
+	if err != nil {
+		return err
+	}
+	g.handle = handle
+	g.updateUnit()
+	g.ctx.MakeContextCurrent()
+	if err := gl.Init(); err != nil {
+		return err
+	}
+	glfw.SwapInterval(1)
+	g.initTexture()
+	g.initEvent()
+	return nil
+}
+
+// updateUnit updates the ratio of logical units to window units, in which
+// the pointer position is reported.
+func (g *GLFW) updateUnit() {
+	fw, _ := g.ctx.GetFramebufferSize()
+	ww, _ := g.ctx.GetSize()
+	g.unit = float64(fw) / float64(ww) / g.ContentScale()
+}
+
+func (g *GLFW) Size() (width, height float64) {
+	fw, fh := g.ctx.GetFramebufferSize()
+	scale := g.ContentScale()
+	return float64(fw) / scale, float64(fh) / scale
+}
+
+func (g *GLFW) ContentScale() float64 {
+	scale, _ := g.ctx.GetContentScale()
+	return float64(scale)
+}
+
+func (g *GLFW) initEvent() {
+	g.ctx.SetCursorPosCallback(func(_ *glfw.Window, x, y float64) {
+		g.mouseX, g.mouseY = x*g.unit, y*g.unit
+		g.handle(MouseMove{
+			X: g.mouseX,
+			Y: g.mouseY,
+		})
+	})
+
+	g.ctx.SetMouseButtonCallback(func(_ *glfw.Window, button glfw.MouseButton, action glfw.Action, mod glfw.ModifierKey) {
+		switch action {
+		case glfw.Press:
+			g.handle(MouseDown{
+				X:           g.mouseX,
+				Y:           g.mouseY,
+				MouseButton: glfwButton(button),
+				Modifier:    glfwModifier(mod),
+			})
+		case glfw.Release:
+			g.handle(MouseUp{
+				X:           g.mouseX,
+				Y:           g.mouseY,
+				MouseButton: glfwButton(button),
+				Modifier:    glfwModifier(mod),
+			})
+		}
+	})
+
+	g.ctx.SetScrollCallback(func(_ *glfw.Window, xoff, yoff float64) {
+		g.handle(MouseScroll{
+			X: xoff,
+			Y: yoff,
+		})
+	})
+
+	g.ctx.SetCharCallback(func(_ *glfw.Window, r rune) {
+		g.handle(KbType{
+			r,
+		})
+	})
+
+	g.ctx.SetKeyCallback(func(_ *glfw.Window, key glfw.Key, _ int, action glfw.Action, mod glfw.ModifierKey) {
+		k, m := glfwKey(key), glfwModifier(mod)
+		switch action {
+		case glfw.Press:
+			g.handle(KbDown{k, m})
+		case glfw.Release:
+			g.handle(KbUp{k, m})
+		case glfw.Repeat:
+			g.handle(KbRepeat{k, m})
+		}
+	})
+
+	g.ctx.SetFramebufferSizeCallback(func(_ *glfw.Window, _, _ int) {
+		g.updateUnit()
+		width, height := g.Size()
+		g.handle(Resize{
+			0, 0,
+			width, height,
+		})
+	})
+
+	g.ctx.SetContentScaleCallback(func(_ *glfw.Window, _, _ float32) {
+		g.updateUnit()
+		width, height := g.Size()
+		g.handle(Resize{
+			0, 0,
+			width, height,
+		})
+	})
+
+	g.ctx.SetRefreshCallback(func(_ *glfw.Window) {
+		g.draw()
+	})
+}
+
+// initTexture creates the texture the canvas is streamed to.
+func (g *GLFW) initTexture() {
+	gl.GenTextures(1, &g.texture)
+	gl.BindTexture(gl.TEXTURE_2D, g.texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.Enable(gl.TEXTURE_2D)
+}
+
+// Present uploads the region r of the canvas to the texture and draws it
+// on a quad covering the window.
+func (g *GLFW) Present(img *image.RGBA, r image.Rectangle) {
+	bounds := img.Bounds()
+	if bounds.Size() != g.textureSize {
+		gl.TexImage2D(
+			gl.TEXTURE_2D, 0, gl.RGBA,
+			int32(bounds.Dx()),
+			int32(bounds.Dy()),
+			0, gl.RGBA, gl.UNSIGNED_BYTE,
+			unsafe.Pointer(&img.Pix[0]),
+		)
+		g.textureSize = bounds.Size()
+	} else if !r.Empty() {
+		gl.PixelStorei(gl.UNPACK_ROW_LENGTH, int32(img.Stride/4))
+		gl.TexSubImage2D(
+			gl.TEXTURE_2D, 0,
+			int32(r.Min.X),
+			int32(r.Min.Y),
+			int32(r.Dx()),
+			int32(r.Dy()),
+			gl.RGBA, gl.UNSIGNED_BYTE,
+			unsafe.Pointer(&img.Pix[img.PixOffset(r.Min.X, r.Min.Y)]),
+		)
+		gl.PixelStorei(gl.UNPACK_ROW_LENGTH, 0)
+	}
+	g.draw()
+}
+
+// draw presents the texture on a quad covering the window.
+func (g *GLFW) draw() {
+	fw, fh := g.ctx.GetFramebufferSize()
+	gl.Viewport(0, 0, int32(fw), int32(fh))
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	gl.Begin(gl.QUADS)
+	gl.TexCoord2f(0, 1)
+	gl.Vertex2f(-1, -1)
+	gl.TexCoord2f(1, 1)
+	gl.Vertex2f(1, -1)
+	gl.TexCoord2f(1, 0)
+	gl.Vertex2f(1, 1)
+	gl.TexCoord2f(0, 0)
+	gl.Vertex2f(-1, 1)
+	gl.End()
+	g.ctx.SwapBuffers()
+}
+
+func (g *GLFW) PollEvents() {
+	glfw.PollEvents()
+}
+
+func (g *GLFW) WaitEvents() {
+	glfw.WaitEvents()
+}
+
+func (g *GLFW) Wake() {
+	glfw.PostEmptyEvent()
+}
+
+func (g *GLFW) Clipboard() string {
+	s, _ := g.ctx.GetClipboardString()
+	return s
+}
+
+func (g *GLFW) SetClipboard(s string) {
+	g.ctx.SetClipboardString(s)
+}
+
+func (g *GLFW) SetCursor(c Cursor) {
+	cursor, ok := g.cursors[c]
+	if !ok {
+		shape := glfw.ArrowCursor
+		switch c {
+		case IBeamCursor:
+			shape = glfw.IBeamCursor
+		case HandCursor:
+			shape = glfw.HandCursor
+		case CrosshairCursor:
+			shape = glfw.CrosshairCursor
+		case HResizeCursor:
+			shape = glfw.HResizeCursor
+		case VResizeCursor:
+			shape = glfw.VResizeCursor
+		}
+		cursor = glfw.CreateStandardCursor(shape)
+		g.cursors[c] = cursor
+	}
+	g.ctx.SetCursor(cursor)
+}
+
+func (g *GLFW) ShouldClose() bool {
+	return g.ctx.ShouldClose()
+}
+
+func (g *GLFW) Close() {
+	g.ctx.Destroy()
+	glfw.Terminate()
+}
+
+func glfwButton(b glfw.MouseButton) MouseButton {
+	switch b {
+	case glfw.MouseButtonRight:
+		return MouseRight
+	case glfw.MouseButtonMiddle:
+		return MouseMiddle
+	}
+	return MouseLeft
+}
+
+func glfwModifier(mod glfw.ModifierKey) (m Modifier) {
+	if mod&glfw.ModShift != 0 {
+		m |= ModShift
+	}
+	if mod&glfw.ModControl != 0 {
+		m |= ModControl
+	}
+	if mod&glfw.ModAlt != 0 {
+		m |= ModAlt
+	}
+	if mod&glfw.ModSuper != 0 {
+		m |= ModSuper
+	}
+	return
+}
+
+var glfwKeys = map[glfw.Key]Key{
+	glfw.KeyF1:           KeyF1,
+	glfw.KeyF2:           KeyF2,
+	glfw.KeyF3:           KeyF3,
+	glfw.KeyF4:           KeyF4,
+	glfw.KeyF5:           KeyF5,
+	glfw.KeyF6:           KeyF6,
+	glfw.KeyF7:           KeyF7,
+	glfw.KeyF8:           KeyF8,
+	glfw.KeyF9:           KeyF9,
+	glfw.KeyF10:          KeyF10,
+	glfw.KeyF11:          KeyF11,
+	glfw.KeyF12:          KeyF12,
+	glfw.KeySpace:        KeySpace,
+	glfw.KeyEnter:        KeyEnter,
+	glfw.KeyKPEnter:      KeyEnter,
+	glfw.KeyTab:          KeyTab,
+	glfw.KeyBackspace:    KeyBackspace,
+	glfw.KeyDelete:       KeyDelete,
+	glfw.KeyInsert:       KeyInsert,
+	glfw.KeyEscape:       KeyEscape,
+	glfw.KeyLeft:         KeyLeft,
+	glfw.KeyRight:        KeyRight,
+	glfw.KeyUp:           KeyUp,
+	glfw.KeyDown:         KeyDown,
+	glfw.KeyHome:         KeyHome,
+	glfw.KeyEnd:          KeyEnd,
+	glfw.KeyPageUp:       KeyPageUp,
+	glfw.KeyPageDown:     KeyPageDown,
+	glfw.KeyMinus:        KeyMinus,
+	glfw.KeyEqual:        KeyEqual,
+	glfw.KeyComma:        KeyComma,
+	glfw.KeyPeriod:       KeyPeriod,
+	glfw.KeySlash:        KeySlash,
+	glfw.KeySemicolon:    KeySemicolon,
+	glfw.KeyApostrophe:   KeyApostrophe,
+	glfw.KeyLeftShift:    KeyLeftShift,
+	glfw.KeyRightShift:   KeyRightShift,
+	glfw.KeyLeftControl:  KeyLeftControl,
+	glfw.KeyRightControl: KeyRightControl,
+	glfw.KeyLeftAlt:      KeyLeftAlt,
+	glfw.KeyRightAlt:     KeyRightAlt,
+	glfw.KeyLeftSuper:    KeyLeftSuper,
+	glfw.KeyRightSuper:   KeyRightSuper,
+}
+
+func glfwKey(k glfw.Key) Key {
+	switch {
+	case k >= glfw.KeyA && k <= glfw.KeyZ:
+		return KeyA + Key(k-glfw.KeyA)
+	case k >= glfw.Key0 && k <= glfw.Key9:
+		return Key0 + Key(k-glfw.Key0)
+	}
+	return glfwKeys[k]
+}
@@ -0,0 +1,131 @@
+package geui
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Headless is an in-memory Backend. Events are sent by the program and the
+// presented frames are kept in memory, which makes it suitable for tests
+// and for rendering without a display.
+type Headless struct {
+	*eventQueue
+	frame         *image.RGBA
+	presents      int
+	width, height float64
+	scale         float64
+	clipboard     string
+	cursor        Cursor
+	closed        bool
+}
+
+// NewHeadless returns a headless backend with a content scale of 1.
+func NewHeadless() *Headless {
+	return &Headless{
+		eventQueue: newEventQueue(),
+		scale:      1,
+	}
+}
+
+func (h *Headless) Create(s Surface, handle func(Event)) error {
+	h.handle = handle
+	h.mu.Lock()
+	h.width, h.height = s.Width, s.Height
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *Headless) Size() (width, height float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.width, h.height
+}
+
+func (h *Headless) ContentScale() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.scale
+}
+
+// SetContentScale changes the content scale as if the surface moved to
+// another monitor.
+func (h *Headless) SetContentScale(scale float64) {
+	h.mu.Lock()
+	h.scale = scale
+	width, height := h.width, h.height
+	h.mu.Unlock()
+	h.Send(Resize{Width: width, Height: height})
+}
+
+// Resize changes the logical size of the surface as the user would.
+func (h *Headless) Resize(width, height float64) {
+	h.mu.Lock()
+	h.width, h.height = width, height
+	h.mu.Unlock()
+	h.Send(Resize{Width: width, Height: height})
+}
+
+func (h *Headless) Present(img *image.RGBA, r image.Rectangle) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.frame == nil || h.frame.Bounds() != img.Bounds() {
+		h.frame = image.NewRGBA(img.Bounds())
+		r = img.Bounds()
+	}
+	draw.Draw(h.frame, r, img, r.Min, draw.Src)
+	h.presents++
+}
+
+// Frame returns the last presented frame.
+func (h *Headless) Frame() *image.RGBA {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.frame
+}
+
+// Presents returns the number of frames presented so far.
+func (h *Headless) Presents() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.presents
+}
+
+func (h *Headless) Clipboard() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.clipboard
+}
+
+func (h *Headless) SetClipboard(s string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clipboard = s
+}
+
+// Cursor returns the cursor last set by the window.
+func (h *Headless) Cursor() Cursor {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.cursor
+}
+
+func (h *Headless) SetCursor(c Cursor) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cursor = c
+}
+
+// ShouldClose reports whether Close was called and the events sent before
+// it were handled.
+func (h *Headless) ShouldClose() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.closed && len(h.events) == 0
+}
+
+func (h *Headless) Close() {
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+	h.Wake()
+}
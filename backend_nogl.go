@@ -0,0 +1,10 @@
+//go:build nogl
+// +build nogl
+
+package geui
+
+// defaultBackend returns nil: builds tagged nogl have no GLFW backend and
+// windows need WithBackend.
+func defaultBackend() Backend {
+	return nil
+}
@@ -0,0 +1,316 @@
+package geui
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// paintBackgroundImage fills the border box of the element n with its
+// background gradient or image. Images are placed in the padding box.
+func (render *Renderer) paintBackgroundImage(n *Node, op float64) {
+	st := n.Style
+	var p gg.Pattern
+	switch name, args := splitFunction(st.BackgroundImage); name {
+	case "linear-gradient":
+		p = render.linearGradient(n, args, op)
+	case "radial-gradient":
+		p = render.radialGradient(n, args, op)
+	case "url":
+		p = render.imagePattern(n, strings.Trim(strings.Join(args, ","), `"' `), op)
+	}
+	if p == nil {
+		return
+	}
+	render.rectangle(n.Model.RelativeX, n.Model.RelativeY, n.Model.Width, n.Model.Height, st.BorderRadius)
+	render.canvas.SetFillStyle(p)
+	render.canvas.Fill()
+}
+
+// splitFunction splits a function like "rgba(0, 0, 0, .5)" into its name
+// and its arguments separated by commas, minding nested functions.
+func splitFunction(s string) (name string, args []string) {
+	i := strings.IndexByte(s, '(')
+	if i < 0 || !strings.HasSuffix(s, ")") {
+		return s, nil
+	}
+	name, s = s[:i], s[i+1:len(s)-1]
+	depth, start := 0, 0
+	for j, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(s[start:j]))
+				start = j + 1
+			}
+		}
+	}
+	return name, append(args, strings.TrimSpace(s[start:]))
+}
+
+// colorStops adds the colour stops args, like "red" or "#fff 30%", to g,
+// spacing the stops without a position evenly between their neighbours.
+func colorStops(g gg.Gradient, args []string, op float64) {
+	colors := make([]color.NRGBA, len(args))
+	pos := make([]float64, len(args))
+	set := make([]bool, len(args))
+	for i, a := range args {
+		c := a
+		// the position follows the colour, outside of its parentheses
+		if j := strings.LastIndexByte(a, ' '); j > strings.LastIndexByte(a, ')') {
+			if v := a[j+1:]; isPercentage(v) {
+				pos[i], set[i] = parseLength(strings.TrimSuffix(v, "%"))/100, true
+				c = strings.TrimSpace(a[:j])
+			}
+		}
+		colors[i] = parseColor(c)
+		colors[i].A = uint8(math.Round(float64(colors[i].A) * op))
+	}
+	if len(args) > 0 && !set[0] {
+		pos[0], set[0] = 0, true
+	}
+	if len(args) > 1 && !set[len(args)-1] {
+		pos[len(args)-1], set[len(args)-1] = 1, true
+	}
+	for i := 0; i < len(args); i++ {
+		if set[i] {
+			continue
+		}
+		j := i
+		for !set[j] {
+			j++
+		}
+		for k := i; k < j; k++ {
+			pos[k] = pos[i-1] + (pos[j]-pos[i-1])*float64(k-i+1)/float64(j-i+1)
+		}
+		i = j
+	}
+	for i := range args {
+		g.AddColorStop(pos[i], colors[i])
+	}
+}
+
+// isColorStop reports whether the gradient argument a is a colour stop
+// rather than a direction or shape.
+func isColorStop(a string) bool {
+	fields := strings.Fields(a)
+	if len(fields) == 0 {
+		return false
+	}
+	switch w := fields[0]; {
+	case w == "to" || w == "at" || w == "circle" || w == "ellipse":
+		return false
+	case strings.HasSuffix(w, "deg"):
+		return !isLength(strings.TrimSuffix(w, "deg"))
+	}
+	return true
+}
+
+// linearGradient returns the gradient of the arguments of linear-gradient()
+// across the box of n, like "to right, red, blue" or "45deg, red 20%, blue".
+func (render *Renderer) linearGradient(n *Node, args []string, op float64) gg.Pattern {
+	if len(args) == 0 {
+		return nil
+	}
+	angle := 180.0
+	if !isColorStop(args[0]) {
+		angle = gradientAngle(args[0], n.Model.Width, n.Model.Height)
+		args = args[1:]
+	}
+	s := render.scale
+	w, h := n.Model.Width*s, n.Model.Height*s
+	cx, cy := n.Model.RelativeX*s+w/2, n.Model.RelativeY*s+h/2
+	// the gradient line passes through the center, long enough for the
+	// corners to take the colours of its ends
+	a := angle * math.Pi / 180
+	dx, dy := math.Sin(a), -math.Cos(a)
+	l := (math.Abs(w*dx) + math.Abs(h*dy)) / 2
+	g := gg.NewLinearGradient(cx-dx*l, cy-dy*l, cx+dx*l, cy+dy*l)
+	colorStops(g, args, op)
+	return g
+}
+
+// gradientAngle returns the angle in degrees of a gradient direction like
+// "45deg" or "to top right" in a box of w by h, clockwise from the top.
+func gradientAngle(dir string, w, h float64) float64 {
+	if strings.HasSuffix(dir, "deg") {
+		return parseLength(strings.TrimSuffix(dir, "deg"))
+	}
+	var x, y float64
+	for _, side := range strings.Fields(dir) {
+		switch side {
+		case "top":
+			y = -1
+		case "bottom":
+			y = 1
+		case "left":
+			x = -1
+		case "right":
+			x = 1
+		}
+	}
+	if x != 0 && y != 0 {
+		// corners are perpendicular to the diagonal between the others
+		return math.Atan2(x*h, -y*w) * 180 / math.Pi
+	}
+	return math.Atan2(x, -y) * 180 / math.Pi
+}
+
+// radialGradient returns the circular gradient of the arguments of
+// radial-gradient() in the box of n, like "circle at top left, red, blue".
+// It reaches the farthest corner of the box.
+func (render *Renderer) radialGradient(n *Node, args []string, op float64) gg.Pattern {
+	if len(args) == 0 {
+		return nil
+	}
+	cx, cy := "center", "center"
+	if !isColorStop(args[0]) {
+		if i := strings.Index(args[0], "at "); i >= 0 {
+			cx, cy = positionValues(args[0][i+3:])
+		}
+		args = args[1:]
+	}
+	s := render.scale
+	left, top := n.Model.RelativeX*s, n.Model.RelativeY*s
+	w, h := n.Model.Width*s, n.Model.Height*s
+	x := left + positionOffset(cx, w, 0, s)
+	y := top + positionOffset(cy, h, 0, s)
+	r := math.Hypot(math.Max(x-left, left+w-x), math.Max(y-top, top+h-y))
+	g := gg.NewRadialGradient(x, y, 0, x, y, r)
+	colorStops(g, args, op)
+	return g
+}
+
+// positionValues splits a position like "left", "right 20%" or "top" into
+// its horizontal and vertical parts. Missing parts are "center".
+func positionValues(s string) (x, y string) {
+	x, y = "center", "center"
+	values := strings.Fields(s)
+	switch len(values) {
+	case 0:
+	case 1:
+		if values[0] == "top" || values[0] == "bottom" {
+			y = values[0]
+		} else {
+			x = values[0]
+		}
+	default:
+		x, y = values[0], values[1]
+		if x == "top" || x == "bottom" || y == "left" || y == "right" {
+			x, y = y, x
+		}
+	}
+	return
+}
+
+// positionOffset returns the offset in device pixels of a tile of size
+// tile placed at v, like "right", "20%" or "10px", in an area of size
+// area.
+func positionOffset(v string, area, tile, scale float64) float64 {
+	switch v {
+	case "left", "top":
+		return 0
+	case "center":
+		return (area - tile) / 2
+	case "right", "bottom":
+		return area - tile
+	}
+	if isPercentage(v) {
+		return (area - tile) * parseLength(strings.TrimSuffix(v, "%")) / 100
+	}
+	return parseLength(v) * scale
+}
+
+// imagePattern returns the image file at path placed in the padding box of
+// n as in the background-size, -position and -repeat of n.
+func (render *Renderer) imagePattern(n *Node, path string, op float64) gg.Pattern {
+	iw, ih := n.images().size(path)
+	if iw == 0 || ih == 0 {
+		return nil
+	}
+	st, s := n.Style, render.scale
+	b := st.borderEdges()
+	x := (n.Model.RelativeX + b.Left) * s
+	y := (n.Model.RelativeY + b.Top) * s
+	w := (n.Model.Width - b.Left - b.Right) * s
+	h := (n.Model.Height - b.Top - b.Bottom) * s
+
+	tw, th := iw*s, ih*s
+	switch size := strings.Fields(st.BackgroundSize); {
+	case len(size) == 0:
+	case size[0] == "cover" || size[0] == "contain":
+		k := math.Max(w/tw, h/th)
+		if size[0] == "contain" {
+			k = math.Min(w/tw, h/th)
+		}
+		tw, th = tw*k, th*k
+	default:
+		length := func(v string, area float64) float64 {
+			if isPercentage(v) {
+				return area * parseLength(strings.TrimSuffix(v, "%")) / 100
+			}
+			return parseLength(v) * s
+		}
+		size = append(size, "auto")
+		switch {
+		case size[0] != "auto" && size[1] != "auto":
+			tw, th = length(size[0], w), length(size[1], h)
+		case size[0] != "auto":
+			tw, th = length(size[0], w), th*length(size[0], w)/tw
+		case size[1] != "auto":
+			tw, th = tw*length(size[1], h)/th, length(size[1], h)
+		}
+	}
+	im := n.images().image(path, int(math.Round(tw)), int(math.Round(th)))
+	if im == nil {
+		return nil
+	}
+	px, py := positionValues(st.BackgroundPosition)
+	if st.BackgroundPosition == "" {
+		px, py = "left", "top"
+	}
+	return &imagePattern{
+		im:      im,
+		x:       int(math.Round(x + positionOffset(px, w, float64(im.Rect.Dx()), s))),
+		y:       int(math.Round(y + positionOffset(py, h, float64(im.Rect.Dy()), s))),
+		repeatX: st.BackgroundRepeat == "repeat" || st.BackgroundRepeat == "repeat-x",
+		repeatY: st.BackgroundRepeat == "repeat" || st.BackgroundRepeat == "repeat-y",
+		opacity: op,
+	}
+}
+
+// imagePattern is an image with its top left corner at x, y, repeated
+// horizontally or vertically, faded by opacity.
+type imagePattern struct {
+	im               *image.RGBA
+	x, y             int
+	repeatX, repeatY bool
+	opacity          float64
+}
+
+func (p *imagePattern) ColorAt(x, y int) color.Color {
+	w, h := p.im.Rect.Dx(), p.im.Rect.Dy()
+	x, y = x-p.x, y-p.y
+	if p.repeatX {
+		x = (x%w + w) % w
+	}
+	if p.repeatY {
+		y = (y%h + h) % h
+	}
+	if x < 0 || y < 0 || x >= w || y >= h {
+		return color.Transparent
+	}
+	c := p.im.RGBAAt(x, y)
+	if p.opacity < 1 {
+		k := p.opacity
+		c = color.RGBA{uint8(float64(c.R) * k), uint8(float64(c.G) * k), uint8(float64(c.B) * k), uint8(float64(c.A) * k)}
+	}
+	return c
+}
@@ -0,0 +1,406 @@
+package geui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// colorNames are the colour keywords understood besides hex colours.
+var colorNames = map[string]color.NRGBA{
+	"transparent": {},
+	"black":       {0, 0, 0, 0xff},
+	"white":       {0xff, 0xff, 0xff, 0xff},
+	"gray":        {0x80, 0x80, 0x80, 0xff},
+	"grey":        {0x80, 0x80, 0x80, 0xff},
+	"silver":      {0xc0, 0xc0, 0xc0, 0xff},
+	"lightgray":   {0xd3, 0xd3, 0xd3, 0xff},
+	"lightgrey":   {0xd3, 0xd3, 0xd3, 0xff},
+	"darkgray":    {0xa9, 0xa9, 0xa9, 0xff},
+	"darkgrey":    {0xa9, 0xa9, 0xa9, 0xff},
+	"red":         {0xff, 0, 0, 0xff},
+	"maroon":      {0x80, 0, 0, 0xff},
+	"orange":      {0xff, 0xa5, 0, 0xff},
+	"gold":        {0xff, 0xd7, 0, 0xff},
+	"yellow":      {0xff, 0xff, 0, 0xff},
+	"olive":       {0x80, 0x80, 0, 0xff},
+	"lime":        {0, 0xff, 0, 0xff},
+	"green":       {0, 0x80, 0, 0xff},
+	"teal":        {0, 0x80, 0x80, 0xff},
+	"aqua":        {0, 0xff, 0xff, 0xff},
+	"cyan":        {0, 0xff, 0xff, 0xff},
+	"blue":        {0, 0, 0xff, 0xff},
+	"navy":        {0, 0, 0x80, 0xff},
+	"indigo":      {0x4b, 0, 0x82, 0xff},
+	"purple":      {0x80, 0, 0x80, 0xff},
+	"violet":      {0xee, 0x82, 0xee, 0xff},
+	"fuchsia":     {0xff, 0, 0xff, 0xff},
+	"magenta":     {0xff, 0, 0xff, 0xff},
+	"pink":        {0xff, 0xc0, 0xcb, 0xff},
+	"brown":       {0xa5, 0x2a, 0x2a, 0xff},
+}
+
+// parseColor parses a colour like "#rgb", "#rrggbb", "#rrggbbaa",
+// "rgba(0, 0, 0, .5)", "hsl(120, 50%, 50%)" or a colour keyword. Unknown
+// colours are black.
+func parseColor(s string) color.NRGBA {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := colorNames[s]; ok {
+		return c
+	}
+	if i := strings.IndexByte(s, '('); i > 0 && strings.HasSuffix(s, ")") {
+		return parseColorFunction(s[:i], colorArgs(s[i+1:len(s)-1]))
+	}
+	h := strings.TrimPrefix(s, "#")
+	if len(h) == 3 || len(h) == 4 {
+		var b strings.Builder
+		for _, r := range h {
+			b.WriteRune(r)
+			b.WriteRune(r)
+		}
+		h = b.String()
+	}
+	if len(h) == 6 {
+		h += "ff"
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil || len(h) != 8 {
+		return color.NRGBA{A: 0xff}
+	}
+	return color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}
+}
+
+// colorArgs splits the arguments of a colour function, given with commas
+// like "0, 0, 0, .5" or with spaces like "0 0 0 / 50%".
+func colorArgs(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '/' || r == ' '
+	})
+}
+
+// parseColorFunction returns the colour of the function rgb, rgba, hsl or
+// hsla applied to args. Unknown functions are black.
+func parseColorFunction(name string, args []string) color.NRGBA {
+	if len(args) < 3 {
+		return color.NRGBA{A: 0xff}
+	}
+	a := 1.0
+	if len(args) > 3 {
+		a = colorNumber(args[3], 1)
+	}
+	var r, g, b float64
+	switch name {
+	case "rgb", "rgba":
+		r, g, b = colorNumber(args[0], 255), colorNumber(args[1], 255), colorNumber(args[2], 255)
+	case "hsl", "hsla":
+		h := parseLength(strings.TrimSuffix(args[0], "deg"))
+		r, g, b = hsl(h, colorNumber(args[1], 1), colorNumber(args[2], 1))
+		r, g, b = r*255, g*255, b*255
+	default:
+		return color.NRGBA{A: 0xff}
+	}
+	channel := func(v, max float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(max, v))))
+	}
+	return color.NRGBA{channel(r, 255), channel(g, 255), channel(b, 255), channel(a*255, 255)}
+}
+
+// colorNumber parses a colour channel, a number up to max or a percentage
+// of max.
+func colorNumber(v string, max float64) float64 {
+	if strings.HasSuffix(v, "%") {
+		return parseLength(strings.TrimSuffix(v, "%")) / 100 * max
+	}
+	return parseLength(v)
+}
+
+// hsl converts the hue h in degrees, saturation s and lightness l, from 0
+// to 1, to red, green and blue from 0 to 1.
+func hsl(h, s, l float64) (r, g, b float64) {
+	h = math.Mod(math.Mod(h, 360)+360, 360) / 60
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h, 2)-1))
+	switch int(h) {
+	case 0:
+		r, g = c, x
+	case 1:
+		r, g = x, c
+	case 2:
+		g, b = c, x
+	case 3:
+		g, b = x, c
+	case 4:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := l - c/2
+	return r + m, g + m, b + m
+}
+
+// opacity returns the opacity of the node, which fades its descendants too.
+func (n *Node) opacity() float64 {
+	o := 1.0
+	for ; n != nil; n = n.Parent {
+		if n.Type == ElementNode {
+			o *= n.animatedNumber("opacity", n.Style.Opacity)
+		}
+	}
+	return o
+}
+
+// setColor sets the colour of the next fill or stroke, faded by opacity.
+func (render *Renderer) setColor(s string, opacity float64) {
+	c := parseColor(s)
+	c.A = uint8(math.Round(float64(c.A) * opacity))
+	render.canvas.SetColor(c)
+}
+
+// setBorderStyle sets the width and dashes of the next stroke for b.
+func (render *Renderer) setBorderStyle(b Border, opacity float64) {
+	w := b.Width * render.scale
+	render.setColor(b.Color, opacity)
+	render.canvas.SetLineWidth(w)
+	render.canvas.SetLineCapButt()
+	switch b.Style {
+	case "dashed":
+		render.canvas.SetDash(3*w, 3*w)
+	case "dotted":
+		render.canvas.SetDash(w, w)
+	default:
+		render.canvas.SetDash()
+	}
+}
+
+// paintBox paints the shadow, background, borders and focus outline of the
+// element n.
+func (render *Renderer) paintBox(n *Node) {
+	st := n.Style
+	op := n.opacity()
+	x, y, w, h := n.Model.RelativeX, n.Model.RelativeY, n.Model.Width, n.Model.Height
+	if st.BoxShadow.Color != "" {
+		render.paintShadow(n, op)
+	}
+
+	render.rectangle(x, y, w, h, st.BorderRadius)
+	render.setColor(n.background(), op)
+	render.canvas.Fill()
+	if st.BackgroundImage != "" {
+		render.paintBackgroundImage(n, op)
+	}
+
+	render.paintBorders(n, op)
+
+	if n.focused && st.Outline.Width > 0 {
+		o := st.OutlineOffset + st.Outline.Width/2
+		r := st.BorderRadius
+		if r > 0 {
+			r += o
+		}
+		render.rectangle(x-o, y-o, w+2*o, h+2*o, r)
+		render.setBorderStyle(st.Outline, op)
+		render.canvas.Stroke()
+	}
+	render.canvas.SetDash()
+}
+
+// rectangle adds a rectangle with corners of radius r to the path.
+func (render *Renderer) rectangle(x, y, w, h, r float64) {
+	if r > 0 {
+		render.canvas.DrawRoundedRectangle(x, y, w, h, math.Min(r, math.Min(w, h)/2))
+	} else {
+		render.canvas.DrawRectangle(x, y, w, h)
+	}
+}
+
+// paintBorders draws the borders of n. The sides of rounded boxes follow
+// the corners, meeting on their diagonals.
+func (render *Renderer) paintBorders(n *Node, op float64) {
+	st := n.Style
+	x, y, w, h := n.Model.RelativeX, n.Model.RelativeY, n.Model.Width, n.Model.Height
+	e := st.borderEdges()
+	outer := [4]gg.Point{{X: x, Y: y}, {X: x + w, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h}}
+	inner := [4]gg.Point{
+		{X: x + e.Left, Y: y + e.Top},
+		{X: x + w - e.Right, Y: y + e.Top},
+		{X: x + w - e.Right, Y: y + h - e.Bottom},
+		{X: x + e.Left, Y: y + h - e.Bottom},
+	}
+	if st.BorderRadius > 0 {
+		sides := st.borders()
+		same := *sides[0] == *sides[1] && *sides[0] == *sides[2] && *sides[0] == *sides[3]
+		for i, b := range sides {
+			if b.Width <= 0 {
+				continue
+			}
+			render.canvas.Push()
+			if !same {
+				// the side is the part of the rounded border in its trapezoid
+				j := (i + 1) % 4
+				render.canvas.MoveTo(outer[i].X, outer[i].Y)
+				render.canvas.LineTo(outer[j].X, outer[j].Y)
+				render.canvas.LineTo(inner[j].X, inner[j].Y)
+				render.canvas.LineTo(inner[i].X, inner[i].Y)
+				render.canvas.ClosePath()
+				render.canvas.Clip()
+			}
+			render.rectangle(x+b.Width/2, y+b.Width/2, w-b.Width, h-b.Width, st.BorderRadius-b.Width/2)
+			render.setBorderStyle(*b, op)
+			render.canvas.Stroke()
+			render.canvas.Pop()
+			if same {
+				break
+			}
+			// Pop leaves the clip of the side
+			render.setClip(render.clip())
+		}
+		return
+	}
+
+	for i, b := range st.borders() {
+		if b.Width <= 0 {
+			continue
+		}
+		j := (i + 1) % 4
+		if b.Style == "solid" {
+			// trapezoids meet at the corners
+			render.canvas.MoveTo(outer[i].X, outer[i].Y)
+			render.canvas.LineTo(outer[j].X, outer[j].Y)
+			render.canvas.LineTo(inner[j].X, inner[j].Y)
+			render.canvas.LineTo(inner[i].X, inner[i].Y)
+			render.canvas.ClosePath()
+			render.setColor(b.Color, op)
+			render.canvas.Fill()
+			continue
+		}
+		// stroke along the middle of the side
+		a := gg.Point{X: (outer[i].X + inner[i].X) / 2, Y: (outer[i].Y + inner[i].Y) / 2}
+		c := gg.Point{X: (outer[j].X + inner[j].X) / 2, Y: (outer[j].Y + inner[j].Y) / 2}
+		render.canvas.DrawLine(a.X, a.Y, c.X, c.Y)
+		render.setBorderStyle(*b, op)
+		render.canvas.Stroke()
+	}
+}
+
+// paintShadow paints the blurred shadow of n outside of its box.
+func (render *Renderer) paintShadow(n *Node, op float64) {
+	sh, s := n.Style.BoxShadow, render.scale
+	x := (n.Model.RelativeX + sh.X - sh.Spread) * s
+	y := (n.Model.RelativeY + sh.Y - sh.Spread) * s
+	w := (n.Model.Width + 2*sh.Spread) * s
+	h := (n.Model.Height + 2*sh.Spread) * s
+	blur := int(math.Ceil(sh.Blur * s))
+	r := image.Rect(int(x)-blur, int(y)-blur, int(math.Ceil(x+w))+blur, int(math.Ceil(y+h))+blur)
+	clip := r.Intersect(render.clip())
+	transformed := n.transformed()
+	if clip.Empty() && !transformed {
+		return
+	}
+
+	dc := gg.NewContext(r.Dx(), r.Dy())
+	radius := 0.0
+	if n.Style.BorderRadius > 0 {
+		radius = (n.Style.BorderRadius + sh.Spread) * s
+	}
+	if radius > 0 {
+		dc.DrawRoundedRectangle(x-float64(r.Min.X), y-float64(r.Min.Y), w, h, radius)
+	} else {
+		dc.DrawRectangle(x-float64(r.Min.X), y-float64(r.Min.Y), w, h)
+	}
+	dc.Fill()
+	mask := image.NewAlpha(r)
+	draw.Draw(mask, r, dc.Image(), image.Point{}, draw.Src)
+	// three box blurs come close to the gaussian blur of CSS, whose
+	// standard deviation is half of the blur radius
+	if k := int(math.Round(sh.Blur * s / 3)); k > 0 {
+		for i := 0; i < 3; i++ {
+			blurAlpha(mask, k)
+		}
+	}
+	// the shadow is cast outside of the box only
+	draw.Draw(dc.Image().(*image.RGBA), dc.Image().Bounds(), image.Transparent, image.Point{}, draw.Src)
+	bx, by := n.Model.RelativeX*s-float64(r.Min.X), n.Model.RelativeY*s-float64(r.Min.Y)
+	if n.Style.BorderRadius > 0 {
+		dc.DrawRoundedRectangle(bx, by, n.Model.Width*s, n.Model.Height*s, n.Style.BorderRadius*s)
+	} else {
+		dc.DrawRectangle(bx, by, n.Model.Width*s, n.Model.Height*s)
+	}
+	dc.Fill()
+	box := dc.Image().(*image.RGBA)
+	for i := range mask.Pix {
+		y, x := i/mask.Stride, i%mask.Stride
+		mask.Pix[i] = uint8(int(mask.Pix[i]) * (255 - int(box.Pix[y*box.Stride+x*4+3])) / 255)
+	}
+
+	c := parseColor(sh.Color)
+	c.A = uint8(math.Round(float64(c.A) * op))
+	if transformed {
+		im := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+		draw.DrawMask(im, im.Rect, image.NewUniform(c), image.Point{}, mask, r.Min, draw.Src)
+		render.drawImage(im, r.Min.X, r.Min.Y)
+		return
+	}
+	draw.DrawMask(render.Image(), clip, image.NewUniform(c), image.Point{}, mask, clip.Min, draw.Over)
+}
+
+// blurAlpha applies a box blur of radius k to a, horizontally then
+// vertically.
+func blurAlpha(a *image.Alpha, k int) {
+	b := a.Bounds()
+	line := make([]int, 0, b.Dx()+b.Dy())
+	blur := func(get func(i int) uint8, set func(i int, v uint8), n int) {
+		line = line[:0]
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += int(get(i))
+			line = append(line, sum)
+		}
+		for i := 0; i < n; i++ {
+			lo, hi := i-k-1, i+k
+			if hi >= n {
+				hi = n - 1
+			}
+			v := line[hi]
+			if lo >= 0 {
+				v -= line[lo]
+			}
+			set(i, uint8(v/(2*k+1)))
+		}
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		blur(
+			func(i int) uint8 { return a.AlphaAt(b.Min.X+i, y).A },
+			func(i int, v uint8) { a.SetAlpha(b.Min.X+i, y, color.Alpha{A: v}) },
+			b.Dx(),
+		)
+	}
+	for x := b.Min.X; x < b.Max.X; x++ {
+		blur(
+			func(i int) uint8 { return a.AlphaAt(x, b.Min.Y+i).A },
+			func(i int, v uint8) { a.SetAlpha(x, b.Min.Y+i, color.Alpha{A: v}) },
+			b.Dy(),
+		)
+	}
+}
+
+// boxArea returns the region the box of the element n paints on, which
+// extends beyond its bounds with its shadow and outline.
+func (n *Node) boxArea() image.Rectangle {
+	r := n.Bounds()
+	st := n.Style
+	if st.Outline.Width > 0 {
+		r = r.Inset(-int(math.Ceil(st.OutlineOffset + st.Outline.Width)))
+	}
+	if sh := st.BoxShadow; sh.Color != "" {
+		shadow := n.Bounds().
+			Inset(-int(math.Ceil(sh.Spread + sh.Blur))).
+			Add(image.Pt(int(math.Floor(sh.X)), int(math.Floor(sh.Y))))
+		r = r.Union(shadow.Inset(-1))
+	}
+	return r
+}
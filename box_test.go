@@ -0,0 +1,166 @@
+package geui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := map[string]color.NRGBA{
+		"#ff0000":     {0xff, 0, 0, 0xff},
+		"#0f08":       {0, 0xff, 0, 0x88},
+		"#11223344":   {0x11, 0x22, 0x33, 0x44},
+		"transparent": {},
+		"White":       {0xff, 0xff, 0xff, 0xff},
+		"orange":      {0xff, 0xa5, 0, 0xff},
+
+		"rgb(255, 0, 128)":        {0xff, 0, 0x80, 0xff},
+		"rgba(0, 0, 0, .5)":       {0, 0, 0, 0x80},
+		"rgb(0 100% 0 / 25%)":     {0, 0xff, 0, 0x40},
+		"hsl(120, 100%, 50%)":     {0, 0xff, 0, 0xff},
+		"hsla(240, 100%, 50%, 1)": {0, 0, 0xff, 0xff},
+	}
+	for s, want := range tests {
+		if got := parseColor(s); got != want {
+			t.Errorf("parseColor(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestBoxStyle(t *testing.T) {
+	n := &Node{Type: ElementNode, Style: NewStyle(), Model: new(Model)}
+	n.SetStyle("padding: 1px 2px 3px; margin-left: -4px; border: 2px dashed #fff; " +
+		"border-left: none; box-shadow: 1px 2px 3px #000; opacity: 0.5; outline: 1px solid red")
+	st := n.Style
+	if want := (Edges{1, 2, 3, 2}); st.Padding != want {
+		t.Errorf("padding = %v, want %v", st.Padding, want)
+	}
+	if st.Margin.Left != -4 {
+		t.Errorf("left margin = %v, want -4", st.Margin.Left)
+	}
+	if want := (Border{2, "#fff", "dashed"}); st.BorderTop != want || st.BorderLeft != (Border{}) {
+		t.Errorf("borders = %v, %v, want %v and none", st.BorderTop, st.BorderLeft, want)
+	}
+	if want := (Shadow{1, 2, 3, 0, "#000"}); st.BoxShadow != want {
+		t.Errorf("shadow = %v, want %v", st.BoxShadow, want)
+	}
+	if st.Opacity != 0.5 || st.Outline.Color != "red" {
+		t.Errorf("opacity %v, outline %v", st.Opacity, st.Outline)
+	}
+}
+
+func TestBackgroundStyle(t *testing.T) {
+	n := &Node{Type: ElementNode, Style: NewStyle(), Model: new(Model)}
+	n.SetStyle("background: url(a.png) right 10px / cover no-repeat rgba(0, 0, 0, .5)")
+	st := n.Style
+	if st.BackgroundImage != "url(a.png)" || st.BackgroundColor != "rgba(0, 0, 0, .5)" ||
+		st.BackgroundPosition != "right 10px" || st.BackgroundSize != "cover" || st.BackgroundRepeat != "no-repeat" {
+		t.Errorf("background = %q %q %q %q %q", st.BackgroundImage, st.BackgroundColor,
+			st.BackgroundPosition, st.BackgroundSize, st.BackgroundRepeat)
+	}
+	n.SetStyle("background-image: linear-gradient(to right, rgba(255, 0, 0, 1) 20%, blue)")
+	if want := "linear-gradient(to right, rgba(255, 0, 0, 1) 20%, blue)"; st.BackgroundImage != want {
+		t.Errorf("background-image = %q, want %q", st.BackgroundImage, want)
+	}
+}
+
+func TestLayoutBox(t *testing.T) {
+	n := LoadXML("testdata/main.xml")
+	n.SetStyle("padding: 5px; border-width: 5px")
+	label := n.FirstChild
+	label.SetStyle("margin: 20px; padding: 10px; height: auto; line-height: 30px")
+	layoutRoot(n, n.Model.Width, n.Model.Height)
+	if got, want := label.Bounds(), image.Rect(30, 30, 270, 80); got != want {
+		t.Fatalf("label bounds = %v, want %v", got, want)
+	}
+	if input := label.NextSibling; input.Model.RelativeY != 100 {
+		t.Fatalf("input is at y %v, want 100 after the collapsed margin", input.Model.RelativeY)
+	}
+	if x, y, w, h := label.content(); x != 40 || y != 40 || w != 220 || h != 30 {
+		t.Fatalf("label content box = %v,%v %vx%v", x, y, w, h)
+	}
+}
+
+func TestPaintBox(t *testing.T) {
+	n := LoadXML("testdata/main.xml")
+	button := n.LastChild
+	button.SetStyle("border-left: 4px solid #ff0000; box-shadow: 0 10px #0000ff")
+	r := NewRenderer(n)
+	r.Paint(r.Image().Bounds())
+	img := r.Image()
+	b := button.Bounds()
+	if c := img.RGBAAt(b.Min.X+2, b.Min.Y+20); c != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Errorf("left border pixel = %v, want red", c)
+	}
+	if c := img.RGBAAt(b.Min.X+20, b.Max.Y+5); c != (color.RGBA{B: 0xff, A: 0xff}) {
+		t.Errorf("shadow pixel = %v, want blue", c)
+	}
+	if area := button.area(); !image.Pt(b.Min.X+20, b.Max.Y+5).In(area) {
+		t.Errorf("area %v does not cover the shadow", area)
+	}
+
+	// the shadow is not cast under a translucent box
+	button.SetStyle("border: none; background-color: transparent; box-shadow: 0 0 0 5px #0000ff")
+	r.Paint(r.Image().Bounds())
+	if c := img.RGBAAt(b.Min.X+20, b.Min.Y+20); c == (color.RGBA{B: 0xff, A: 0xff}) {
+		t.Errorf("pixel in the box = %v, want no shadow", c)
+	}
+	if c := img.RGBAAt(b.Min.X+20, b.Max.Y+2); c != (color.RGBA{B: 0xff, A: 0xff}) {
+		t.Errorf("spread shadow pixel = %v, want blue", c)
+	}
+
+	// each side of a rounded box has its own border
+	button.SetStyle("box-shadow: none; border-radius: 10px; border: 4px solid #ff0000; border-bottom: 6px solid #00ff00")
+	r.Paint(r.Image().Bounds())
+	if c := img.RGBAAt(b.Min.X+b.Dx()/2, b.Min.Y+1); c != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Errorf("top border pixel = %v, want red", c)
+	}
+	if c := img.RGBAAt(b.Min.X+b.Dx()/2, b.Max.Y-5); c != (color.RGBA{G: 0xff, A: 0xff}) {
+		t.Errorf("bottom border pixel = %v, want green", c)
+	}
+
+	// the deprecated border fields set all sides
+	button.Style.BorderWidth, button.Style.BorderColor = 3, "#0000ff"
+	layoutRoot(n, n.Model.Width, n.Model.Height)
+	if st := button.Style; st.BorderLeft != (Border{3, "#0000ff", "solid"}) || st.BorderBottom != st.BorderLeft {
+		t.Errorf("borders %v and %v, want the deprecated width and colour", st.BorderLeft, st.BorderBottom)
+	}
+}
+
+func TestPaintBackground(t *testing.T) {
+	n := LoadXML("testdata/main.xml")
+	button := n.LastChild
+	button.SetStyle("background: linear-gradient(to right, #ff0000, #0000ff)")
+	r := NewRenderer(n)
+	r.Paint(r.Image().Bounds())
+	b := button.Bounds()
+	if c := r.Image().RGBAAt(b.Min.X, b.Min.Y+10); c.R < 0xf0 || c.B > 0x10 {
+		t.Errorf("left of the gradient = %v, want red", c)
+	}
+	if c := r.Image().RGBAAt(b.Max.X-1, b.Min.Y+10); c.B < 0xf0 || c.R > 0x10 {
+		t.Errorf("right of the gradient = %v, want blue", c)
+	}
+
+	path := filepath.Join(t.TempDir(), "dot.png")
+	im := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	draw.Draw(im, im.Rect, image.NewUniform(color.RGBA{G: 0xff, A: 0xff}), image.Point{}, draw.Src)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(f, im)
+	f.Close()
+	button.SetStyle("background: #ffffff url(" + path + ") right bottom / 10px no-repeat")
+	r.Paint(r.Image().Bounds())
+	if c := r.Image().RGBAAt(b.Max.X-5, b.Max.Y-5); c != (color.RGBA{G: 0xff, A: 0xff}) {
+		t.Errorf("corner of the image = %v, want green", c)
+	}
+	if c := r.Image().RGBAAt(b.Max.X-15, b.Max.Y-5); c != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("outside of the image = %v, want white", c)
+	}
+}
@@ -0,0 +1,305 @@
+package geui
+
+import (
+	"image/color"
+	"sort"
+	"strings"
+)
+
+// An Element builds an element of a node tree in Go instead of XML, e.g.
+//
+//	root := geui.Document(geui.Attrs{"name": "Hello"},
+//		geui.Label("Hello").FontSize(20),
+//		geui.Input().ID("email"),
+//		geui.Button("Submit").Height(50).OnClick(submit),
+//	).Width(300).Build()
+//
+// Build makes the same tree LoadXML does from the XML document with the
+// same elements, attributes and text, the styles set with the methods of
+// Element being those of the style attribute after the other attributes.
+type Element struct {
+	tag      string
+	text     string      // of a text node, whose tag is empty
+	attrs    [][2]string // attributes in the order they were set
+	style    []string    // declarations of the style attribute
+	children []*Element
+	on       handlers // functions of the node
+}
+
+// Content is what an element is built with: Attrs, Text or child
+// elements.
+type Content interface {
+	addTo(e *Element)
+}
+
+// Attrs are attributes of an element, set in the order of their names.
+type Attrs map[string]string
+
+func (a Attrs) addTo(e *Element) {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e.Attr(k, a[k])
+	}
+}
+
+// Text is the text content of an element.
+type Text string
+
+func (t Text) addTo(e *Element) {
+	e.children = append(e.children, &Element{text: string(t)})
+}
+
+func (c *Element) addTo(e *Element) {
+	e.children = append(e.children, c)
+}
+
+// El returns an element with the given tag and content.
+func El(tag string, content ...Content) *Element {
+	e := &Element{tag: tag}
+	for _, c := range content {
+		c.addTo(e)
+	}
+	return e
+}
+
+// Document returns a <window> element, the root of a document. It is not
+// named after its tag like the other elements since Window is the type of
+// the window showing the tree.
+func Document(content ...Content) *Element {
+	return El("window", content...)
+}
+
+// Box returns a <box> element.
+func Box(content ...Content) *Element {
+	return El("box", content...)
+}
+
+// Label returns a <label> element showing text.
+func Label(text string, content ...Content) *Element {
+	return El("label", append([]Content{Text(text)}, content...)...)
+}
+
+// Input returns an <input> element.
+func Input(content ...Content) *Element {
+	return El("input", content...)
+}
+
+// Button returns a <button> element showing text.
+func Button(text string, content ...Content) *Element {
+	return El("button", append([]Content{Text(text)}, content...)...)
+}
+
+// Attr sets the attribute key of e to v.
+func (e *Element) Attr(key, v string) *Element {
+	e.attrs = append(e.attrs, [2]string{key, v})
+	return e
+}
+
+// ID sets the id of e.
+func (e *Element) ID(id string) *Element { return e.Attr("id", id) }
+
+// Name sets the name of e.
+func (e *Element) Name(name string) *Element { return e.Attr("name", name) }
+
+// Key sets the key e is matched by when a tree is reconciled, see
+// Node.Reconcile.
+func (e *Element) Key(key string) *Element { return e.Attr("key", key) }
+
+// Value sets the value of e, like the text of an input.
+func (e *Element) Value(v string) *Element { return e.Attr("value", v) }
+
+// XY places e at x, y in its parent.
+func (e *Element) XY(x, y float64) *Element {
+	return e.Attr("xy", formatLength(x)+","+formatLength(y))
+}
+
+// Style adds the declarations of an inline style, e.g. "overflow:auto",
+// for the properties without a method.
+func (e *Element) Style(css string) *Element {
+	e.style = append(e.style, css)
+	return e
+}
+
+// OnClick sets the function called when the user clicks e or one of its
+// descendants, see Node.OnClick.
+func (e *Element) OnClick(f func()) *Element {
+	e.on.click = f
+	return e
+}
+
+// OnChange sets the function called with the new value when the user
+// changes the value of e, see Node.OnChange.
+func (e *Element) OnChange(f func(value string)) *Element {
+	e.on.change = f
+	return e
+}
+
+// OnInput sets the function called with the new value whenever the user
+// edits the value of e, see Node.OnInput.
+func (e *Element) OnInput(f func(value string)) *Element {
+	e.on.input = f
+	return e
+}
+
+// Width sets the width of e, 0 sizing it to its parent.
+func (e *Element) Width(v float64) *Element { return e.Style("width:" + formatPixels(v)) }
+
+// Height sets the height of e, 0 sizing it to its content.
+func (e *Element) Height(v float64) *Element { return e.Style("height:" + formatPixels(v)) }
+
+// Margin sets the margins of e.
+func (e *Element) Margin(m Edges) *Element { return e.Style("margin:" + formatEdges(m)) }
+
+// Padding sets the padding of e.
+func (e *Element) Padding(p Edges) *Element { return e.Style("padding:" + formatEdges(p)) }
+
+// Border sets the border of every side of e.
+func (e *Element) Border(b Border) *Element {
+	if b.Style == "" {
+		b.Style = "solid"
+	}
+	css := "border:" + formatPixels(b.Width) + " " + b.Style
+	if b.Color != "" {
+		css += " " + b.Color
+	}
+	return e.Style(css)
+}
+
+// BorderRadius sets the radius of the corners of e.
+func (e *Element) BorderRadius(v float64) *Element {
+	return e.Style("border-radius:" + formatPixels(v))
+}
+
+// BackgroundColor sets the background colour of e.
+func (e *Element) BackgroundColor(c color.Color) *Element {
+	return e.Style("background-color:" + formatCSSColor(c))
+}
+
+// HoverColor sets the background colour of e under the pointer.
+func (e *Element) HoverColor(c color.Color) *Element {
+	return e.Style("hover-color:" + formatCSSColor(c))
+}
+
+// FontColor sets the colour of the text of e.
+func (e *Element) FontColor(c color.Color) *Element {
+	return e.Style("font-color:" + formatCSSColor(c))
+}
+
+// FontSize sets the size of the text of e.
+func (e *Element) FontSize(v float64) *Element { return e.Style("font-size:" + formatLength(v)) }
+
+// TextAlign sets the horizontal alignment of the text of e: LEFT, RIGHT
+// or CENTER.
+func (e *Element) TextAlign(a Align) *Element {
+	switch a {
+	case LEFT:
+		return e.Style("text-align:left")
+	case RIGHT:
+		return e.Style("text-align:right")
+	}
+	return e.Style("text-align:center")
+}
+
+// VerticalAlign sets the vertical alignment of the text of e: TOP, MIDDLE
+// or BOTTOM.
+func (e *Element) VerticalAlign(a Align) *Element {
+	switch a {
+	case TOP:
+		return e.Style("vertical-align:top")
+	case BOTTOM:
+		return e.Style("vertical-align:bottom")
+	}
+	return e.Style("vertical-align:middle")
+}
+
+// A Display is how an element is laid out.
+type Display string
+
+const (
+	DisplayBlock Display = "block" // stacked with its siblings
+	DisplayNone  Display = "none"  // hidden
+	DisplayGrid  Display = "grid"  // placing its children in cells
+)
+
+// Display sets how e is laid out.
+func (e *Element) Display(v Display) *Element { return e.Style("display:" + string(v)) }
+
+// An Overflow is what an element does with content overflowing its box.
+type Overflow string
+
+const (
+	OverflowVisible Overflow = "visible" // painted outside the box
+	OverflowHidden  Overflow = "hidden"  // clipped
+	OverflowScroll  Overflow = "scroll"  // clipped, with scrollbars
+	OverflowAuto    Overflow = "auto"    // clipped, with scrollbars when it overflows
+)
+
+// Overflow sets what e does with content overflowing its box.
+func (e *Element) Overflow(v Overflow) *Element { return e.Style("overflow:" + string(v)) }
+
+// Opacity sets the opacity of e, from 0 to 1.
+func (e *Element) Opacity(v float64) *Element { return e.Style("opacity:" + formatLength(v)) }
+
+// Build returns the tree of e, laid out like a document loaded with
+// LoadXML, with its own images, but without a file to hot reload.
+func (e *Element) Build() *Node {
+	root := e.node(1)
+	root.imageStore = newImageStore(nil)
+	root.loadStyleSheets()
+	layoutRoot(root, root.Style.Width, root.Style.Height)
+	return root
+}
+
+// node returns the node of e at the given level of the tree, nil for
+// empty text.
+func (e *Element) node(level int) *Node {
+	if e.tag == "" {
+		v := strings.TrimSpace(e.text)
+		if v == "" {
+			return nil
+		}
+		return &Node{Type: CharDataNode, Data: v, level: level, Model: new(Model)}
+	}
+	n := &Node{
+		Type:  ElementNode,
+		Model: new(Model),
+		Data:  e.tag,
+		Style: newElementStyle(e.tag),
+		level: level,
+	}
+	// the functions set by id stay when the tree is reconciled with one
+	// built without them
+	if on := e.on; on.click != nil || on.change != nil || on.input != nil ||
+		on.selected != nil || on.activate != nil {
+		n.on = &on
+	}
+	for _, a := range e.attrs {
+		parseAttr(n, a[0], a[1])
+	}
+	if len(e.style) > 0 {
+		parseAttr(n, "style", strings.Join(e.style, ";"))
+	}
+	for _, c := range e.children {
+		if cn := c.node(level + 1); cn != nil {
+			AddChild(n, cn)
+		}
+	}
+	return n
+}
+
+func formatPixels(v float64) string {
+	return formatLength(v) + "px"
+}
+
+// formatCSSColor returns c as a hexadecimal colour with alpha.
+func formatCSSColor(c color.Color) string {
+	return formatColor(color.NRGBAModel.Convert(c).(color.NRGBA))
+}
+
+func formatEdges(e Edges) string {
+	return formatPixels(e.Top) + " " + formatPixels(e.Right) + " " + formatPixels(e.Bottom) + " " + formatPixels(e.Left)
+}
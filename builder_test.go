@@ -0,0 +1,129 @@
+package geui
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	clicks := 0
+	root := Document(Attrs{"name": "Hello"},
+		Label("Hello").FontSize(20).TextAlign(LEFT),
+		Input().ID("email").Value("ada@example.com").Border(Border{Width: 2, Color: "#ff0000"}),
+		Box(Attrs{"key": "k"},
+			Button("Submit").ID("submit").Height(50).Margin(Edges{0, 10, 0, 10}).OnClick(func() { clicks++ }),
+			Text("  "),
+		).XY(5, 10),
+	).Width(300).Height(400).Build()
+
+	want := loadTestXML(t, `<window name="Hello" style="width:300px;height:400px">
+	<label style="font-size:20;text-align:left">Hello</label>
+	<input id="email" value="ada@example.com" style="border:2px solid #ff0000"></input>
+	<box key="k" xy="5,10">
+		<button id="submit" style="height:50px;margin:0px 10px 0px 10px">Submit</button>
+	</box>
+</window>`)
+	if !sameNode(root, want, true) {
+		t.Fatal("built tree differs from the loaded one")
+	}
+	got, wantNodes := root.GetNodes(), want.GetNodes()
+	for i := range got {
+		if got[i].Model.Width != wantNodes[i].Model.Width || got[i].Model.Height != wantNodes[i].Model.Height ||
+			got[i].Model.RelativeY != wantNodes[i].Model.RelativeY {
+			t.Errorf("node %d <%s> laid out at %+v, want %+v", i, got[i].Data, *got[i].Model, *wantNodes[i].Model)
+		}
+	}
+	if st := root.FirstChild.Style; st.FontSize != 20 || st.TextAlign != LEFT {
+		t.Errorf("label style = %+v", st)
+	}
+	for _, n := range []*Node{root, want} {
+		if box := n.LastChild; box.Model.X != 5 || box.Model.Y != 10 {
+			t.Errorf("box placed at %v, %v, want 5, 10", box.Model.X, box.Model.Y)
+		}
+	}
+
+	w, err := NewWindow(root, Size(300, 400), WithBackend(NewHeadless()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.repaint()
+	b := root.GetNodeByID("submit")
+	click := func(n *Node) {
+		w.dispatch(MouseMove{X: n.Model.RelativeX + 5, Y: n.Model.RelativeY + 5})
+		w.dispatch(MouseDown{MouseButton: MouseLeft})
+		w.dispatch(MouseUp{MouseButton: MouseLeft})
+	}
+	click(b)
+	if clicks != 1 {
+		t.Errorf("%d clicks, want 1", clicks)
+	}
+	// a click function set by id works for loaded documents too
+	loaded := 0
+	w.OnClick("email", func() { loaded++ })
+	click(root.GetNodeByID("email"))
+	if loaded != 1 || clicks != 1 {
+		t.Errorf("%d clicks on the input and %d on the button, want 1 and 1", loaded, clicks)
+	}
+
+	// a tree built again reconciles with the new click function
+	root.Reconcile(Document(Attrs{"name": "Hello"},
+		Label("Hello").FontSize(20).TextAlign(LEFT),
+		Input().ID("email").Value("ada@example.com").Border(Border{Width: 2, Color: "#ff0000"}),
+		Box(Attrs{"key": "k"},
+			Button("Submit").ID("submit").Height(50).Margin(Edges{0, 10, 0, 10}).OnClick(func() { clicks += 10 }),
+		).XY(5, 10),
+	).Width(300).Height(400).Build())
+	if root.GetNodeByID("submit") != b {
+		t.Fatal("button replaced")
+	}
+	click(b)
+	if clicks != 11 {
+		t.Errorf("%d clicks, want the new function called", clicks)
+	}
+	// the input of the new tree sets no click function, the one set by id stays
+	click(root.GetNodeByID("email"))
+	if loaded != 2 {
+		t.Errorf("%d clicks on the input, want 2", loaded)
+	}
+}
+
+func TestBuilderStyle(t *testing.T) {
+	root := Document(
+		Box().ID("box").BackgroundColor(color.NRGBA{R: 0xff, A: 0x80}).HoverColor(color.White).
+			FontColor(color.RGBA{G: 0xff, A: 0xff}).Display(DisplayNone).Overflow(OverflowHidden),
+	).Width(300).Height(200).Build()
+	box := root.GetNodeByID("box")
+	st := box.Style
+	if st.BackgroundColor != "#ff000080" || st.HoverColor != "#ffffffff" || st.FontColor != "#00ff00ff" {
+		t.Errorf("colours %v, %v, %v", st.BackgroundColor, st.HoverColor, st.FontColor)
+	}
+	if st.Display != "none" || st.Overflow != "hidden" {
+		t.Errorf("display %q, overflow %q", st.Display, st.Overflow)
+	}
+	if root.imageStore == nil {
+		t.Error("built tree has no image store")
+	}
+}
+
+func TestBuilderEvents(t *testing.T) {
+	var inputs, changes []string
+	root := Document(
+		Input().ID("name").OnInput(func(v string) { inputs = append(inputs, v) }),
+		El("number", Attrs{"id": "count", "value": "1"}).OnChange(func(v string) { changes = append(changes, v) }),
+	).Width(300).Height(200).Build()
+	w, err := NewWindow(root, Size(300, 200), WithBackend(NewHeadless()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.repaint()
+	w.focus(root.GetNodeByID("name"))
+	w.insert([]rune("Ada"))
+	count := root.GetNodeByID("count")
+	w.focus(count)
+	count.setText("2")
+	w.commit(count)
+	if fmt.Sprint(inputs) != "[Ada]" || fmt.Sprint(changes) != "[2]" {
+		t.Errorf("inputs %q, changes %q", inputs, changes)
+	}
+}
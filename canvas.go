@@ -0,0 +1,41 @@
+package geui
+
+import (
+	"image"
+
+	"github.com/fogleman/gg"
+)
+
+// A PaintFunc draws the content of a <canvas> element on dc, which is
+// translated to the top left corner of the element and clipped to it.
+// bounds is the box of the element in the units of dc.
+type PaintFunc func(dc *gg.Context, bounds image.Rectangle)
+
+// OnPaint sets the function drawing the <canvas> element with the given
+// id. It is called whenever the element is repainted; call Invalidate on
+// the element to repaint it.
+func (w *Window) OnPaint(id string, f PaintFunc) {
+	w.renderer.painters[id] = f
+	if n := w.node.GetNodeByID(id); n != nil {
+		n.markRepaint()
+	}
+}
+
+// paintCanvas calls the paint function of the <canvas> element n.
+func (render *Renderer) paintCanvas(n *Node) {
+	f := render.painters[n.ID]
+	if n.ID == "" || f == nil {
+		return
+	}
+	// popClip also restores the clip of the renderer after f clips dc,
+	// which Pop keeps
+	render.pushClip(render.device(n.canvasBounds()))
+	defer render.popClip()
+	dc := render.canvas
+	dc.Push()
+	defer dc.Pop()
+	dc.Translate(n.Model.RelativeX, n.Model.RelativeY)
+	render.setColor(n.Style.FontColor, n.opacity())
+	f(dc, image.Rect(0, 0, int(n.Model.Width), int(n.Model.Height)))
+	dc.ClearPath()
+}
@@ -0,0 +1,50 @@
+package geui
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/fogleman/gg"
+)
+
+func TestCanvasPaint(t *testing.T) {
+	w, _ := newTestWindow(t)
+	chart := &Node{Type: ElementNode, Data: "canvas", ID: "chart", Style: newElementStyle("canvas"), Model: new(Model)}
+	chart.Model.X, chart.Model.Y = 20, 10
+	chart.SetStyle("width: 50px; height: 40px")
+	AddChild(w.node, chart)
+
+	var bounds image.Rectangle
+	calls := 0
+	w.OnPaint("chart", func(dc *gg.Context, b image.Rectangle) {
+		bounds = b
+		calls++
+		dc.SetColor(color.RGBA{R: 0xff, A: 0xff})
+		// overflows the box to the left and the bottom
+		dc.DrawRectangle(-10, 20, 30, 40)
+		dc.Fill()
+	})
+	w.repaint()
+	if calls != 1 || bounds != image.Rect(0, 0, 50, 40) {
+		t.Fatalf("paint called %d times with bounds %v", calls, bounds)
+	}
+	img := w.renderer.Image()
+	if c := img.RGBAAt(25, 35); c != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Errorf("pixel in the canvas = %v, want red", c)
+	}
+	if c := img.RGBAAt(15, 35); c.R == 0xff && c.G == 0 {
+		t.Errorf("paint function drew left of the canvas")
+	}
+	if c := img.RGBAAt(25, 55); c.R == 0xff && c.G == 0 {
+		t.Errorf("paint function drew below the canvas")
+	}
+
+	if !w.repaint().Empty() || calls != 1 {
+		t.Fatal("canvas repainted without changes")
+	}
+	chart.Invalidate()
+	if r := w.repaint(); !r.In(image.Rect(0, 0, 300, 175)) || r.Empty() || calls != 2 {
+		t.Fatalf("invalidated canvas was not repainted, calls %d", calls)
+	}
+}
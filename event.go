@@ -2,28 +2,205 @@ package geui
 
 import (
 	"fmt"
-	"github.com/go-gl/glfw/v3.2/glfw"
 )
 
 type Event interface {
 	String() string
 }
 
-type (
+// A Key is a key on the keyboard.
+type Key int
+
+const (
+	KeyUnknown Key = iota
+	KeyA
+	KeyB
+	KeyC
+	KeyD
+	KeyE
+	KeyF
+	KeyG
+	KeyH
+	KeyI
+	KeyJ
+	KeyK
+	KeyL
+	KeyM
+	KeyN
+	KeyO
+	KeyP
+	KeyQ
+	KeyR
+	KeyS
+	KeyT
+	KeyU
+	KeyV
+	KeyW
+	KeyX
+	KeyY
+	KeyZ
+	Key0
+	Key1
+	Key2
+	Key3
+	Key4
+	Key5
+	Key6
+	Key7
+	Key8
+	Key9
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeySpace
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyDelete
+	KeyInsert
+	KeyEscape
+	KeyLeft
+	KeyRight
+	KeyUp
+	KeyDown
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyMinus
+	KeyEqual
+	KeyComma
+	KeyPeriod
+	KeySlash
+	KeySemicolon
+	KeyApostrophe
+	KeyLeftShift
+	KeyRightShift
+	KeyLeftControl
+	KeyRightControl
+	KeyLeftAlt
+	KeyRightAlt
+	KeyLeftSuper
+	KeyRightSuper
+)
+
+var keyNames = map[Key]string{
+	KeySpace:        "Space",
+	KeyEnter:        "Enter",
+	KeyTab:          "Tab",
+	KeyBackspace:    "Backspace",
+	KeyDelete:       "Delete",
+	KeyInsert:       "Insert",
+	KeyEscape:       "Escape",
+	KeyLeft:         "Left",
+	KeyRight:        "Right",
+	KeyUp:           "Up",
+	KeyDown:         "Down",
+	KeyHome:         "Home",
+	KeyEnd:          "End",
+	KeyPageUp:       "PageUp",
+	KeyPageDown:     "PageDown",
+	KeyMinus:        "-",
+	KeyEqual:        "=",
+	KeyComma:        ",",
+	KeyPeriod:       ".",
+	KeySlash:        "/",
+	KeySemicolon:    ";",
+	KeyApostrophe:   "'",
+	KeyLeftShift:    "LeftShift",
+	KeyRightShift:   "RightShift",
+	KeyLeftControl:  "LeftControl",
+	KeyRightControl: "RightControl",
+	KeyLeftAlt:      "LeftAlt",
+	KeyRightAlt:     "RightAlt",
+	KeyLeftSuper:    "LeftSuper",
+	KeyRightSuper:   "RightSuper",
+}
+
+func (k Key) String() string {
+	switch {
+	case k >= KeyA && k <= KeyZ:
+		return string(rune('A' + k - KeyA))
+	case k >= Key0 && k <= Key9:
+		return string(rune('0' + k - Key0))
+	case k >= KeyF1 && k <= KeyF12:
+		return fmt.Sprintf("F%d", 1+k-KeyF1)
+	}
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// A MouseButton is a button of the mouse.
+type MouseButton uint8
+
+const (
+	MouseLeft MouseButton = iota
+	MouseRight
+	MouseMiddle
+)
+
+func (b MouseButton) String() string {
+	switch b {
+	case MouseLeft:
+		return "left"
+	case MouseRight:
+		return "right"
+	case MouseMiddle:
+		return "middle"
+	}
+	return fmt.Sprintf("button%d", b)
+}
+
+// A Modifier is a set of modifier keys held down during an event.
+type Modifier uint8
 
+const (
+	ModShift Modifier = 1 << iota
+	ModControl
+	ModAlt
+	ModSuper
+)
+
+func (m Modifier) String() string {
+	s := ""
+	for _, mod := range []struct {
+		Modifier
+		name string
+	}{{ModControl, "Ctrl+"}, {ModAlt, "Alt+"}, {ModShift, "Shift+"}, {ModSuper, "Super+"}} {
+		if m&mod.Modifier != 0 {
+			s += mod.name
+		}
+	}
+	return s
+}
+
+type (
 	MouseMove struct {
 		X, Y float64
-		glfw.MouseButton
+		MouseButton
 	}
 
 	MouseDown struct {
 		X, Y float64
-		glfw.MouseButton
+		MouseButton
+		Modifier
 	}
 
 	MouseUp struct {
 		X, Y float64
-		glfw.MouseButton
+		MouseButton
+		Modifier
 	}
 
 	MouseScroll struct {
@@ -36,18 +213,21 @@ type (
 
 	// KbDown is an event that happens when a key on the keyboard gets pressed.
 	KbDown struct {
-		glfw.Key
+		Key
+		Modifier
 	}
 
 	// KbUp is an event that happens when a key on the keyboard gets released.
 	KbUp struct {
-		glfw.Key
+		Key
+		Modifier
 	}
 
 	// KbRepeat is an event that happens when a key on the keyboard gets repeated.
 	// This happens when its held down for some time.
 	KbRepeat struct {
-		glfw.Key
+		Key
+		Modifier
 	}
 
 	Resize struct {
@@ -56,12 +236,79 @@ type (
 	}
 )
 
-func (mm MouseMove) String() string   { return fmt.Sprintf("mouse/move/%v/%v", mm.X, mm.Y) }
-func (md MouseDown) String() string   { return fmt.Sprintf("mouse/down/%v/%v/%v", md.X, md.Y, md.MouseButton) }
-func (mu MouseUp) String() string     { return fmt.Sprintf("mouse/up/%v/%v/%v", mu.X, mu.Y, mu.MouseButton) }
+func (mm MouseMove) String() string { return fmt.Sprintf("mouse/move/%v/%v", mm.X, mm.Y) }
+func (md MouseDown) String() string {
+	return fmt.Sprintf("mouse/down/%v/%v/%v%v", md.X, md.Y, md.Modifier, md.MouseButton)
+}
+func (mu MouseUp) String() string {
+	return fmt.Sprintf("mouse/up/%v/%v/%v%v", mu.X, mu.Y, mu.Modifier, mu.MouseButton)
+}
 func (ms MouseScroll) String() string { return fmt.Sprintf("mouse/scroll/%v/%v", ms.X, ms.Y) }
 func (kt KbType) String() string      { return fmt.Sprintf("keyboad/type/%v", kt.rune) }
-func (kd KbDown) String() string      { return fmt.Sprintf("keyboad/down/%v", kd.Key) }
-func (ku KbUp) String() string        { return fmt.Sprintf("keyboad/up/%v", ku.Key) }
-func (kr KbRepeat) String() string    { return fmt.Sprintf("keyboad/repeat/%v", kr.Key) }
+func (kd KbDown) String() string      { return fmt.Sprintf("keyboad/down/%v%v", kd.Modifier, kd.Key) }
+func (ku KbUp) String() string        { return fmt.Sprintf("keyboad/up/%v%v", ku.Modifier, ku.Key) }
+func (kr KbRepeat) String() string    { return fmt.Sprintf("keyboad/repeat/%v%v", kr.Modifier, kr.Key) }
 func (rs Resize) String() string      { return fmt.Sprintf("viewport/resize/%v/%v", rs.Width, rs.Height) }
+
+// NewKbType returns the event of typing the rune r.
+func NewKbType(r rune) KbType {
+	return KbType{r}
+}
+
+// Rune returns the typed rune.
+func (kt KbType) Rune() rune {
+	return kt.rune
+}
+
+// handlers are the functions an element calls for the input of the user.
+// Elements built with Element always have theirs; elements loaded from XML
+// get them by id, see Window.OnClick.
+type handlers struct {
+	click    func()
+	change   func(value string)
+	input    func(value string)
+	selected func(index int)
+	activate func()
+}
+
+// handlers returns the functions of n, made on first use.
+func (n *Node) handlers() *handlers {
+	if n.on == nil {
+		n.on = new(handlers)
+	}
+	return n.on
+}
+
+// OnClick sets the function called when the user clicks the element n, or
+// one of its descendants without a click function.
+func (n *Node) OnClick(f func()) {
+	n.handlers().click = f
+}
+
+// OnChange sets the function called with the new value when the user
+// changes the value of the element n: chooses an option of a <select> or
+// <combobox>, releases the thumb of a <slider> or the divider of a
+// <splitter>, or confirms the text of a <number> or <combobox> with enter.
+func (n *Node) OnChange(f func(value string)) {
+	n.handlers().change = f
+}
+
+// OnInput sets the function called with the new value whenever the user
+// edits the value of the element n, like typing in an input or dragging
+// the thumb of a <slider>.
+func (n *Node) OnInput(f func(value string)) {
+	n.handlers().input = f
+}
+
+// OnSelect sets the function called with the index of the item the user
+// selects in the element n: a row of a <list>, a tab of a <tabs> or the
+// section of an <accordion> opened or collapsed.
+func (n *Node) OnSelect(f func(index int)) {
+	n.handlers().selected = f
+}
+
+// OnActivate sets the function called when the user activates the menu
+// item n, by clicking it, with enter or with its shortcut.
+func (n *Node) OnActivate(f func()) {
+	n.handlers().activate = f
+}
@@ -0,0 +1,5 @@
+package geui
+
+var (
+	DefaultFont = "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"
+)
@@ -1,13 +1,18 @@
 module github/diiyw/geui
 
-go 1.15
+go 1.17
 
 require (
 	github.com/fogleman/gg v1.3.0
 	github.com/go-gl/gl v0.0.0-20190320180904-bf2b1f2f34d7
-	github.com/go-gl/glfw v0.0.0-20200707082815-5321531c36a2
-	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
+	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20200707082815-5321531c36a2
+	github.com/go-text/typesetting v0.2.1
 	github.com/gorilla/css v1.0.0
-	golang.org/x/image v0.0.0-20200801110659-972c09e46d76
-	golang.org/x/net v0.0.0-20200822124328-c89045814202
+	github.com/srwiley/oksvg v0.0.0-20221011165216-be6e8873101c
+	github.com/srwiley/rasterx v0.0.0-20220730225603-2ab79fcdd4ef
+	golang.org/x/image v0.3.0
+	golang.org/x/net v0.9.0
+	golang.org/x/text v0.9.0
 )
+
+require github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
@@ -0,0 +1,397 @@
+package geui
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A gridTrack is the size of a row or column of a grid: a length, a
+// percentage of the grid, a fraction of the free space or auto, sized to
+// the items in it.
+type gridTrack struct {
+	size float64
+	unit string // px, %, fr or auto
+}
+
+// parseTracks parses track sizes like "100px", "25%", "1fr", "auto" and
+// "repeat(3, 1fr)".
+func parseTracks(values []string) (tracks []gridTrack) {
+	for _, v := range values {
+		if name, args := splitFunction(v); name == "repeat" && len(args) == 2 {
+			n, _ := strconv.Atoi(args[0])
+			inner := parseTracks(strings.Fields(args[1]))
+			for i := 0; i < n; i++ {
+				tracks = append(tracks, inner...)
+			}
+			continue
+		}
+		switch {
+		case v == "auto":
+			tracks = append(tracks, gridTrack{unit: "auto"})
+		case strings.HasSuffix(v, "fr"):
+			tracks = append(tracks, gridTrack{size: parseLength(strings.TrimSuffix(v, "fr")), unit: "fr"})
+		case strings.HasSuffix(v, "%"):
+			tracks = append(tracks, gridTrack{size: parseLength(strings.TrimSuffix(v, "%")), unit: "%"})
+		default:
+			tracks = append(tracks, gridTrack{size: parseLength(v), unit: "px"})
+		}
+	}
+	return
+}
+
+// gridLines parses the placement of an item along one axis, like "2",
+// "1 / 3", "span 2" or "2 / span 3", into its first line, from 1 or 0 to
+// place it automatically, and the number of tracks it spans.
+func gridLines(s string) (start, span int) {
+	span = 1
+	parts := strings.SplitN(s, "/", 2)
+	first := strings.Fields(parts[0])
+	switch {
+	case len(first) == 2 && first[0] == "span":
+		span, _ = strconv.Atoi(first[1])
+	case len(first) == 1:
+		start, _ = strconv.Atoi(first[0])
+	}
+	if len(parts) == 2 {
+		end := strings.Fields(parts[1])
+		switch {
+		case len(end) == 2 && end[0] == "span":
+			span, _ = strconv.Atoi(end[1])
+		case len(end) == 1 && start > 0:
+			if e, err := strconv.Atoi(end[0]); err == nil && e > start {
+				span = e - start
+			}
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+	if span < 1 {
+		span = 1
+	}
+	return
+}
+
+// A gridItem is an element placed in the cells of a grid, from 0.
+type gridItem struct {
+	node             *Node
+	row, col         int
+	rowSpan, colSpan int
+	width, height    float64 // size of the item with its margins
+	old              Model
+}
+
+// gridAreas returns the cells named in grid-template-areas, by name.
+func (st *CSStyle) gridAreas() map[string]gridItem {
+	areas := map[string]gridItem{}
+	for r, line := range st.GridTemplateAreas {
+		for c, name := range strings.Fields(line) {
+			if name == "." {
+				continue
+			}
+			a, ok := areas[name]
+			if !ok {
+				areas[name] = gridItem{row: r, col: c, rowSpan: 1, colSpan: 1}
+				continue
+			}
+			a.rowSpan = r - a.row + 1
+			a.colSpan = c - a.col + 1
+			areas[name] = a
+		}
+	}
+	return areas
+}
+
+// placeGrid places the stacked element children of the grid n in its
+// cells: by area name or lines when given, else in the next free cells,
+// row after row. It returns the items and the number of columns.
+func placeGrid(n *Node) (items []gridItem, cols int) {
+	cols = len(parseTracks(n.Style.GridTemplateColumns))
+	if len(n.Style.GridTemplateAreas) > 0 {
+		cols = int(math.Max(float64(cols), float64(len(strings.Fields(n.Style.GridTemplateAreas[0])))))
+	}
+	areas := n.Style.gridAreas()
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != ElementNode || c.positioned() || !c.displayed() {
+			continue
+		}
+		it := gridItem{node: c}
+		if a, ok := areas[c.Style.GridArea]; ok {
+			it.row, it.col, it.rowSpan, it.colSpan = a.row+1, a.col+1, a.rowSpan, a.colSpan
+		} else {
+			it.row, it.rowSpan = gridLines(c.Style.GridRow)
+			it.col, it.colSpan = gridLines(c.Style.GridColumn)
+		}
+		if it.col > 0 {
+			cols = int(math.Max(float64(cols), float64(it.col+it.colSpan-1)))
+		}
+		cols = int(math.Max(float64(cols), float64(it.colSpan)))
+		items = append(items, it)
+	}
+	if cols == 0 {
+		cols = 1
+	}
+
+	used := map[[2]int]bool{}
+	fits := func(it gridItem) bool {
+		if it.col+it.colSpan > cols {
+			return false
+		}
+		for r := it.row; r < it.row+it.rowSpan; r++ {
+			for c := it.col; c < it.col+it.colSpan; c++ {
+				if used[[2]int{r, c}] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	take := func(it gridItem) {
+		for r := it.row; r < it.row+it.rowSpan; r++ {
+			for c := it.col; c < it.col+it.colSpan; c++ {
+				used[[2]int{r, c}] = true
+			}
+		}
+	}
+	// items with both lines given go first
+	fixed := make([]bool, len(items))
+	for i := range items {
+		if it := &items[i]; it.row > 0 && it.col > 0 {
+			it.row--
+			it.col--
+			take(*it)
+			fixed[i] = true
+		}
+	}
+	row, col := 0, 0
+	for i := range items {
+		it := &items[i]
+		switch {
+		case fixed[i]:
+			continue
+		case it.row > 0:
+			it.row--
+			for it.col = 0; !fits(*it) && it.col+it.colSpan < cols; it.col++ {
+			}
+		case it.col > 0:
+			it.col--
+			for it.row = 0; !fits(*it); it.row++ {
+			}
+		default:
+			for it.row, it.col = row, col; !fits(*it); {
+				if it.col++; it.col+it.colSpan > cols {
+					it.row, it.col = it.row+1, 0
+				}
+			}
+			row, col = it.row, it.col+it.colSpan
+		}
+		take(*it)
+	}
+	return items, cols
+}
+
+// sizeTracks returns the sizes of count tracks sharing space, less the
+// gaps between them, where auto tracks take the size of their content.
+// Flexible tracks share the free space, or auto tracks stretch into it
+// when there are none. A negative space is unknown, then percentages and
+// flexible tracks size to their content too.
+func sizeTracks(tracks []gridTrack, count int, space, gap float64, content []float64) []float64 {
+	sizes := make([]float64, count)
+	fr := 0.0
+	var auto []int
+	for i := range sizes {
+		t := gridTrack{unit: "auto"}
+		if i < len(tracks) {
+			t = tracks[i]
+		}
+		switch {
+		case t.unit == "px":
+			sizes[i] = t.size
+		case t.unit == "%" && space >= 0:
+			sizes[i] = t.size / 100 * space
+		case t.unit == "fr" && space >= 0:
+			fr += t.size
+		default:
+			sizes[i] = content[i]
+			auto = append(auto, i)
+		}
+	}
+	if space < 0 {
+		return sizes
+	}
+	free := space - gap*float64(count-1)
+	for _, s := range sizes {
+		free -= s
+	}
+	if free <= 0 {
+		return sizes
+	}
+	switch {
+	case fr > 0:
+		for i := range sizes {
+			if i < len(tracks) && tracks[i].unit == "fr" {
+				sizes[i] = free * tracks[i].size / math.Max(1, fr)
+			}
+		}
+	case len(auto) > 0:
+		for _, i := range auto {
+			sizes[i] += free / float64(len(auto))
+		}
+	}
+	return sizes
+}
+
+// trackStart returns the offset of the track i from the sizes of the
+// tracks and the gap between them.
+func trackStart(sizes []float64, gap float64, i int) float64 {
+	x := 0.0
+	for _, s := range sizes[:i] {
+		x += s + gap
+	}
+	return x
+}
+
+// trackSpan returns the length of span tracks from the track i, with the
+// gaps between them.
+func trackSpan(sizes []float64, gap float64, i, span int) float64 {
+	return trackStart(sizes, gap, i+span) - trackStart(sizes, gap, i) - gap
+}
+
+// layoutGrid lays out the element children of the grid n in the cells of
+// its rows and columns, given by grid-template-columns and
+// grid-template-rows, with rows added as needed. Items stretch to their
+// cells unless given a size.
+func layoutGrid(n *Node) {
+	x, y, w, h := n.content()
+	if n.Style.scrolls() {
+		x -= n.scrollX
+		y -= n.scrollY
+	}
+	if _, height := n.size(); height == 0 {
+		h = -1
+	}
+	items, cols := placeGrid(n)
+	rows := len(parseTracks(n.Style.GridTemplateRows))
+	for _, it := range items {
+		rows = int(math.Max(float64(rows), float64(it.row+it.rowSpan)))
+	}
+
+	widths := make([]float64, cols)
+	for i := range items {
+		it := &items[i]
+		c, m := it.node, it.node.Style.Margin
+		it.old = *c.Model
+		it.width = c.preferredWidth() + m.Left + m.Right
+		if it.colSpan == 1 {
+			widths[it.col] = math.Max(widths[it.col], it.width)
+		}
+	}
+	colSizes := sizeTracks(parseTracks(n.Style.GridTemplateColumns), cols, w, n.Style.ColumnGap, widths)
+
+	heights := make([]float64, rows)
+	for i := range items {
+		it := &items[i]
+		c, m := it.node, it.node.Style.Margin
+		width, height := c.size()
+		c.Model.Width = width
+		if c.Model.Width == 0 {
+			c.Model.Width = trackSpan(colSizes, n.Style.ColumnGap, it.col, it.colSpan) - m.Left - m.Right
+		}
+		c.Model.Height = height
+		layout(c)
+		if height == 0 {
+			c.Model.Height = c.contentHeight()
+		}
+		it.height = c.Model.Height + m.Top + m.Bottom
+		if it.rowSpan == 1 {
+			heights[it.row] = math.Max(heights[it.row], it.height)
+		}
+	}
+	rowSizes := sizeTracks(parseTracks(n.Style.GridTemplateRows), rows, h, n.Style.RowGap, heights)
+
+	// the items keep the layout of their measure, moved to their cells,
+	// unless it depends on the height they stretch to
+	for _, it := range items {
+		c, m := it.node, it.node.Style.Margin
+		measured := c.Model.Height
+		if _, height := c.size(); height == 0 {
+			c.Model.Height = trackSpan(rowSizes, n.Style.RowGap, it.row, it.rowSpan) - m.Top - m.Bottom
+		}
+		cx := x + trackStart(colSizes, n.Style.ColumnGap, it.col) + m.Left
+		cy := y + trackStart(rowSizes, n.Style.RowGap, it.row) + m.Top
+		if c.Model.Height != measured && c.heightDependent() {
+			c.Model.RelativeX, c.Model.RelativeY = cx, cy
+			layout(c)
+		} else {
+			c.translate(cx-c.Model.RelativeX, cy-c.Model.RelativeY)
+		}
+		if *c.Model != it.old {
+			c.markRepaint()
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == CharDataNode:
+			layoutText(c)
+		case c.Type == ElementNode && c.positioned() && c.displayed():
+			old := *c.Model
+			c.Model.RelativeX, c.Model.RelativeY = c.Model.X, c.Model.Y
+			c.Model.Width, c.Model.Height = c.size()
+			if c.Model.Width == 0 {
+				c.Model.Width = w - c.Style.Margin.Left - c.Style.Margin.Right
+			}
+			layout(c)
+			if _, height := c.size(); height == 0 {
+				c.Model.Height = c.contentHeight()
+			}
+			if *c.Model != old {
+				c.markRepaint()
+			}
+		}
+	}
+	if n.Style.scrolls() && n.clampScroll() {
+		layout(n)
+	}
+}
+
+// heightDependent reports whether the layout of the descendants of the
+// element n depends on its height, not only on its width.
+func (n *Node) heightDependent() bool {
+	return n.Data == "list" || n.Data == "splitter"
+}
+
+// translate moves the laid out element n and its descendants by dx, dy,
+// but for the elements placed with the xy attribute.
+func (n *Node) translate(dx, dy float64) {
+	if dx == 0 && dy == 0 {
+		return
+	}
+	n.Model.RelativeX += dx
+	n.Model.RelativeY += dy
+	n.markRepaint()
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == ElementNode && !c.positioned() {
+			c.translate(dx, dy)
+		}
+	}
+}
+
+// preferredWidth returns the width the element n needs to show its text
+// on one line and its children, with its border and padding.
+func (n *Node) preferredWidth() float64 {
+	if width, _ := n.size(); width != 0 {
+		return width
+	}
+	w := 0.0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == CharDataNode:
+			w = math.Max(w, styleFace(n.Style, 1).measure(collapseSpace(c.Data)))
+		case c.Type == ElementNode && !c.positioned() && c.displayed():
+			w = math.Max(w, c.preferredWidth()+c.Style.Margin.Left+c.Style.Margin.Right)
+		}
+	}
+	b, p := n.Style.borderEdges(), n.Style.Padding
+	return w + b.Left + p.Left + b.Right + p.Right
+}
@@ -0,0 +1,91 @@
+package geui
+
+import (
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// loadTestXML lays out the document src.
+func loadTestXML(t *testing.T, src string) *Node {
+	p := filepath.Join(t.TempDir(), "test.xml")
+	if err := ioutil.WriteFile(p, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadXML(p)
+}
+
+func TestParseTracks(t *testing.T) {
+	got := parseTracks([]string{"100px", "repeat(2, 1fr auto)", "25%"})
+	want := []gridTrack{{100, "px"}, {1, "fr"}, {0, "auto"}, {1, "fr"}, {0, "auto"}, {25, "%"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tracks = %v, want %v", got, want)
+	}
+	for s, want := range map[string][2]int{"": {0, 1}, "2": {2, 1}, "1 / 3": {1, 2}, "span 3": {0, 3}, "2 / span 2": {2, 2}} {
+		if start, span := gridLines(s); start != want[0] || span != want[1] {
+			t.Errorf("gridLines(%q) = %d, %d, want %v", s, start, span, want)
+		}
+	}
+	n := element("box", "grid-area: 1 / 2 / 3 / span 2")
+	if n.Style.GridRow != "1 / 3" || n.Style.GridColumn != "2 / span 2" {
+		t.Errorf("grid-area set rows %q and columns %q", n.Style.GridRow, n.Style.GridColumn)
+	}
+}
+
+func TestGridLayout(t *testing.T) {
+	root := loadTestXML(t, `<window style="width: 320px; height: 300px">
+	<grid style="margin: 0; padding: 10px; gap: 10px 20px; grid-template-columns: 100px 1fr 2fr; grid-template-rows: 50px auto; grid-template-areas: 'head head head' 'side . .'">
+		<box style="grid-area: head; margin: 0; height: 0"/>
+		<box style="grid-area: side; margin: 0; height: 0">Side</box>
+		<box style="margin: 0; height: 40px"/>
+		<box style="margin: 0; height: 20px"/>
+		<box style="margin: 0; grid-column: span 3; height: 30px"/>
+		<box style="margin: 0; grid-row: 1; grid-column: 3; width: 10px"/>
+	</grid>
+</window>`)
+	grid := root.FirstChild
+	boxes := grid.elements()
+	// the columns share 320-20-2*20-100 = 160 as 1fr and 2fr
+	want := []Model{
+		{RelativeX: 10, RelativeY: 10, Width: 300, Height: 50},
+		{RelativeX: 10, RelativeY: 70, Width: 100, Height: 40},
+		{RelativeX: 130, RelativeY: 70, Width: 160.0 / 3, Height: 40},
+		{RelativeX: 130 + 160.0/3 + 20, RelativeY: 70, Width: 320.0 / 3, Height: 20},
+		{RelativeX: 10, RelativeY: 120, Width: 300, Height: 30},
+	}
+	for i, w := range want {
+		got := *boxes[i].Model
+		if math.Abs(got.RelativeX-w.RelativeX) > 1e-9 || got.RelativeY != w.RelativeY ||
+			math.Abs(got.Width-w.Width) > 1e-9 || got.Height != w.Height {
+			t.Errorf("box %d = %+v, want %+v", i, got, w)
+		}
+	}
+	// placed over the header, which comes first
+	if m := boxes[5].Model; math.Abs(m.RelativeX-want[3].RelativeX) > 1e-9 || m.RelativeY != 10 || m.Width != 10 {
+		t.Errorf("box placed in row 1 column 3 = %+v", *m)
+	}
+	if grid.Model.Height != 160 {
+		t.Errorf("grid height = %v, want the rows with their gaps and padding", grid.Model.Height)
+	}
+}
+
+func TestGridNested(t *testing.T) {
+	// each grid lays out its items once, however deep they nest
+	const depth = 20
+	src := `<box id="leaf" style="margin: 0; height: 30px">Leaf</box>`
+	for i := 0; i < depth; i++ {
+		src = `<grid style="margin: 0; grid-template-columns: 10px 1fr"><box style="margin: 0"/>` + src + `</grid>`
+	}
+	root := loadTestXML(t, `<window style="width: 300px; height: 300px">`+src+`</window>`)
+	leaf := root.GetNodeByID("leaf")
+	if m := leaf.Model; m.RelativeX != 10*depth || m.RelativeY != 0 || m.Width != 300-10*depth || m.Height != 30 {
+		t.Errorf("innermost item = %+v", *m)
+	}
+	for g := leaf.Parent; g != root; g = g.Parent {
+		if m := g.FirstChild.Model; m.RelativeX != g.Model.RelativeX || m.Height != g.Model.Height {
+			t.Fatalf("first item at %+v, want it stretched to the row", *m)
+		}
+	}
+}
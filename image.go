@@ -0,0 +1,272 @@
+package geui
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// maxScaled is the number of sizes an image is kept scaled to.
+const maxScaled = 4
+
+// maxImages is the number of image files a store keeps decoded.
+const maxImages = 64
+
+// An imageStore decodes image files, from a file system or from the
+// working directory, and caches them by path and size. Each window has
+// its own. The least recently used files are dropped beyond maxImages,
+// and files that fail to load are read again the next time.
+type imageStore struct {
+	sync.Mutex
+	fsys   fs.FS
+	files  map[string]*decodedImage
+	uses   int             // number of decodes, the time of the last use of files
+	failed map[string]bool // files whose error was logged
+}
+
+// decodedImage is an image file with its copies scaled to the sizes it was
+// drawn at. SVG images are rasterised at each size.
+type decodedImage struct {
+	src    image.Image
+	svg    *oksvg.SvgIcon
+	width  float64
+	height float64
+	scaled map[image.Point]*image.RGBA
+	used   int // uses of the store when it was last used
+}
+
+func newImageStore(fsys fs.FS) *imageStore {
+	return &imageStore{fsys: fsys, files: map[string]*decodedImage{}, failed: map[string]bool{}}
+}
+
+// decode returns the image file at p. Images that fail to load are nil.
+func (s *imageStore) decode(p string) *decodedImage {
+	s.uses++
+	if im, ok := s.files[p]; ok {
+		im.used = s.uses
+		return im
+	}
+	data, err := s.read(p)
+	var im *decodedImage
+	if err == nil {
+		im, err = decodeImage(p, data)
+	}
+	if err != nil {
+		if !s.failed[p] {
+			log.Println(err)
+			s.failed[p] = true
+		}
+		return nil
+	}
+	delete(s.failed, p)
+	if len(s.files) >= maxImages {
+		s.evict()
+	}
+	im.used = s.uses
+	s.files[p] = im
+	return im
+}
+
+// evict drops the least recently used image file.
+func (s *imageStore) evict() {
+	var oldest string
+	for p, im := range s.files {
+		if oldest == "" || im.used < s.files[oldest].used {
+			oldest = p
+		}
+	}
+	delete(s.files, oldest)
+}
+
+// clear drops the decoded images, to read their files again.
+func (s *imageStore) clear() {
+	s.Lock()
+	defer s.Unlock()
+	s.files = map[string]*decodedImage{}
+}
+
+// read returns the content of the file at p, from the file system of the
+// store or the working directory.
+func (s *imageStore) read(p string) ([]byte, error) {
+	if s.fsys != nil {
+		return fs.ReadFile(s.fsys, strings.TrimPrefix(path.Clean(p), "/"))
+	}
+	return ioutil.ReadFile(p)
+}
+
+// stat describes the file at p, in the file system of the store or the
+// working directory.
+func (s *imageStore) stat(p string) (fs.FileInfo, error) {
+	if s.fsys != nil {
+		return fs.Stat(s.fsys, strings.TrimPrefix(path.Clean(p), "/"))
+	}
+	return os.Stat(p)
+}
+
+func decodeImage(p string, data []byte) (*decodedImage, error) {
+	if strings.EqualFold(path.Ext(p), ".svg") {
+		icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.IgnoreErrorMode)
+		if err != nil {
+			return nil, err
+		}
+		return &decodedImage{svg: icon, width: icon.ViewBox.W, height: icon.ViewBox.H}, nil
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, &os.PathError{Op: "decode", Path: p, Err: err}
+	}
+	b := src.Bounds()
+	return &decodedImage{src: src, width: float64(b.Dx()), height: float64(b.Dy())}, nil
+}
+
+// size returns the intrinsic size of the image file at p, or 0 by 0 when
+// it fails to load.
+func (s *imageStore) size(p string) (w, h float64) {
+	s.Lock()
+	defer s.Unlock()
+	if im := s.decode(p); im != nil {
+		return im.width, im.height
+	}
+	return 0, 0
+}
+
+// image returns the image file at p scaled to w by h device pixels, or nil
+// when it fails to load.
+func (s *imageStore) image(p string, w, h int) *image.RGBA {
+	s.Lock()
+	defer s.Unlock()
+	im := s.decode(p)
+	if im == nil || w <= 0 || h <= 0 {
+		return nil
+	}
+	size := image.Pt(w, h)
+	if scaled, ok := im.scaled[size]; ok {
+		return scaled
+	}
+	if len(im.scaled) >= maxScaled || im.scaled == nil {
+		im.scaled = map[image.Point]*image.RGBA{}
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, w, h))
+	if im.svg != nil {
+		im.svg.SetTarget(0, 0, float64(w), float64(h))
+		scanner := rasterx.NewScannerGV(w, h, scaled, scaled.Rect)
+		im.svg.Draw(rasterx.NewDasher(w, h, scanner), 1)
+	} else {
+		draw.BiLinear.Scale(scaled, scaled.Rect, im.src, im.src.Bounds(), draw.Src, nil)
+	}
+	im.scaled[size] = scaled
+	return scaled
+}
+
+// images returns the store the images of the tree of n are loaded from,
+// the one of the window showing the tree, made for its root when it is
+// not shown yet.
+func (n *Node) images() *imageStore {
+	r := n.root()
+	if r.imageStore == nil {
+		r.imageStore = newImageStore(nil)
+	}
+	return r.imageStore
+}
+
+// imageSize returns the size of the border box of the image element n,
+// given its width and height, 0 when auto. Missing sizes come from the
+// intrinsic size of the image, keeping its aspect ratio. ok is false when
+// the image does not load.
+func (n *Node) imageSize(width, height float64) (w, h float64, ok bool) {
+	iw, ih := n.images().size(n.Attrs["src"])
+	if iw == 0 || ih == 0 {
+		return 0, 0, false
+	}
+	b, p := n.Style.borderEdges(), n.Style.Padding
+	dx, dy := b.Left+p.Left+b.Right+p.Right, b.Top+p.Top+b.Bottom+p.Bottom
+	switch {
+	case width == 0 && height == 0:
+		width, height = iw+dx, ih+dy
+	case width == 0:
+		width = (height-dy)*iw/ih + dx
+	case height == 0:
+		height = (width-dx)*ih/iw + dy
+	}
+	return width, height, true
+}
+
+// paintImage draws the image of the element n in its content box, fitted
+// as in its fit attribute: fill, contain, cover, none or scale-down.
+func (render *Renderer) paintImage(n *Node) {
+	src := n.Attrs["src"]
+	iw, ih := n.images().size(src)
+	if iw == 0 || ih == 0 {
+		return
+	}
+	s := render.scale
+	x, y, w, h := n.content()
+	x, y, w, h = x*s, y*s, w*s, h*s
+	contain := math.Min(w/(iw*s), h/(ih*s))
+	k := 0.0
+	switch n.Attrs["fit"] {
+	case "contain":
+		k = contain
+	case "cover":
+		k = math.Max(w/(iw*s), h/(ih*s))
+	case "none":
+		k = 1
+	case "scale-down":
+		k = math.Min(contain, 1)
+	}
+	tw, th := w, h
+	if k != 0 {
+		tw, th = iw*s*k, ih*s*k
+	}
+	im := n.images().image(src, int(math.Round(tw)), int(math.Round(th)))
+	if im == nil {
+		return
+	}
+	clip := image.Rect(int(math.Floor(x)), int(math.Floor(y)), int(math.Ceil(x+w)), int(math.Ceil(y+h)))
+	if n.transformed() {
+		// the image pattern is not transformed with the canvas, the image
+		// is drawn through the transform instead
+		box := image.Rect(int(math.Floor(x/s)), int(math.Floor(y/s)), int(math.Ceil((x+w)/s)), int(math.Ceil((y+h)/s)))
+		render.pushClip(render.device(n.transformRect(box)))
+		defer render.popClip()
+		render.drawImage(fade(im, n.opacity()), int(math.Round(x+(w-tw)/2)), int(math.Round(y+(h-th)/2)))
+		return
+	}
+	render.pushClip(clip)
+	defer render.popClip()
+	render.canvas.DrawRectangle(x/s, y/s, w/s, h/s)
+	render.canvas.SetFillStyle(&imagePattern{
+		im:      im,
+		x:       int(math.Round(x + (w-tw)/2)),
+		y:       int(math.Round(y + (h-th)/2)),
+		opacity: n.opacity(),
+	})
+	render.canvas.Fill()
+}
+
+// fade returns a copy of im faded by opacity, or im when it is opaque.
+func fade(im *image.RGBA, opacity float64) *image.RGBA {
+	if opacity >= 1 {
+		return im
+	}
+	faded := image.NewRGBA(im.Rect)
+	for i, v := range im.Pix {
+		faded.Pix[i] = uint8(float64(v) * opacity)
+	}
+	return faded
+}
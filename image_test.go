@@ -0,0 +1,92 @@
+package geui
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+	"testing/fstest"
+)
+
+const square = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 20 10">
+<rect width="20" height="10" fill="#0000ff"/>
+</svg>`
+
+func testFS(t *testing.T) fstest.MapFS {
+	im := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	draw.Draw(im, im.Rect, image.NewUniform(color.RGBA{R: 0xff, A: 0xff}), image.Point{}, draw.Src)
+	var b bytes.Buffer
+	if err := png.Encode(&b, im); err != nil {
+		t.Fatal(err)
+	}
+	return fstest.MapFS{
+		"icons/red.png":  {Data: b.Bytes()},
+		"icons/blue.svg": {Data: []byte(square)},
+	}
+}
+
+func TestImageStore(t *testing.T) {
+	s := newImageStore(testFS(t))
+	if w, h := s.size("icons/red.png"); w != 40 || h != 20 {
+		t.Errorf("png size = %vx%v, want 40x20", w, h)
+	}
+	if w, h := s.size("/icons/blue.svg"); w != 20 || h != 10 {
+		t.Errorf("svg size = %vx%v, want 20x10", w, h)
+	}
+	if w, h := s.size("missing.png"); w != 0 || h != 0 {
+		t.Errorf("missing image size = %vx%v, want 0x0", w, h)
+	}
+	im := s.image("icons/blue.svg", 60, 30)
+	if im == nil || im.Rect.Dx() != 60 || im.RGBAAt(30, 15) != (color.RGBA{B: 0xff, A: 0xff}) {
+		t.Fatalf("svg is not rasterised at 60x30")
+	}
+	if s.image("icons/blue.svg", 60, 30) != im {
+		t.Errorf("scaled image is not cached")
+	}
+
+	// a file that failed to load is read again
+	fsys := s.fsys.(fstest.MapFS)
+	fsys["missing.png"] = fsys["icons/red.png"]
+	if w, h := s.size("missing.png"); w != 40 || h != 20 {
+		t.Errorf("added image size = %vx%v, want 40x20", w, h)
+	}
+	// the least recently used files are dropped
+	for i := 0; i < maxImages; i++ {
+		p := fmt.Sprintf("icons/%d.svg", i)
+		fsys[p] = fsys["icons/blue.svg"]
+		s.size(p)
+		s.size("icons/red.png")
+	}
+	if len(s.files) != maxImages || s.files["icons/red.png"] == nil || s.files["icons/blue.svg"] != nil {
+		t.Errorf("%d images kept, want %d without the least recently used", len(s.files), maxImages)
+	}
+}
+
+func TestImageElement(t *testing.T) {
+	n := LoadXML("testdata/main.xml")
+	n.imageStore = newImageStore(testFS(t))
+	img := &Node{Type: ElementNode, Data: "image", Style: newElementStyle("image"), Model: new(Model)}
+	img.SetAttr("src", "icons/red.png")
+	img.SetAttr("fit", "contain")
+	AddChild(n, img)
+	layoutRoot(n, 300, 0)
+	if img.Model.Width != 40 || img.Model.Height != 20 {
+		t.Fatalf("image size = %vx%v, want its intrinsic 40x20", img.Model.Width, img.Model.Height)
+	}
+
+	img.SetStyle("width: 100px; height: 100px; background-color: #ffffff")
+	layoutRoot(n, 300, 0)
+	r := NewRenderer(n)
+	r.Resize(300, n.Model.Height)
+	r.Paint(r.Image().Bounds())
+	b := img.Bounds()
+	if c := r.Image().RGBAAt(b.Min.X+50, b.Min.Y+50); c != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Errorf("center of the image = %v, want red", c)
+	}
+	if c := r.Image().RGBAAt(b.Min.X+50, b.Min.Y+10); c != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("above the contained image = %v, want the white background", c)
+	}
+}
@@ -0,0 +1,171 @@
+package geui
+
+import "math"
+
+// layoutRoot gives the root n a box of the given size at the origin and
+// lays out the tree in it. A height of 0 sizes the root to its content.
+func layoutRoot(n *Node, width, height float64) {
+	n.Model.RelativeX, n.Model.RelativeY = 0, 0
+	n.Model.Width, n.Model.Height = width, height
+	layout(n)
+	if height == 0 {
+		n.Model.Height = n.contentHeight()
+	}
+}
+
+// layout positions and sizes the descendants of n within its content box.
+// Elements are stacked vertically, with the margins between them
+// collapsing, unless placed with the xy attribute, and stretch to the
+// width of their parent unless given a width. Images take the size of
+// their picture when it is not given. The content of elements with
+// overflow is moved by their scroll offset. Nodes whose box or text
+// lines changed are marked dirty. Grids place their children in cells
+// instead, see layoutGrid, and splitters on either side of their divider,
+// see layoutSplitter.
+func layout(n *Node) {
+	n.Style.legacyBorder()
+	switch n.Data {
+	case "list":
+		layoutList(n)
+		return
+	case "splitter":
+		layoutSplitter(n)
+		return
+	}
+	switch n.Data {
+	case "table":
+		layoutTable(n)
+	case "select", "combobox":
+		// takes the options out of the tree
+		n.choice()
+	case "slider", "number":
+		// fits the value in the range
+		n.ranged()
+	case "tabs":
+		// inserts the bar of tab buttons
+		n.tabs()
+	case "accordion":
+		// inserts the section headers
+		n.accordion()
+	case "menubar":
+		// shows the menus side by side
+		n.menubar()
+	}
+	if n.Style.Display == "grid" {
+		layoutGrid(n)
+		return
+	}
+	x, y, w, _ := n.content()
+	if n.Style.scrolls() {
+		x -= n.scrollX
+		y -= n.scrollY
+	}
+	var prev *Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case ElementNode:
+			if !c.displayed() {
+				continue
+			}
+			old := *c.Model
+			m := c.Style.Margin
+			width, height := c.size()
+			c.Model.Width = width
+			if c.Model.Width == 0 {
+				c.Model.Width = w - m.Left - m.Right
+			}
+			if c.positioned() {
+				c.Model.RelativeX, c.Model.RelativeY = c.Model.X, c.Model.Y
+			} else {
+				c.Model.RelativeX = x + m.Left
+				c.Model.RelativeY = y + m.Top
+				if prev != nil {
+					c.Model.RelativeY = prev.Model.RelativeY + prev.Model.Height +
+						math.Max(prev.Style.Margin.Bottom, m.Top)
+				}
+				prev = c
+			}
+			c.Model.Height = height
+			sized := false
+			if c.Data == "image" {
+				if iw, ih, ok := c.imageSize(width, height); ok {
+					c.Model.Width, c.Model.Height, sized = iw, ih, true
+				}
+			}
+			layout(c)
+			if height == 0 && !sized {
+				c.Model.Height = c.contentHeight()
+			}
+			if *c.Model != old {
+				c.markRepaint()
+			}
+		case CharDataNode:
+			layoutText(c)
+		}
+	}
+	if n.Style.scrolls() && n.clampScroll() {
+		layout(n)
+	}
+}
+
+// displayed reports whether the element takes part in the layout of its
+// parent, unless it has display none or is collapsed by a container like
+// <tabs>.
+func (n *Node) displayed() bool {
+	return n.Style.Display != "none" && !n.collapsed
+}
+
+// hidden reports whether the node or one of its ancestors is not
+// displayed.
+func (n *Node) hidden() bool {
+	for ; n != nil; n = n.Parent {
+		if n.Type == ElementNode && !n.displayed() {
+			return true
+		}
+	}
+	return false
+}
+
+// setCollapsed hides or shows the element n, repainting its tree.
+func (n *Node) setCollapsed(collapsed bool) {
+	if n.collapsed == collapsed {
+		return
+	}
+	n.collapsed = collapsed
+	for _, c := range n.GetNodes() {
+		c.markDirty()
+	}
+}
+
+// positioned reports whether the element is placed with the xy attribute
+// instead of being stacked.
+func (n *Node) positioned() bool {
+	return n.Model.X != 0 || n.Model.Y != 0
+}
+
+// content returns the content box of the element n, inside of its border
+// and padding.
+func (n *Node) content() (x, y, w, h float64) {
+	b, p := n.Style.borderEdges(), n.Style.Padding
+	x = n.Model.RelativeX + b.Left + p.Left
+	y = n.Model.RelativeY + b.Top + p.Top
+	w = n.Model.Width - b.Left - p.Left - b.Right - p.Right
+	h = n.Model.Height - b.Top - p.Top - b.Bottom - p.Bottom
+	return
+}
+
+// contentHeight returns the height the node needs to show its text and
+// stacked children inside of its border and padding.
+func (n *Node) contentHeight() (h float64) {
+	_, y, _, _ := n.content()
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == CharDataNode:
+			h = math.Max(h, c.text.height)
+		case c.Type == ElementNode && !c.positioned() && c.displayed():
+			h = math.Max(h, c.Model.RelativeY+c.Model.Height+c.Style.Margin.Bottom-y)
+		}
+	}
+	b, p := n.Style.borderEdges(), n.Style.Padding
+	return h + b.Top + p.Top + b.Bottom + p.Bottom
+}
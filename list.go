@@ -0,0 +1,328 @@
+package geui
+
+import (
+	"bytes"
+	"log"
+	"math"
+	"strings"
+	"text/template"
+)
+
+// A ListModel holds the rows shown by a <list> element.
+type ListModel interface {
+	Len() int
+	// Item returns the data of the row i, which the text of the row
+	// template refers to, like {{.Name}}.
+	Item(i int) interface{}
+}
+
+// listState is the state of a <list> element: its model, the template the
+// rows are made from and the nodes of the visible rows.
+type listState struct {
+	model     ListModel
+	template  *Node
+	rowHeight float64
+	rows      map[int]*Node // nodes of the visible rows by index
+	selected  int
+	texts     map[string]*template.Template
+}
+
+// list returns the state of the <list> element n. The first element in
+// the list is taken out of the tree to be the row template.
+func (n *Node) list() *listState {
+	if n.listState != nil {
+		return n.listState
+	}
+	l := &listState{rows: map[int]*Node{}, selected: -1, texts: map[string]*template.Template{}}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == ElementNode {
+			l.template = c
+			break
+		}
+	}
+	for n.FirstChild != nil {
+		RemoveChild(n, n.FirstChild)
+	}
+	if l.template != nil {
+		l.template.Parent = nil
+		l.rowHeight = l.template.Style.Height
+		if h, ok := n.Attrs["row-height"]; ok {
+			l.rowHeight = parseLength(h)
+		}
+	}
+	n.listState = l
+	return l
+}
+
+// SetListModel sets the rows of the <list> element n. Other elements are
+// left as they are.
+func (n *Node) SetListModel(m ListModel) {
+	if n.Data != "list" {
+		return
+	}
+	l := n.list()
+	l.model = m
+	if m == nil || l.selected >= m.Len() {
+		l.selected = -1
+	}
+	n.markDirty()
+}
+
+// Selected returns the index of the selected row of the <list> element n,
+// of the chosen option of a <select> or <combobox>, or of the shown tab
+// of a <tabs>, or else -1.
+func (n *Node) Selected() int {
+	switch n.Data {
+	case "select", "combobox":
+		return n.choice().selected
+	case "tabs":
+		return n.tabs().selected
+	case "list":
+		return n.list().selected
+	}
+	return -1
+}
+
+// SetSelected selects the row i of the <list> element n, -1 for none, and
+// scrolls it into view. A <select> or <combobox> chooses its option i, a
+// <tabs> shows its tab i. Other elements are left as they are.
+func (n *Node) SetSelected(i int) {
+	switch n.Data {
+	case "select", "combobox":
+		n.choose(i)
+		return
+	case "tabs":
+		n.selectTab(i)
+		return
+	}
+	if n.Data != "list" {
+		return
+	}
+	l := n.list()
+	if l.model == nil || i < -1 || i >= l.model.Len() {
+		return
+	}
+	if r, ok := l.rows[l.selected]; ok {
+		r.selected = false
+		r.markRepaint()
+	}
+	l.selected = i
+	if r, ok := l.rows[i]; ok {
+		r.selected = true
+		r.markRepaint()
+	}
+	if i >= 0 {
+		_, _, _, h := n.padding()
+		top := n.Style.Padding.Top + float64(i)*l.rowHeight
+		switch {
+		case top < n.scrollY:
+			n.ScrollTo(n.scrollX, top)
+		case top+l.rowHeight > n.scrollY+h:
+			n.ScrollTo(n.scrollX, top+l.rowHeight-h)
+		}
+	}
+	n.markRepaint()
+}
+
+// len returns the number of rows of the list.
+func (l *listState) len() int {
+	if l.model == nil || l.template == nil {
+		return 0
+	}
+	return l.model.Len()
+}
+
+// layoutList lays out the visible rows of the <list> element n, making
+// nodes for rows scrolled into view out of those scrolled out of it or
+// the template. Rows are stacked in the content box, one row height apart.
+func layoutList(n *Node) {
+	l := n.list()
+	x, y, w, _ := n.content()
+	_, _, _, h := n.padding()
+	p := n.Style.Padding
+	n.scrollW = w + p.Left + p.Right
+	n.scrollH = float64(l.len())*l.rowHeight + p.Top + p.Bottom
+	mx, my := n.maxScroll()
+	n.scrollX = math.Max(0, math.Min(n.scrollX, mx))
+	n.scrollY = math.Max(0, math.Min(n.scrollY, my))
+
+	first, last := 0, 0
+	if l.rowHeight > 0 {
+		first = int(math.Max(0, (n.scrollY-p.Top)/l.rowHeight))
+		last = int(math.Min(float64(l.len()), math.Ceil((n.scrollY+h-p.Top)/l.rowHeight)))
+	}
+	var free []*Node
+	for i, r := range l.rows {
+		if i < first || i >= last {
+			free = append(free, r)
+			delete(l.rows, i)
+		}
+	}
+	for i := first; i < last; i++ {
+		r, ok := l.rows[i]
+		if !ok {
+			if len(free) > 0 {
+				r, free = free[len(free)-1], free[:len(free)-1]
+			} else {
+				r = cloneNode(l.template)
+				AddChild(n, r)
+			}
+			l.rows[i] = r
+			r.markRepaint()
+		}
+		if sel := i == l.selected; sel != r.selected {
+			r.selected = sel
+			r.markRepaint()
+		}
+		l.bind(r, l.template, l.model.Item(i))
+
+		old := *r.Model
+		r.Model.RelativeX = x - n.scrollX
+		r.Model.RelativeY = y - n.scrollY + float64(i)*l.rowHeight
+		r.Model.Width, r.Model.Height = w, l.rowHeight
+		layout(r)
+		if *r.Model != old {
+			r.markRepaint()
+		}
+	}
+	for _, r := range free {
+		RemoveChild(n, r)
+	}
+}
+
+// bind fills the text and values of the row node r with the item, from
+// the node t of the template.
+func (l *listState) bind(r, t *Node, item interface{}) {
+	switch r.Type {
+	case CharDataNode:
+		if s := l.execute(t.Data, item); s != r.Data {
+			r.Data = s
+			r.markRepaint()
+		}
+	case ElementNode:
+		if s := l.execute(string(t.Value), item); s != string(r.Value) {
+			r.Value = []rune(s)
+			r.markRepaint()
+		}
+	}
+	for rc, tc := r.FirstChild, t.FirstChild; rc != nil && tc != nil; rc, tc = rc.NextSibling, tc.NextSibling {
+		l.bind(rc, tc, item)
+	}
+}
+
+// execute returns the text s of the template with item as its data.
+func (l *listState) execute(s string, item interface{}) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	t, ok := l.texts[s]
+	if !ok {
+		var err error
+		t, err = template.New("").Parse(s)
+		if err != nil {
+			log.Println(err)
+		}
+		l.texts[s] = t
+	}
+	if t == nil {
+		return s
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, item); err != nil {
+		return s
+	}
+	return b.String()
+}
+
+// click selects the row of the <list> element n the node hit is in, and
+// gives the focus to the list.
+func (l *listState) click(w *Window, n, hit *Node) *Node {
+	for ; hit != nil && hit.Parent != n; hit = hit.Parent {
+	}
+	for i, r := range l.rows {
+		if r != hit || hit == nil {
+			continue
+		}
+		if i != l.selected {
+			n.SetSelected(i)
+			w.selected(n, i)
+		}
+		return n
+	}
+	return nil
+}
+
+// key moves the selection of the focused <list> element n with the arrow,
+// page, home and end keys, and leaves the other keys to the elements
+// around it.
+func (l *listState) key(w *Window, n *Node, k Key) bool {
+	if w.active != n {
+		return false
+	}
+	_, _, _, h := n.padding()
+	page := 1
+	if l.rowHeight > 0 {
+		page = int(math.Max(1, math.Floor(h/l.rowHeight)))
+	}
+	i := l.selected
+	switch k {
+	case KeyUp:
+		i--
+	case KeyDown:
+		i++
+	case KeyPageUp:
+		i -= page
+	case KeyPageDown:
+		i += page
+	case KeyHome:
+		i = 0
+	case KeyEnd:
+		i = l.len() - 1
+	default:
+		return false
+	}
+	if i >= l.len() {
+		i = l.len() - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	if i != l.selected && l.len() > 0 {
+		n.SetSelected(i)
+		w.selected(n, i)
+	}
+	return true
+}
+
+// cloneNode returns a copy of the tree of n without ids, which would
+// repeat in every row.
+func cloneNode(n *Node) *Node {
+	c := &Node{
+		Model: new(Model),
+		Name:  n.Name,
+		Type:  n.Type,
+		Data:  n.Data,
+		Value: append([]rune(nil), n.Value...),
+		level: n.level,
+
+		declared: n.declared,
+		matched:  n.matched,
+		initial:  n.initial,
+		on:       n.on,
+	}
+	if n.Style != nil {
+		st := *n.Style
+		c.Style = &st
+	}
+	*c.Model = *n.Model
+	if n.Attrs != nil {
+		c.Attrs = make(map[string]string, len(n.Attrs))
+		for k, v := range n.Attrs {
+			c.Attrs[k] = v
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		AddChild(c, cloneNode(child))
+	}
+	return c
+}
@@ -0,0 +1,120 @@
+package geui
+
+import (
+	"strconv"
+	"testing"
+)
+
+type numbers int
+
+func (m numbers) Len() int               { return int(m) }
+func (m numbers) Item(i int) interface{} { return struct{ N string }{strconv.Itoa(i)} }
+
+// newListWindow shows a list 200 high of rows 20 high.
+func newListWindow(t *testing.T, rows int) (*Window, *Node) {
+	src := `<window style="width: 200px; height: 300px; background-color: #ffffff; hover-color: #ffffff">
+	<list id="list" style="height: 200px; margin: 0">
+		<row id="row" style="height: 20px; margin: 0; hover-color: #ffffff">Row {{.N}}</row>
+	</list>
+</window>`
+	w := newXMLWindow(t, src)
+	list := w.node.GetNodeByID("list")
+	list.SetListModel(numbers(rows))
+	w.repaint()
+	return w, list
+}
+
+func rowText(list *Node, i int) string {
+	r, ok := list.listState.rows[i]
+	if !ok || r.FirstChild == nil {
+		return ""
+	}
+	return r.FirstChild.Data
+}
+
+func TestListVirtualized(t *testing.T) {
+	w, list := newListWindow(t, 100000)
+	count := func() (n int) {
+		for c := list.FirstChild; c != nil; c = c.NextSibling {
+			n++
+		}
+		return
+	}
+	if n := count(); n != 10 {
+		t.Fatalf("list has %d row nodes, want the 10 visible", n)
+	}
+	if got := rowText(list, 3); got != "Row 3" {
+		t.Errorf("row 3 = %q", got)
+	}
+	if r := w.node.GetNodeByID("row"); r != nil {
+		t.Errorf("row %q keeps the id of the template", r.FirstChild.Data)
+	}
+	nodes := map[*Node]bool{}
+	for c := list.FirstChild; c != nil; c = c.NextSibling {
+		nodes[c] = true
+	}
+
+	list.ScrollTo(0, 50000)
+	w.repaint()
+	if n := count(); n != 10 {
+		t.Fatalf("list has %d row nodes after scrolling", n)
+	}
+	if got := rowText(list, 2500); got != "Row 2500" {
+		t.Errorf("row 2500 = %q", got)
+	}
+	for c := list.FirstChild; c != nil; c = c.NextSibling {
+		if !nodes[c] {
+			t.Errorf("row node %v was made, want it recycled", c)
+		}
+	}
+	if r := list.listState.rows[2501]; r.Model.RelativeY != 2501*20-50000 {
+		t.Errorf("row 2501 at %v", r.Model.RelativeY)
+	}
+}
+
+func TestListSelection(t *testing.T) {
+	w, list := newListWindow(t, 1000)
+	w.dispatch(MouseMove{X: 50, Y: 75})
+	w.dispatch(MouseDown{MouseButton: MouseLeft})
+	w.dispatch(MouseUp{MouseButton: MouseLeft})
+	w.repaint()
+	if i := list.Selected(); i != 3 {
+		t.Fatalf("clicked row %d, want 3", i)
+	}
+	if w.active != list || !list.listState.rows[3].selected {
+		t.Fatal("clicked row is not selected in the focused list")
+	}
+
+	w.dispatch(KbDown{Key: KeyDown})
+	if i := list.Selected(); i != 4 || list.listState.rows[3].selected {
+		t.Errorf("selected %d after down, want 4", i)
+	}
+	w.dispatch(KbDown{Key: KeyEnd})
+	w.repaint()
+	if i := list.Selected(); i != 999 {
+		t.Errorf("selected %d after end, want 999", i)
+	}
+	if _, y := list.ScrollOffset(); y != 1000*20-200 {
+		t.Errorf("scroll offset = %v, want the last row in view", y)
+	}
+	if !list.listState.rows[999].selected {
+		t.Error("last row is not selected")
+	}
+	w.dispatch(KbDown{Key: KeyPageUp})
+	if i := list.Selected(); i != 989 {
+		t.Errorf("selected %d after page up, want 989", i)
+	}
+	if list.listState.key(w, list, KeyEnter) {
+		t.Error("the list took a key it does not use")
+	}
+}
+
+func TestListMethodsOnOtherElements(t *testing.T) {
+	root := loadTestXML(t, `<window><box id="box"><label>Hello</label></box></window>`)
+	box := root.GetNodeByID("box")
+	box.SetListModel(nil)
+	box.SetSelected(0)
+	if i := box.Selected(); i != -1 || box.FirstChild == nil || box.listState != nil {
+		t.Errorf("selected %d, children %v: want -1 and the box left as it is", i, box.FirstChild)
+	}
+}
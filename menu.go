@@ -0,0 +1,263 @@
+package geui
+
+import "math"
+
+const (
+	minMenu  = 120 // narrowest popup of a menu
+	menuGap  = 24  // space between the text of a menu item and its shortcut
+	menuFade = 0.6 // opacity of the shortcuts of menu items
+)
+
+// menuState is the state of a <menu> element: its <menuitem> and
+// <separator> children, which are kept out of the tree like the options of
+// a <select>, and its popup.
+type menuState struct {
+	items     []*Node
+	shortcuts map[Shortcut]*Node // items by their shortcut attribute
+	label     *Node              // title of a menu of a <menubar>
+	popup     *Node              // open popup, or nil
+	active    int                // highlighted item of the popup, or -1
+}
+
+// menu returns the state of the <menu> element n, taking its items out of
+// the tree. A menu of a <menubar> shows its title attribute; other menus
+// are context menus, which only show in their popup.
+func (n *Node) menu() *menuState {
+	if n.menuState != nil {
+		return n.menuState
+	}
+	s := &menuState{shortcuts: map[Shortcut]*Node{}, active: -1}
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == ElementNode && (c.Data == "menuitem" || c.Data == "separator") {
+			RemoveChild(n, c)
+			if _, ok := c.Attrs["disabled"]; ok {
+				c.Style.FontColor = DefaultDisabledColor
+			}
+			s.items = append(s.items, c)
+			if sc, err := ParseShortcut(c.Attrs["shortcut"]); err == nil && s.shortcuts[sc] == nil {
+				s.shortcuts[sc] = c
+			}
+		}
+		c = next
+	}
+	n.menuState = s
+	if n.inMenubar() {
+		s.label = &Node{Type: CharDataNode, Data: n.Attrs["title"], Model: new(Model)}
+		AddChild(n, s.label)
+	}
+	return s
+}
+
+// inMenubar reports whether n is a menu of a <menubar>.
+func (n *Node) inMenubar() bool {
+	return n.Data == "menu" && n.Parent != nil && n.Parent.Data == "menubar"
+}
+
+// menubar shows the menus of the <menubar> element n side by side.
+func (n *Node) menubar() {
+	if n.Style.GridTemplateColumns != nil {
+		return
+	}
+	for _, c := range n.elements() {
+		if c.Data == "menu" {
+			c.Style.Display = "block"
+			c.menu()
+		}
+		n.Style.GridTemplateColumns = append(n.Style.GridTemplateColumns, "auto")
+	}
+	// the rest of the bar is left empty
+	n.Style.GridTemplateColumns = append(n.Style.GridTemplateColumns, "1fr")
+}
+
+// enabled reports whether the menu item n can be activated.
+func (n *Node) enabled() bool {
+	_, disabled := n.Attrs["disabled"]
+	return n.Data == "menuitem" && !disabled
+}
+
+// openMenu shows the items of the <menu> element n in a popup at the
+// element anchor, wide enough for their text and shortcuts.
+func (w *Window) openMenu(n, anchor *Node) {
+	w.closeMenu()
+	w.closePopup()
+	s := n.menu()
+	if len(s.items) == 0 {
+		return
+	}
+	popup := &Node{Type: ElementNode, Data: "popup", Style: newElementStyle("popup"), Model: new(Model)}
+	width := float64(minMenu)
+	for _, it := range s.items {
+		AddChild(popup, it)
+		if sc := it.Attrs["shortcut"]; sc != "" {
+			width = math.Max(width, it.preferredWidth()+menuGap+styleFace(it.Style, 1).measure(sc))
+		} else {
+			width = math.Max(width, it.preferredWidth())
+		}
+	}
+	b := popup.Style.borderEdges()
+	popup.Style.Width = width + b.Left + b.Right
+	s.popup, s.active = popup, -1
+	w.menu = n
+	n.selected = n.inMenubar()
+	n.markRepaint()
+	w.openOverlay(popup, anchor)
+}
+
+// closeMenu closes the open menu, if any.
+func (w *Window) closeMenu() {
+	n := w.menu
+	if n == nil {
+		return
+	}
+	s := n.menu()
+	w.closeOverlay(s.popup)
+	for _, it := range s.items {
+		RemoveChild(s.popup, it)
+		it.selected, it.hovered = false, false
+	}
+	s.popup, w.menu = nil, nil
+	n.selected = false
+	n.markRepaint()
+}
+
+// contextMenu opens the menu named by the contextmenu attribute of the
+// element at the point x, y or of its nearest ancestor with one, at the
+// point. It reports whether there was one.
+func (w *Window) contextMenu(x, y float64) bool {
+	for n := w.nodeAt(x, y); n != nil; n = n.Parent {
+		id, ok := n.Attrs["contextmenu"]
+		if !ok {
+			continue
+		}
+		m := w.node.GetNodeByID(id)
+		if m == nil || m.Data != "menu" {
+			return false
+		}
+		at := &Node{Type: ElementNode, Model: &Model{RelativeX: x, RelativeY: y}}
+		w.openMenu(m, at)
+		return w.menu == m
+	}
+	return false
+}
+
+// menuItem returns the index of the item of the open menu the node n is
+// in, or -1.
+func (w *Window) menuItem(n *Node) int {
+	if w.menu == nil {
+		return -1
+	}
+	for ; n != nil; n = n.Parent {
+		for i, it := range w.menu.menu().items {
+			if it == n {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// activate closes the open menu and calls the activate function of the
+// menu item it, unless it is disabled.
+func (w *Window) activate(it *Node) {
+	w.closeMenu()
+	if !it.enabled() {
+		return
+	}
+	if it.on != nil && it.on.activate != nil {
+		it.on.activate()
+	}
+}
+
+// highlightItem highlights the enabled item of the open menu next to the
+// highlighted one in the direction dir, wrapping around.
+func (s *menuState) highlightItem(dir int) {
+	i := s.active
+	if i < 0 && dir < 0 {
+		i = 0
+	}
+	for range s.items {
+		i = (i + dir + len(s.items)) % len(s.items)
+		if !s.items[i].enabled() {
+			continue
+		}
+		for j, it := range s.items {
+			if sel := j == i; sel != it.selected {
+				it.selected = sel
+				it.markRepaint()
+			}
+		}
+		s.active = i
+		return
+	}
+}
+
+// menuKey handles the key k while a menu is open and reports whether it
+// was used. The arrows highlight the items, or open the menu next to it
+// in a menubar; enter activates the highlighted item.
+func (w *Window) menuKey(k Key) bool {
+	n := w.menu
+	s := n.menu()
+	switch k {
+	case KeyUp:
+		s.highlightItem(-1)
+	case KeyDown:
+		s.highlightItem(1)
+	case KeyLeft, KeyRight:
+		if !n.inMenubar() {
+			return true
+		}
+		var menus []*Node
+		i := 0
+		for _, c := range n.Parent.elements() {
+			if c == n {
+				i = len(menus)
+			}
+			if c.Data == "menu" {
+				menus = append(menus, c)
+			}
+		}
+		if k == KeyLeft {
+			i += len(menus) - 1
+		} else {
+			i++
+		}
+		m := menus[i%len(menus)]
+		w.openMenu(m, m)
+	case KeyEnter, KeySpace:
+		if s.active >= 0 {
+			w.activate(s.items[s.active])
+		}
+	case KeyEscape:
+		w.closeMenu()
+	default:
+		return false
+	}
+	return true
+}
+
+// hoverMenubar opens the menu of a menubar under the pointer when another
+// menu of the bar is open.
+func (w *Window) hoverMenubar() {
+	m := w.menu
+	if m == nil || !m.inMenubar() {
+		return
+	}
+	if n := w.nodeAt(w.mouseX, w.mouseY); n != nil && n != m && n.Parent == m.Parent && n.Data == "menu" {
+		w.openMenu(n, n)
+	}
+}
+
+// paintShortcut draws the shortcut of the menu item n at the right of its
+// content box.
+func (render *Renderer) paintShortcut(n *Node) {
+	sc := n.Attrs["shortcut"]
+	if sc == "" {
+		return
+	}
+	x, y, w, h := n.content()
+	face := styleFace(n.Style, 1)
+	ascent, descent, _ := face.metrics()
+	render.setColor(n.Style.FontColor, n.opacity()*menuFade)
+	render.drawText(n.Style, sc, x+w-face.measure(sc), y+(h-ascent-descent)/2+ascent)
+}
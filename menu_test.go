@@ -0,0 +1,136 @@
+package geui
+
+import "testing"
+
+func TestParseShortcut(t *testing.T) {
+	for s, want := range map[string]Shortcut{
+		"Ctrl+S":       {KeyS, ModControl},
+		"ctrl+shift+z": {KeyZ, ModControl | ModShift},
+		"F5":           {KeyF5, 0},
+		"Alt+Enter":    {KeyEnter, ModAlt},
+		"Cmd+,":        {KeyComma, ModSuper},
+	} {
+		if sc, err := ParseShortcut(s); err != nil || sc != want {
+			t.Errorf("ParseShortcut(%q) = %v, %v, want %v", s, sc, err, want)
+		}
+	}
+	for _, s := range []string{"", "Ctrl+", "Hyper+A", "Ctrl+Foo"} {
+		if _, err := ParseShortcut(s); err == nil {
+			t.Errorf("ParseShortcut(%q) did not fail", s)
+		}
+	}
+	if s := (Shortcut{KeyS, ModControl | ModShift}).String(); s != "Ctrl+Shift+S" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func newMenuWindow(t *testing.T) *Window {
+	w := newXMLWindow(t, `<window style="width: 300px; height: 300px; hover-color: #ffffff">
+	<menubar>
+		<menu id="file" title="File">
+			<menuitem id="open" shortcut="Ctrl+O">Open</menuitem>
+			<separator/>
+			<menuitem id="save" shortcut="Ctrl+S">Save</menuitem>
+		</menu>
+		<menu id="edit" title="Edit">
+			<menuitem id="undo" disabled="">Undo</menuitem>
+			<menuitem id="copy">Copy</menuitem>
+		</menu>
+	</menubar>
+	<box id="doc" contextmenu="ctx" style="height: 100px; margin: 0"></box>
+	<menu id="ctx">
+		<menuitem id="cut">Cut</menuitem>
+	</menu>
+</window>`)
+	return w
+}
+
+func TestMenubar(t *testing.T) {
+	w := newMenuWindow(t)
+	var got []string
+	for _, id := range []string{"open", "save", "undo", "copy", "cut"} {
+		id := id
+		w.OnActivate(id, func() { got = append(got, id) })
+	}
+	file, edit, doc := w.node.GetNodeByID("file"), w.node.GetNodeByID("edit"), w.node.GetNodeByID("doc")
+	if file.Model.Height != 28 || edit.Model.RelativeX <= file.Model.RelativeX || doc.Model.RelativeY != 29 {
+		t.Fatalf("menus %+v, %+v and content %+v not laid out in a bar", *file.Model, *edit.Model, *doc.Model)
+	}
+
+	click(w, file.Model.RelativeX+5, 10)
+	popup := file.menu().popup
+	if w.menu != file || popup == nil || popup.Model.RelativeY != 28 || popup.Model.Width < minMenu {
+		t.Fatal("clicking the menu did not open its popup below it")
+	}
+	// moving over the next menu of the bar opens it instead
+	w.dispatch(MouseMove{X: edit.Model.RelativeX + 5, Y: 10})
+	if w.menu != edit || file.menu().popup != nil {
+		t.Fatal("the open menu did not follow the pointer")
+	}
+	// the disabled item is skipped
+	w.keyDown(KeyDown)
+	if s := edit.menu(); s.active != 1 {
+		t.Errorf("highlighted item = %d, want the enabled one", s.active)
+	}
+	w.keyDown(KeyLeft)
+	w.keyDown(KeyDown)
+	w.keyDown(KeyDown)
+	w.keyDown(KeyEnter)
+	if w.menu != nil || len(got) != 1 || got[0] != "save" {
+		t.Fatalf("activated %v, want save", got)
+	}
+
+	click(w, edit.Model.RelativeX+5, 10)
+	it := edit.menu().items[0].Model
+	click(w, it.RelativeX+10, it.RelativeY+10)
+	if w.menu != nil || len(got) != 1 {
+		t.Errorf("clicking the disabled item activated %v", got)
+	}
+
+	// shortcuts of the items, parsed with the menu, work with the menus
+	// closed
+	if it := file.menu().shortcuts[Shortcut{KeyO, ModControl}]; it == nil || it.ID != "open" {
+		t.Errorf("shortcut of the open item not kept with the menu: %v", it)
+	}
+	w.dispatch(KbDown{KeyO, ModControl})
+	if len(got) != 2 || got[1] != "open" {
+		t.Errorf("activated %v, want open", got)
+	}
+}
+
+func TestContextMenu(t *testing.T) {
+	w := newMenuWindow(t)
+	var got []string
+	w.OnActivate("cut", func() { got = append(got, "cut") })
+	if ctx := w.node.GetNodeByID("ctx"); !ctx.hidden() {
+		t.Fatal("the context menu shows in the tree")
+	}
+	w.dispatch(MouseMove{X: 100, Y: 60})
+	w.dispatch(MouseDown{MouseButton: MouseRight})
+	w.dispatch(MouseUp{MouseButton: MouseRight})
+	w.repaint()
+	popup := w.node.GetNodeByID("ctx").menu().popup
+	if popup == nil || popup.Model.RelativeX != 100 || popup.Model.RelativeY != 60 {
+		t.Fatal("right-clicking did not open the context menu at the pointer")
+	}
+	click(w, 110, 70)
+	if w.menu != nil || len(got) != 1 {
+		t.Errorf("activated %v, want cut", got)
+	}
+
+	// accelerators of the window come first
+	saved := 0
+	if err := w.SetAccelerator("Ctrl+S", func() { saved++ }); err != nil {
+		t.Fatal(err)
+	}
+	w.dispatch(KbDown{KeyS, ModControl})
+	w.dispatch(KbDown{KeyS, ModControl | ModShift})
+	if saved != 1 {
+		t.Errorf("accelerator ran %d times, want 1", saved)
+	}
+	w.SetAccelerator("Ctrl+S", nil)
+	w.dispatch(KbDown{KeyS, ModControl})
+	if saved != 1 {
+		t.Error("removed accelerator ran")
+	}
+}
@@ -1,5 +1,7 @@
 package geui
 
+import "image"
+
 // A NodeType is the type of a Node.
 type NodeType uint
 
@@ -34,10 +36,46 @@ type Node struct {
 	Type  NodeType
 	Data  string
 	Value []rune
+	Attrs map[string]string // attributes without a field, like src
+
+	Style    *CSStyle
+	declared string // style set by the attributes, to tell when it changes
+	matched  string // declarations of the style sheet rules matching the element
+	initial  string // value set by the attribute, to tell when it changes
+
+	level    int             // node level in the tree
+	dirty    bool            // node changed since the last frame
+	relayout bool            // tree of the root changed since it was laid out
+	hovered  bool            // pointer is over the node
+	focused  bool            // node receives the keyboard input
+	caret    int             // position of the caret in the value, in runes
+	painted  image.Rectangle // area covered by the last paint of the node
+	text     textBlock       // lines of a text node
+
+	imageStore *imageStore // images of the tree of a root
+	window     *Window     // window showing the tree of a root
+	source     string      // XML file of the tree of a root
+
+	scrollX, scrollY float64 // offset of the content of a scrolling element
+	scrollW, scrollH float64 // size of the content, with the padding
+
+	listState   *listState   // rows of a <list> element
+	tableState  *tableState  // columns of a <table> element
+	selectState *selectState // options of a <select> or <combobox> element
+	rangeState  *rangeState  // committed value of a <slider> or <number> element
+
+	tabsState      *tabsState      // bar and shown tab of a <tabs> element
+	accordionState *accordionState // section headers of an <accordion> element
+	splitterState  *splitterState  // divider of a <splitter> element
+	menuState      *menuState      // items of a <menu> element
+
+	anim  *animState  // transitions and animations of the node
+	sheet *styleSheet // rules and @keyframes of a <style> element
 
-	Style *CSStyle
+	on *handlers // functions called for the input of the user
 
-	level int // node level in the tree
+	collapsed bool // hidden by a container, like the other tabs of <tabs>
+	selected  bool // node is the selected row of a list
 }
 
 // AddChild adds a new node 'n' to a node 'parent' as its last child.
@@ -53,6 +91,7 @@ func AddChild(parent, n *Node) {
 	}
 
 	parent.LastChild = n
+	n.markDirty()
 }
 
 // AddSibling adds a new node 'n' as a sibling of a given node 'sibling'.
@@ -71,6 +110,118 @@ func AddSibling(sibling, n *Node) {
 	if sibling.Parent != nil {
 		sibling.Parent.LastChild = n
 	}
+	n.markDirty()
+}
+
+// InsertBefore adds the node n to the children of parent before the child
+// before, or last when before is nil.
+func InsertBefore(parent, n, before *Node) {
+	if before == nil {
+		AddChild(parent, n)
+		return
+	}
+	n.Parent = parent
+	n.PrevSibling, n.NextSibling = before.PrevSibling, before
+	if before.PrevSibling != nil {
+		before.PrevSibling.NextSibling = n
+	} else {
+		parent.FirstChild = n
+	}
+	before.PrevSibling = n
+	n.markDirty()
+}
+
+// RemoveChild removes the child n from the node parent.
+func RemoveChild(parent, n *Node) {
+	if n.PrevSibling != nil {
+		n.PrevSibling.NextSibling = n.NextSibling
+	} else {
+		parent.FirstChild = n.NextSibling
+	}
+	if n.NextSibling != nil {
+		n.NextSibling.PrevSibling = n.PrevSibling
+	} else {
+		parent.LastChild = n.PrevSibling
+	}
+	n.Parent, n.PrevSibling, n.NextSibling = nil, nil, nil
+	// repaint where the child was
+	parent.markDirty()
+}
+
+// root returns the root of the tree of n.
+func (n *Node) root() *Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+// markDirty flags the node so that the next frame lays out its tree again
+// and repaints it.
+func (n *Node) markDirty() {
+	n.dirty = true
+	n.root().relayout = true
+}
+
+// markRepaint flags the node so that the next frame repaints it, for
+// changes that leave the layout as it is, like the hover state.
+func (n *Node) markRepaint() {
+	n.dirty = true
+}
+
+// Invalidate asks for the node to be laid out and repainted, e.g. after
+// changing its fields, or when what the paint function of a <canvas>
+// draws changed. The window showing the node is
+// woken up to paint it.
+func (n *Node) Invalidate() {
+	n.markDirty()
+	for ; n != nil; n = n.Parent {
+		if n.window != nil {
+			n.window.Wake()
+			return
+		}
+	}
+}
+
+// SetValue replaces the value of the node. A <select> chooses its first
+// option with the value v instead, a <slider> or <number> fits v in its
+// range.
+func (n *Node) SetValue(v string) {
+	if n.Data == "select" {
+		for i, o := range n.choice().options {
+			if optionValue(o) == v {
+				n.choose(i)
+				return
+			}
+		}
+		return
+	}
+	n.Value = []rune(v)
+	n.caret = len(n.Value)
+	n.markDirty()
+	if n.Data == "slider" || n.Data == "number" {
+		n.setNumber(n.number())
+		n.ranged().committed = string(n.Value)
+	}
+}
+
+// SetAttr sets the attribute key of the node, as in the XML.
+func (n *Node) SetAttr(key, val string) {
+	parseAttr(n, key, val)
+	n.markDirty()
+}
+
+// SetStyle applies an inline style declaration, e.g. "font-size:20", to the node.
+func (n *Node) SetStyle(v string) {
+	transformed := len(n.Style.Transform) > 0
+	parseInlineStyle(n, v)
+	n.markDirty()
+	if transformed || len(n.Style.Transform) > 0 {
+		// the descendants move with the transform of the node
+		for _, c := range n.GetNodes() {
+			c.markDirty()
+		}
+	}
 }
 
 func (n *Node) GetNodes() (nodes []*Node) {
@@ -89,16 +240,66 @@ func (n *Node) GetNodes() (nodes []*Node) {
 	return
 }
 
+// area returns the region of the canvas the node paints on. Text is
+// painted inside of its parent. Nodes are clipped by their scrolling
+// ancestors; hidden nodes paint nothing.
+func (n *Node) area() (r image.Rectangle) {
+	switch {
+	case n.hidden():
+		return image.Rectangle{}
+	case n.Type == ElementNode:
+		r = n.transformRect(n.boxArea())
+	case n.Parent != nil:
+		r = n.Parent.canvasBounds()
+	}
+	if clip, clipped := n.visible(); clipped {
+		r = r.Intersect(clip)
+	}
+	return r
+}
+
+// damage returns the region covered by the dirty nodes of the tree, both
+// where they were last painted and where they are now.
+func (n *Node) damage() (r image.Rectangle) {
+	for _, c := range n.GetNodes() {
+		if c.dirty {
+			r = r.Union(c.painted).Union(c.area())
+		}
+	}
+	return
+}
+
+// clean resets the dirty flags of the node and its descendants.
+func (n *Node) clean() {
+	for _, c := range n.GetNodes() {
+		c.dirty = false
+	}
+}
+
+// GetNodeByID returns the first node of the tree of n with the given id, or
+// nil.
+func (n *Node) GetNodeByID(id string) *Node {
+	for _, c := range n.GetNodes() {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// GetActiveNode returns the innermost element under the point x, y, or nil
+// when it is over no descendant of n.
 func (n *Node) GetActiveNode(x, y float64) *Node {
 	var f func(*Node) *Node
 	f = func(n *Node) *Node {
-		if n != nil {
-			for c := n.FirstChild; c != nil; c = c.NextSibling {
-				if c.Focused(x, y) {
-					return c
-				}
-				f(c)
+		for c := n.LastChild; c != nil; c = c.PrevSibling {
+			if c.Type != ElementNode || !c.Focused(x, y) {
+				continue
+			}
+			if active := f(c); active != nil {
+				return active
 			}
+			return c
 		}
 		return nil
 	}
@@ -1,11 +1,23 @@
 package geui
 
 import (
+	"image/png"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
 func TestNewNode(t *testing.T) {
 	n := LoadXML("testdata/main.xml")
 	r := NewRenderer(n)
-	r.Render("testdata/main.png")
+	p := filepath.Join(t.TempDir(), "main.png")
+	r.Render(p)
+	fi, err := os.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fi.Close()
+	if _, err := png.Decode(fi); err != nil {
+		t.Errorf("rendered file does not decode: %v", err)
+	}
 }
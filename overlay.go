@@ -0,0 +1,96 @@
+package geui
+
+import "math"
+
+// An overlay is a tree of nodes painted above the node tree of a window
+// and hit by the pointer first, like the popup of a <select>. It is not
+// clipped by the ancestors of its anchor, the element it is placed at:
+// below it, or above it when there is no room below.
+type overlay struct {
+	node   *Node
+	anchor *Node
+}
+
+// openOverlay shows the tree n as an overlay at the element anchor.
+func (w *Window) openOverlay(n, anchor *Node) {
+	w.renderer.overlays = append(w.renderer.overlays, &overlay{node: n, anchor: anchor})
+	// images load like those of the window
+	n.imageStore = w.node.images()
+	n.markDirty()
+}
+
+// closeOverlay hides the overlay of the tree n.
+func (w *Window) closeOverlay(n *Node) {
+	overlays := w.renderer.overlays
+	for i, o := range overlays {
+		if o.node != n {
+			continue
+		}
+		w.renderer.overlays = append(overlays[:i:i], overlays[i+1:]...)
+		for _, c := range n.GetNodes() {
+			w.damage = w.damage.Union(c.painted)
+		}
+		return
+	}
+}
+
+// layoutOverlays places the overlays at their anchors, within the canvas
+// when they fit, and lays them out. Overlays are as wide as their anchor
+// unless given a width.
+func (w *Window) layoutOverlays() {
+	for _, o := range w.renderer.overlays {
+		n, a := o.node, o.anchor.Model
+		old := *n.Model
+		n.Model.Width = n.Style.Width
+		if n.Model.Width == 0 {
+			n.Model.Width = a.Width
+		}
+		n.Model.Height = n.Style.Height
+		n.Model.RelativeX = math.Max(0, math.Min(a.RelativeX, w.renderer.width-n.Model.Width))
+		n.Model.RelativeY = a.RelativeY + a.Height
+		layout(n)
+		if n.Style.Height == 0 {
+			n.Model.Height = n.contentHeight()
+		}
+		if n.Model.RelativeY+n.Model.Height > w.renderer.height && a.RelativeY-n.Model.Height >= 0 {
+			n.Model.RelativeY = a.RelativeY - n.Model.Height
+			layout(n)
+		}
+		if *n.Model != old {
+			n.markRepaint()
+		}
+	}
+}
+
+// overlayAt returns the topmost overlay tree under the point x, y, or nil.
+// Tooltips and reload errors are not hit by the pointer.
+func (w *Window) overlayAt(x, y float64) *Node {
+	overlays := w.renderer.overlays
+	for i := len(overlays) - 1; i >= 0; i-- {
+		if n := overlays[i].node; n.Data != "tooltip" && n.Data != "error" && n.Focused(x, y) {
+			return n
+		}
+	}
+	return nil
+}
+
+// nodeAt returns the innermost element under the point x, y, in the
+// overlays first, or nil.
+func (w *Window) nodeAt(x, y float64) *Node {
+	if o := w.overlayAt(x, y); o != nil {
+		if n := o.GetActiveNode(x, y); n != nil {
+			return n
+		}
+		return o
+	}
+	return w.node.GetActiveNode(x, y)
+}
+
+// trees returns the node tree of the window followed by its overlays.
+func (w *Window) trees() []*Node {
+	trees := []*Node{w.node}
+	for _, o := range w.renderer.overlays {
+		trees = append(trees, o.node)
+	}
+	return trees
+}
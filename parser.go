@@ -2,6 +2,7 @@ package geui
 
 import (
 	"encoding/xml"
+	"errors"
 	"io"
 	"os"
 	"strconv"
@@ -11,20 +12,43 @@ import (
 )
 
 func LoadXML(v string) *Node {
-	fi, err := os.Open(v)
+	root, err := parseXML(v, nil)
 	if err != nil {
 		panic(err)
 	}
+	return root
+}
+
+// parseXML parses and lays out the document in the file v, reading its
+// images and linked style sheets with images, or from the working
+// directory when nil.
+func parseXML(v string, images *imageStore) (*Node, error) {
+	fi, err := os.Open(v)
+	if err != nil {
+		return nil, err
+	}
+	defer fi.Close()
 	p := newParser(fi)
 	for {
 		_, err := p.parse()
 		if err == io.EOF {
-			p.doc.FirstChild.NextSibling.Parent = nil
-			p.doc.FirstChild.NextSibling.PrevSibling = nil
-			return p.doc.FirstChild.NextSibling
+			if p.doc.FirstChild == nil || p.doc.FirstChild.NextSibling == nil {
+				return nil, errors.New("geui: no root element in " + v)
+			}
+			root := p.doc.FirstChild.NextSibling
+			root.Parent = nil
+			root.PrevSibling = nil
+			root.source = v
+			if images == nil {
+				images = newImageStore(nil)
+			}
+			root.imageStore = images
+			root.loadStyleSheets()
+			layoutRoot(root, root.Style.Width, root.Style.Height)
+			return root, nil
 		}
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 	}
 }
@@ -67,7 +91,7 @@ func (p *parser) parse() (*Node, error) {
 				Type:  ElementNode,
 				Model: new(Model),
 				Data:  tok.Name.Local,
-				Style: NewStyle(),
+				Style: newElementStyle(tok.Name.Local),
 				level: p.level,
 			}
 			for _, attr := range tok.Attr {
@@ -83,7 +107,6 @@ func (p *parser) parse() (*Node, error) {
 				}
 				AddSibling(p.prev.Parent, node)
 			}
-			parse(node)
 			p.prev = node
 			p.level++
 		case xml.EndElement:
@@ -121,16 +144,12 @@ func (p *parser) parse() (*Node, error) {
 	}
 }
 
+// parserXY parses a position like "5,10", y being 0 when missing.
 func parserXY(v string) (x, y float64) {
 	xy := strings.Split(v, ",")
-	if len(xy) == 0 {
-		return
-	}
-	if len(xy) == 1 {
-		x, _ = strconv.ParseFloat(xy[0], 64)
-	}
+	x, _ = strconv.ParseFloat(strings.TrimSpace(xy[0]), 64)
 	if len(xy) == 2 {
-		y, _ = strconv.ParseFloat(xy[1], 64)
+		y, _ = strconv.ParseFloat(strings.TrimSpace(xy[1]), 64)
 	}
 	return
 }
@@ -146,41 +165,22 @@ func parseAttr(node *Node, key, val string) {
 	case "rel-xy":
 		node.Model.RelativeX, node.Model.RelativeY = parserXY(val)
 	case "width":
-		node.Model.Width, _ = strconv.ParseFloat(val, 64)
+		node.Style.Width = parseLength(val)
+		node.declared += ";width:" + val
 	case "height":
-		node.Model.Height, _ = strconv.ParseFloat(val, 64)
+		node.Style.Height = parseLength(val)
+		node.declared += ";height:" + val
 	case "value":
 		node.Value = []rune(val)
+		node.caret = len(node.Value)
+		node.initial = val
 	case "style":
 		parseInlineStyle(node, val)
-	}
-}
-
-func parse(node *Node) {
-	// position
-	if node.Model.X == 0 && node.Model.Y == 0 && node.Data != "window" {
-		if node.Parent != nil {
-			node.Model.RelativeX = node.Parent.Model.X + 10
-			node.Model.RelativeY = node.Parent.Model.Y + 10
-			if node.PrevSibling != nil {
-				node.Model.RelativeY += node.PrevSibling.Model.Height + node.PrevSibling.Model.RelativeY
-			}
-		}
-	}
-	// width
-	if node.Model.Width == 0 {
-		if node.Style.Width != 0 {
-			node.Model.Width = node.Style.Width
-		}
-		// extend parent
-		if node.Model.Width == 0 && node.Parent != nil && node.Parent.Model != nil {
-			node.Model.Width = node.Parent.Model.Width - 10*2
-		}
-	}
-	// height
-	if node.Model.Height == 0 {
-		if node.Style.Height != 0 {
-			node.Model.Height = node.Style.Height
+		node.declared += ";" + val
+	default:
+		if node.Attrs == nil {
+			node.Attrs = map[string]string{}
 		}
+		node.Attrs[key] = val
 	}
 }
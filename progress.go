@@ -0,0 +1,71 @@
+package geui
+
+import (
+	"image"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	progressPeriod = 1500 * time.Millisecond // sweep of an indeterminate progress bar
+	frameInterval  = time.Second / 60        // time between the frames of animations
+)
+
+// progress returns how far the <progress> element n is, from 0 to 1, and
+// false when it has no value, which makes it indeterminate.
+func (n *Node) progress() (float64, bool) {
+	v, err := strconv.ParseFloat(string(n.Value), 64)
+	if err != nil {
+		return 0, false
+	}
+	max := 1.0
+	if m, err := strconv.ParseFloat(n.Attrs["max"], 64); err == nil && m > 0 {
+		max = m
+	}
+	return math.Max(0, math.Min(1, v/max)), true
+}
+
+// paintProgress draws the track of the <progress> element n, filled with
+// the accent colour as far as it is, or with a bar sweeping along it when
+// it is indeterminate.
+func (render *Renderer) paintProgress(n *Node) {
+	x, y, w, h := n.content()
+	r := math.Min(n.Style.BorderRadius, h/2)
+	op := n.opacity()
+	render.rectangle(x, y, w, h, r)
+	render.setColor(n.Style.TrackColor, op)
+	render.canvas.Fill()
+
+	from, to := 0.0, 0.0
+	if k, ok := n.progress(); ok {
+		to = k
+	} else {
+		phase := float64(render.now.UnixNano()%int64(progressPeriod)) / float64(progressPeriod)
+		from = phase*1.3 - 0.3
+		to = from + 0.3
+	}
+	fill := image.Rect(
+		int(math.Floor(x+math.Max(0, from)*w)), int(math.Floor(y)),
+		int(math.Ceil(x+math.Min(1, to)*w)), int(math.Ceil(y+h)),
+	)
+	if fill.Empty() {
+		return
+	}
+	// the bar keeps the rounded ends of the track
+	render.pushClip(render.device(n.transformRect(fill)))
+	defer render.popClip()
+	render.rectangle(x, y, w, h, r)
+	render.setColor(n.Style.AccentColor, op)
+	render.canvas.Fill()
+}
+
+// sweeping reports whether n is a shown <progress> element without a
+// value, whose bar sweeps along it.
+func (n *Node) sweeping() bool {
+	if n.Data != "progress" || n.hidden() {
+		return false
+	}
+	_, ok := n.progress()
+	return !ok
+}
@@ -0,0 +1,290 @@
+package geui
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// rangeState is the state of a <slider> or <number> element.
+type rangeState struct {
+	committed string // value when last committed, to tell a change
+}
+
+// ranged returns the state of the <slider> or <number> element n, fitting
+// its value in its range first.
+func (n *Node) ranged() *rangeState {
+	if n.rangeState == nil {
+		v, err := strconv.ParseFloat(strings.TrimSpace(string(n.Value)), 64)
+		if err != nil {
+			min, _, _ := n.numberRange()
+			v = math.Max(0, min)
+		}
+		n.setText(n.formatNumber(v))
+		n.rangeState = &rangeState{committed: string(n.Value)}
+	}
+	return n.rangeState
+}
+
+// numberRange returns the min, max and step attributes of the <slider> or
+// <number> element n. A slider goes from 0 to 100 by default, a number
+// is not bounded; both step by 1.
+func (n *Node) numberRange() (min, max, step float64) {
+	min, max = math.Inf(-1), math.Inf(1)
+	if n.Data == "slider" {
+		min, max = 0, 100
+	}
+	attr := func(key string, def float64) float64 {
+		if v, err := strconv.ParseFloat(n.Attrs[key], 64); err == nil {
+			return v
+		}
+		return def
+	}
+	min, max, step = attr("min", min), attr("max", max), attr("step", 1)
+	if max < min {
+		max = min
+	}
+	if step <= 0 {
+		step = 1
+	}
+	return
+}
+
+// number returns the value of n as a number, or the value last committed
+// when it is not one.
+func (n *Node) number() float64 {
+	if v, err := strconv.ParseFloat(strings.TrimSpace(string(n.Value)), 64); err == nil {
+		return v
+	}
+	v, _ := strconv.ParseFloat(n.ranged().committed, 64)
+	return v
+}
+
+// formatNumber returns v within the range of n, on a step from min, with
+// as many decimals as the step.
+func (n *Node) formatNumber(v float64) string {
+	min, max, step := n.numberRange()
+	base := min
+	if math.IsInf(base, 0) {
+		base = 0
+	}
+	v = base + math.Round((v-base)/step)*step
+	if v > max {
+		v -= step
+	}
+	v = math.Max(min, math.Min(max, v))
+	decimals := 0
+	if s, ok := n.Attrs["step"]; ok {
+		if i := strings.IndexByte(s, '.'); i >= 0 {
+			decimals = len(s) - i - 1
+		}
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+// setText replaces the value of n with s, keeping the caret at the end.
+func (n *Node) setText(s string) {
+	if s != string(n.Value) {
+		n.Value = []rune(s)
+		n.markDirty()
+	}
+	n.caret = len(n.Value)
+}
+
+// setNumber sets the value of the <slider> or <number> element n to v,
+// fitted in its range, and reports whether it changed.
+func (n *Node) setNumber(v float64) bool {
+	old := string(n.Value)
+	n.setText(n.formatNumber(v))
+	return string(n.Value) != old
+}
+
+// invalid reports whether the text of the <number> element n is not a
+// number.
+func (n *Node) invalid() bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(string(n.Value)), 64)
+	return n.Data == "number" && err != nil
+}
+
+// commit fits the value of the <slider> or <number> element n in its
+// range, bringing back the last committed value when it is not a number,
+// and calls the change function of n when it changed since the last
+// commit.
+func (w *Window) commit(n *Node) {
+	st := n.ranged()
+	if n.invalid() {
+		n.setText(st.committed)
+	} else {
+		n.setNumber(n.number())
+	}
+	if string(n.Value) != st.committed {
+		st.committed = string(n.Value)
+		w.changed(n)
+	}
+}
+
+// step changes the value of the <slider> or <number> element n by k steps
+// and commits it.
+func (w *Window) step(n *Node, k float64) {
+	_, _, step := n.numberRange()
+	if n.setNumber(n.number() + k*step) {
+		w.inputted(n)
+	}
+	w.commit(n)
+}
+
+// click leaves the clicks on a <slider> or <number> element to the
+// pointer capture, see press.
+func (st *rangeState) click(w *Window, n, hit *Node) *Node {
+	return nil
+}
+
+// key handles the key k for the focused <slider> or <number> element n
+// and reports whether it was used. The arrow keys step the value, the
+// page keys by ten steps; on a slider, home and end go to the ends of the
+// range. Enter commits the text typed in a number.
+func (st *rangeState) key(w *Window, n *Node, k Key) bool {
+	if w.active != n {
+		return false
+	}
+	slider := n.Data == "slider"
+	switch {
+	case k == KeyUp, slider && k == KeyRight:
+		w.step(n, 1)
+	case k == KeyDown, slider && k == KeyLeft:
+		w.step(n, -1)
+	case k == KeyPageUp:
+		w.step(n, 10)
+	case k == KeyPageDown:
+		w.step(n, -10)
+	case slider && (k == KeyHome || k == KeyEnd):
+		min, max, _ := n.numberRange()
+		v := min
+		if k == KeyEnd {
+			v = max
+		}
+		if n.setNumber(v) {
+			w.inputted(n)
+		}
+		w.commit(n)
+	case !slider && k == KeyEnter:
+		w.commit(n)
+	default:
+		return false
+	}
+	return true
+}
+
+// sliderTrack returns the ends of the track of the <slider> element n the
+// centre of its thumb moves along, its vertical centre and the radius of
+// the thumb.
+func (n *Node) sliderTrack() (left, right, cy, r float64) {
+	x, y, w, h := n.content()
+	r = math.Min(8, h/2)
+	return x + r, x + w - r, y + h/2, r
+}
+
+// fraction returns how far the value of n is along its range, from 0 to 1.
+func (n *Node) fraction() float64 {
+	min, max, _ := n.numberRange()
+	if max <= min || math.IsInf(max-min, 0) {
+		return 0
+	}
+	return math.Max(0, math.Min(1, (n.number()-min)/(max-min)))
+}
+
+// slideTo moves the thumb of the <slider> element n to the pointer at x, y.
+func (w *Window) slideTo(n *Node, x, y float64) {
+	x, _ = n.local(x, y)
+	left, right, _, _ := n.sliderTrack()
+	min, max, _ := n.numberRange()
+	k := 0.0
+	if right > left {
+		k = math.Max(0, math.Min(1, (x-left)/(right-left)))
+	}
+	if n.setNumber(min + k*(max-min)) {
+		w.inputted(n)
+	}
+}
+
+// typeRune keeps the runes that are not part of a number out of the value
+// of a <number> element n.
+func (st *rangeState) typeRune(w *Window, n *Node, r rune) bool {
+	return n.Data == "number" && !strings.ContainsRune("0123456789.-+eE", r)
+}
+
+// drag moves the thumb of the <slider> element n to the pointer at x, y.
+func (st *rangeState) drag(w *Window, n *Node, x, y float64) {
+	w.slideTo(n, x, y)
+}
+
+// release commits the value of the <slider> element n and gives it the
+// focus.
+func (st *rangeState) release(w *Window, n *Node) {
+	w.commit(n)
+	w.focus(n)
+}
+
+// press handles the pointer pressed at x, y on the <slider> or <number>
+// element n: a slider moves its thumb there and captures the pointer
+// until it is released, the arrows of a number step its value.
+func (st *rangeState) press(w *Window, n *Node, x, y float64) {
+	if n.Data == "slider" {
+		w.capture = n
+		w.slideTo(n, x, y)
+		return
+	}
+	x, y = n.local(x, y)
+	cx, cy, cw, ch := n.content()
+	if x < cx+cw {
+		return
+	}
+	if y < cy+ch/2 {
+		w.step(n, 1)
+	} else {
+		w.step(n, -1)
+	}
+}
+
+// paintSlider draws the track of the <slider> element n, filled up to its
+// thumb with the accent colour, and the thumb.
+func (render *Renderer) paintSlider(n *Node) {
+	left, right, cy, r := n.sliderTrack()
+	op := n.opacity()
+	x := left + n.fraction()*(right-left)
+	render.rectangle(left, cy-2, right-left, 4, 2)
+	render.setColor(n.Style.TrackColor, op)
+	render.canvas.Fill()
+	render.rectangle(left, cy-2, x-left, 4, 2)
+	render.setColor(n.Style.AccentColor, op)
+	render.canvas.Fill()
+	render.canvas.DrawCircle(x, cy, r)
+	render.canvas.Fill()
+}
+
+// paintSpinArrows draws the arrows stepping the value of the <number>
+// element n in its right padding, and marks a text that is not a number
+// with a border of the invalid colour.
+func (render *Renderer) paintSpinArrows(n *Node) {
+	x, y, w, h := n.content()
+	op := n.opacity()
+	cx := x + w + n.Style.Padding.Right/2
+	for _, a := range [][2]float64{{y + h/4, -1}, {y + h*3/4, 1}} {
+		cy, dir := a[0], a[1]
+		render.canvas.MoveTo(cx-4, cy-2*dir)
+		render.canvas.LineTo(cx, cy+2*dir)
+		render.canvas.LineTo(cx+4, cy-2*dir)
+	}
+	render.setColor(n.Style.FontColor, op)
+	render.canvas.SetLineWidth(1.5 * render.scale)
+	render.canvas.Stroke()
+	if n.invalid() {
+		b := n.Style.BorderTop
+		b.Width = math.Max(1, b.Width)
+		b.Color = n.Style.InvalidColor
+		bw := b.Width
+		render.rectangle(n.Model.RelativeX+bw/2, n.Model.RelativeY+bw/2, n.Model.Width-bw, n.Model.Height-bw, n.Style.BorderRadius)
+		render.setBorderStyle(b, op)
+		render.canvas.Stroke()
+	}
+}
@@ -0,0 +1,133 @@
+package geui
+
+import (
+	"image/color"
+	"testing"
+	"time"
+)
+
+func newRangeWindow(t *testing.T) *Window {
+	w := newXMLWindow(t, `<window style="width: 300px; height: 300px; background-color: #ffffff; hover-color: #ffffff">
+	<slider id="volume" min="0" max="10" step="0.5" value="2" style="margin: 0; height: 20px"/>
+	<number id="count" min="1" max="99" value="5" style="margin: 0; height: 30px"/>
+	<progress id="job" value="30" max="120" style="margin: 0; height: 10px; track-color: #00ff00; accent-color: #0000ff"/>
+	<progress id="wait" style="margin: 0; height: 10px; track-color: #00ff00; accent-color: #0000ff"/>
+</window>`)
+	return w
+}
+
+func TestSlider(t *testing.T) {
+	w := newRangeWindow(t)
+	volume := w.node.GetNodeByID("volume")
+	var inputs, changes []string
+	w.OnInput("volume", func(v string) { inputs = append(inputs, v) })
+	w.OnChange("volume", func(v string) { changes = append(changes, v) })
+	if v := volume.GetValue(); v != "2.0" {
+		t.Fatalf("value = %q, want it with the decimals of the step", v)
+	}
+
+	// the track runs from 8 to 292
+	w.dispatch(MouseMove{X: 8 + 284*0.5, Y: 10})
+	w.dispatch(MouseDown{MouseButton: MouseLeft})
+	if v := volume.GetValue(); v != "5.0" || w.capture != volume {
+		t.Fatalf("value = %q after pressing the middle of the track", v)
+	}
+	// the slider keeps the pointer when it leaves it
+	w.dispatch(MouseMove{X: 8 + 284*0.76, Y: 200})
+	w.dispatch(MouseMove{X: 400, Y: 250})
+	w.dispatch(MouseUp{MouseButton: MouseLeft})
+	if v := volume.GetValue(); v != "10.0" || w.capture != nil || w.active != volume {
+		t.Errorf("value = %q after dragging past the end", v)
+	}
+	if len(inputs) != 3 || inputs[1] != "7.5" || len(changes) != 1 || changes[0] != "10.0" {
+		t.Errorf("inputs %v, changes %v", inputs, changes)
+	}
+
+	w.dispatch(KbDown{Key: KeyLeft})
+	w.dispatch(KbDown{Key: KeyPageDown})
+	if v := volume.GetValue(); v != "4.5" {
+		t.Errorf("value = %q after left and page down", v)
+	}
+	w.dispatch(KbDown{Key: KeyHome})
+	w.dispatch(KbType{rune: '7'})
+	if v := volume.GetValue(); v != "0.0" || len(changes) != 4 {
+		t.Errorf("value = %q after home and typing, changes %v", v, changes)
+	}
+	w.repaint()
+	if c := w.renderer.Image().RGBAAt(8, 10); c != (color.RGBA{0x5b, 0x9d, 0xd9, 0xff}) {
+		t.Errorf("pixel of the thumb at the start = %v", c)
+	}
+}
+
+func TestNumber(t *testing.T) {
+	w := newRangeWindow(t)
+	count := w.node.GetNodeByID("count")
+	var changes []string
+	w.OnChange("count", func(v string) { changes = append(changes, v) })
+	w.focus(count)
+	w.dispatch(KbDown{Key: KeyUp})
+	if v := count.GetValue(); v != "6" || len(changes) != 1 {
+		t.Errorf("value = %q after up, changes %v", v, changes)
+	}
+	// the arrows are in the right padding, up above down
+	click(w, 290, 25)
+	click(w, 290, 45)
+	click(w, 290, 45)
+	if v := count.GetValue(); v != "5" || len(changes) != 4 {
+		t.Errorf("value = %q after clicking the arrows, changes %v", v, changes)
+	}
+
+	w.dispatch(KbType{rune: 'x'})
+	w.dispatch(KbType{rune: '-'})
+	if v := count.GetValue(); v != "5-" || !count.invalid() {
+		t.Fatalf("value = %q after typing, want letters ignored", v)
+	}
+	w.repaint()
+	if c := w.renderer.Image().RGBAAt(150, 20); c != (color.RGBA{0xd9, 0x53, 0x4f, 0xff}) {
+		t.Errorf("pixel of the border of an invalid number = %v", c)
+	}
+	w.dispatch(KbDown{Key: KeyEnter})
+	if v := count.GetValue(); v != "5" || len(changes) != 4 {
+		t.Errorf("value = %q after confirming an invalid number", v)
+	}
+	count.SetValue("")
+	w.focus(count)
+	w.insert([]rune("250"))
+	w.focus(nil)
+	if v := count.GetValue(); v != "99" || len(changes) != 5 {
+		t.Errorf("value = %q after leaving a number out of range", v)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	w := newRangeWindow(t)
+	img := w.renderer.Image()
+	// 30 of 120 fills a quarter
+	if c := img.RGBAAt(70, 55); c != (color.RGBA{B: 0xff, A: 0xff}) {
+		t.Errorf("pixel of the filled part = %v, want the accent colour", c)
+	}
+	if c := img.RGBAAt(80, 55); c != (color.RGBA{G: 0xff, A: 0xff}) {
+		t.Errorf("pixel of the track = %v, want the track colour", c)
+	}
+
+	wait := w.node.GetNodeByID("wait")
+	if _, ok := wait.progress(); ok {
+		t.Fatal("progress without a value is determinate")
+	}
+	blue := func() (n int) {
+		for x := 0; x < 300; x++ {
+			if img.RGBAAt(x, 65) == (color.RGBA{B: 0xff, A: 0xff}) {
+				n++
+			}
+		}
+		return
+	}
+	w.renderer.now = time.Unix(0, int64(progressPeriod)/2)
+	w.renderer.Paint(wait.Bounds())
+	if n := blue(); n < 80 || n > 100 {
+		t.Errorf("indeterminate bar is %d wide, want 30%%", n)
+	}
+	if r := w.repaint(); !r.Overlaps(wait.Bounds()) {
+		t.Errorf("repaint %v left out the indeterminate bar", r)
+	}
+}
@@ -0,0 +1,405 @@
+package geui
+
+import "strconv"
+
+// Reconcile updates the tree of n in place to be like the tree of next,
+// e.g. a tree built again from the state of the program, or a reloaded
+// XML document. The children of both are matched by tag and key attribute,
+// or id, or else by their order among the children with the same tag.
+// Matched nodes keep their state, like the value, caret, focus and scroll
+// offsets of an input, and are marked dirty only when they changed; the
+// other children are removed, or moved from next. Styles and values are
+// compared as declared by the style sheets and the style, width, height and
+// value attributes: the properties and values that changed are set, the
+// others keep what the program or the user changed.
+//
+// n is replaced by next when their tags differ, and so are elements whose
+// state is built from their children, like a <select> from its options,
+// when these children changed; next takes their state. Reconcile returns
+// the node in place of n: n, or next when n was replaced. next is not to
+// be used afterwards.
+func (n *Node) Reconcile(next *Node) *Node {
+	r := n
+	if n.kind() != next.kind() || !n.patch(next) {
+		keepState(n, next)
+		r = next
+		switch {
+		case n.Parent != nil:
+			InsertBefore(n.Parent, next, n)
+			RemoveChild(n.Parent, n)
+		case n.window != nil:
+			n.window.setRoot(next)
+		}
+	}
+	if w := r.root().window; w != nil {
+		w.reattach()
+	}
+	return r
+}
+
+// key returns the key attribute of n, or else its id.
+func (n *Node) key() string {
+	if k, ok := n.Attrs["key"]; ok {
+		return k
+	}
+	return n.ID
+}
+
+// kind returns the tag of the element n, or its type for other nodes.
+func (n *Node) kind() string {
+	if n.Type == ElementNode {
+		return n.Data
+	}
+	return "#" + strconv.Itoa(int(n.Type))
+}
+
+// patch updates n in place to be like next. It reports false, leaving n
+// as it is, when the state of n was built from children that changed.
+func (n *Node) patch(next *Node) bool {
+	if n.Type != ElementNode {
+		if n.Data != next.Data {
+			n.Data = next.Data
+			n.markDirty()
+		}
+		return true
+	}
+	if !n.sameSources(next) {
+		return false
+	}
+	if n.ID != next.ID || n.Name != next.Name || n.Model.X != next.Model.X || n.Model.Y != next.Model.Y ||
+		!sameAttrs(n.Attrs, next.Attrs) {
+		if n.Attrs["position"] != next.Attrs["position"] {
+			// read again by a <splitter>
+			n.splitterState = nil
+		}
+		n.ID, n.Name, n.Attrs = next.ID, next.Name, next.Attrs
+		n.Model.X, n.Model.Y = next.Model.X, next.Model.Y
+		n.markDirty()
+	}
+	if n.initial != next.initial {
+		// the value typed in n stays until the attribute changes
+		n.initial = next.initial
+		n.SetValue(next.initial)
+	}
+	if n.declared != next.declared || n.matched != next.matched {
+		n.restyle(next.matched, next.declared)
+		if n.inMenubar() {
+			// the bar shows its menus again
+			n.Parent.Style.GridTemplateColumns = nil
+		}
+		n.markDirty()
+	}
+	// the functions of a tree built again are new closures, a loaded
+	// document keeps those set by id
+	if next.on != nil {
+		n.on = next.on
+	}
+	// parsed again from the new text of a <style>
+	n.sheet = nil
+	if _, taken, _ := n.sources(); !taken {
+		n.patchChildren(next)
+	}
+	return true
+}
+
+// patchChildren updates the children of n to be like those of next. The
+// children n inserted itself, like the bar of a <tabs>, stay, and those
+// next inserted are left out.
+func (n *Node) patchChildren(next *Node) {
+	type childKey struct{ kind, key string }
+	keyed := map[childKey]*Node{}
+	unkeyed := map[string][]*Node{}
+	var old []*Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if n.inserted(c) {
+			continue
+		}
+		old = append(old, c)
+		if k := c.key(); k != "" {
+			keyed[childKey{c.kind(), k}] = c
+		} else {
+			unkeyed[c.kind()] = append(unkeyed[c.kind()], c)
+		}
+	}
+	var children []*Node
+	kept := map[*Node]bool{}
+	for c := next.FirstChild; c != nil; c = c.NextSibling {
+		if next.inserted(c) {
+			continue
+		}
+		var o *Node
+		if k := c.key(); k != "" {
+			o = keyed[childKey{c.kind(), k}]
+			delete(keyed, childKey{c.kind(), k})
+		} else if q := unkeyed[c.kind()]; len(q) > 0 {
+			o, unkeyed[c.kind()] = q[0], q[1:]
+		}
+		switch {
+		case o != nil && o.patch(c):
+			kept[o] = true
+			children = append(children, o)
+		case o != nil:
+			keepState(o, c)
+			fallthrough
+		default:
+			children = append(children, c)
+		}
+	}
+	for _, c := range old {
+		if !kept[c] {
+			RemoveChild(n, c)
+		}
+	}
+	// move the children in the order of next, around the inserted ones
+	at := n.FirstChild
+	for _, c := range children {
+		for at != nil && n.inserted(at) {
+			at = at.NextSibling
+		}
+		if c == at {
+			at = at.NextSibling
+			continue
+		}
+		if c.Parent == n {
+			RemoveChild(n, c)
+		}
+		InsertBefore(n, c, at)
+	}
+}
+
+// inserted reports whether the child c was inserted by n, like the bar of
+// a <tabs> or the headers of an <accordion>.
+func (n *Node) inserted(c *Node) bool {
+	switch {
+	case n.tabsState != nil:
+		return c == n.tabsState.bar
+	case n.accordionState != nil:
+		for _, h := range n.accordionState.headers {
+			if c == h {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameSources reports whether the elements n and next, when their state
+// is built from their children, have the same attributes and children to
+// build it from, see sources.
+func (n *Node) sameSources(next *Node) bool {
+	a, taken, ok := n.sources()
+	if !ok {
+		return true
+	}
+	b, _, _ := next.sources()
+	if !sameAttrs(n.Attrs, next.Attrs) || len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameNode(a[i], b[i], taken) {
+			return false
+		}
+	}
+	return true
+}
+
+// sources returns the children the state of the element n is built from,
+// whether the state is built yet or not, and whether they are taken out
+// of the tree, like the options of a <select>, which makes their
+// descendants part of the state too. ok is false for the other elements.
+func (n *Node) sources() (sources []*Node, taken, ok bool) {
+	var source func(c *Node) bool
+	switch n.Data {
+	case "list":
+		if n.listState != nil {
+			if t := n.listState.template; t != nil {
+				return []*Node{t}, true, true
+			}
+			return nil, true, true
+		}
+		source = func(c *Node) bool { return len(sources) == 0 }
+		taken = true
+	case "select", "combobox":
+		if n.selectState != nil {
+			return n.selectState.options, true, true
+		}
+		source = func(c *Node) bool { return c.Data == "option" }
+		taken = true
+	case "menu":
+		if n.menuState != nil {
+			return n.menuState.items, true, true
+		}
+		source = func(c *Node) bool { return c.Data == "menuitem" || c.Data == "separator" }
+		taken = true
+	case "tabs":
+		if n.tabsState != nil {
+			return n.tabsState.tabs, false, true
+		}
+		source = func(c *Node) bool { return c.Data == "tab" }
+	case "accordion":
+		if n.accordionState != nil {
+			return n.accordionState.sections, false, true
+		}
+		source = func(c *Node) bool { return c.Data == "section" }
+	default:
+		return nil, false, false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == ElementNode && source(c) {
+			sources = append(sources, c)
+		}
+	}
+	return sources, taken, true
+}
+
+// sameNode reports whether the nodes a and b are declared alike, with
+// their descendants when deep.
+func sameNode(a, b *Node, deep bool) bool {
+	if a.Type != b.Type || a.Data != b.Data || a.ID != b.ID || a.Name != b.Name || a.declared != b.declared || a.matched != b.matched ||
+		string(a.Value) != string(b.Value) || a.Model.X != b.Model.X || a.Model.Y != b.Model.Y ||
+		!sameAttrs(a.Attrs, b.Attrs) {
+		return false
+	}
+	if !deep {
+		return true
+	}
+	ac, bc := a.FirstChild, b.FirstChild
+	for ; ac != nil && bc != nil; ac, bc = ac.NextSibling, bc.NextSibling {
+		if !sameNode(ac, bc, true) {
+			return false
+		}
+	}
+	return ac == nil && bc == nil
+}
+
+// sameAttrs reports whether the attributes a and b are the same.
+func sameAttrs(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// keepState gives the element n, which replaces the element old, the
+// state of old, then does the same for their children.
+func keepState(old, n *Node) {
+	if old.initial == n.initial {
+		n.Value, n.caret = old.Value, old.caret
+	}
+	n.scrollX, n.scrollY = old.scrollX, old.scrollY
+	n.hovered, n.focused = old.hovered, old.focused
+	if n.on == nil {
+		n.on = old.on
+	}
+	switch {
+	case old.listState != nil:
+		n.SetListModel(old.listState.model)
+		n.SetSelected(old.listState.selected)
+	case old.selectState != nil, old.tabsState != nil:
+		n.SetSelected(old.Selected())
+	case old.accordionState != nil:
+		for i := range old.accordionState.sections {
+			n.SetExpanded(i, old.Expanded(i))
+		}
+	}
+	if s := old.splitterState; s != nil && old.Attrs["position"] == n.Attrs["position"] {
+		n.SetPosition(s.pos)
+	}
+	if t := old.tableState; t != nil && len(t.widths) == len(n.table().widths) {
+		n.table().widths = t.widths
+	}
+	seen := map[string]int{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != ElementNode {
+			continue
+		}
+		if o := counterpart(old, c, seen[c.Data]); o != nil {
+			keepState(o, c)
+		}
+		seen[c.Data]++
+	}
+}
+
+// counterpart returns the child of old that the child c of a new tree
+// replaces: the one with the key of c, or else the one with the tag of c
+// and no key at the index i among the children with that tag.
+func counterpart(old, c *Node, i int) *Node {
+	for o := old.FirstChild; o != nil; o = o.NextSibling {
+		if o.Type != ElementNode || o.Data != c.Data {
+			continue
+		}
+		if k := c.key(); k != "" {
+			if o.key() == k {
+				return o
+			}
+			continue
+		}
+		if i == 0 {
+			if o.key() == "" {
+				return o
+			}
+			return nil
+		}
+		i--
+	}
+	return nil
+}
+
+// setRoot shows the tree of root in the window instead of its tree.
+func (w *Window) setRoot(root *Node) {
+	old := w.node
+	root.imageStore, root.window, root.source = old.imageStore, w, old.source
+	old.window = nil
+	w.node, w.renderer.node = root, root
+	w.invalidate()
+}
+
+// shows reports whether the node n is in a tree of the window.
+func (w *Window) shows(n *Node) bool {
+	r := n.root()
+	for _, t := range w.trees() {
+		if t == r {
+			return true
+		}
+	}
+	return false
+}
+
+// reattach lets go of the nodes Reconcile took out of the trees of the
+// window, and gives the focus to the node that took the state of the
+// focused one.
+func (w *Window) reattach() {
+	if w.popup != nil && !w.shows(w.popup) {
+		w.closePopup()
+	}
+	if w.menu != nil && !w.shows(w.menu) {
+		w.closeMenu()
+	}
+	if w.capture != nil && !w.shows(w.capture) {
+		w.capture = nil
+	}
+	if w.drag != nil && !w.shows(w.drag.node) {
+		w.drag = nil
+	}
+	if w.resizing != nil && !w.shows(w.resizing.table) {
+		w.resizing = nil
+	}
+	if w.tip.target != nil && !w.shows(w.tip.target) {
+		w.hideTooltip()
+		w.tip.target = nil
+	}
+	if w.active != nil && !w.shows(w.active) {
+		w.active = nil
+		for _, n := range w.node.GetNodes() {
+			if n.focused {
+				w.active = n
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package geui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	root := loadTestXML(t, `<window style="width: 300px; height: 400px">
+	<input id="name"></input>
+	<box key="a" style="height: 20px">A</box>
+	<box key="b" style="height: 20px">B</box>
+	<box key="c" style="height: 20px">C</box>
+	<box id="label" style="height: 20px">Hello</box>
+</window>`)
+	w, err := NewWindow(root, Size(300, 400), WithBackend(NewHeadless()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.repaint()
+	input := root.GetNodeByID("name")
+	inputs := 0
+	w.OnInput("name", func(string) { inputs++ })
+	w.focus(input)
+	w.insert([]rune("Ada"))
+	keyed := map[string]*Node{}
+	for _, n := range root.GetNodes() {
+		if k, ok := n.Attrs["key"]; ok {
+			keyed[k] = n
+		}
+	}
+	label := root.GetNodeByID("label")
+	w.repaint()
+
+	next := loadTestXML(t, `<window style="width: 300px; height: 400px">
+	<input id="name"></input>
+	<box key="c" style="height: 20px">C</box>
+	<box key="a" style="height: 20px">A</box>
+	<box key="d" style="height: 30px">D</box>
+	<box id="label" style="height: 20px">Hello</box>
+</window>`)
+	if r := root.Reconcile(next); r != root {
+		t.Fatal("root replaced")
+	}
+	var order []string
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		order = append(order, c.key())
+	}
+	if got := fmt.Sprint(order); got != "[name c a d label]" {
+		t.Errorf("children %s", got)
+	}
+	if root.GetNodeByID("name") != input || input.GetValue() != "Ada" || w.active != input {
+		t.Error("input not kept with its value and focus")
+	}
+	if w.insert([]rune("!")); inputs != 2 {
+		t.Errorf("%d inputs, want the input function kept", inputs)
+	}
+	if c := root.FirstChild.NextSibling; c != keyed["c"] || c.NextSibling != keyed["a"] {
+		t.Error("keyed children not moved")
+	}
+	if keyed["b"].Parent != nil {
+		t.Error("removed child still in the tree")
+	}
+	if label.dirty || label.FirstChild.dirty || keyed["a"].FirstChild.dirty {
+		t.Error("unchanged nodes marked dirty")
+	}
+
+	// text and styles are updated in place
+	next = loadTestXML(t, `<window style="width: 300px; height: 400px">
+	<input id="name"></input>
+	<box key="c" style="height: 20px">C</box>
+	<box key="a" style="height: 40px">A</box>
+	<box key="d" style="height: 30px">D</box>
+	<box id="label" style="height: 20px">Bye</box>
+</window>`)
+	w.repaint()
+	root.Reconcile(next)
+	if keyed["a"].Style.Height != 40 || !keyed["a"].dirty || keyed["c"].dirty {
+		t.Error("style not updated, or updated on the wrong node")
+	}
+	if label.FirstChild.Data != "Bye" || !label.FirstChild.dirty || label.dirty {
+		t.Error("text not updated in place")
+	}
+
+	// only the declared properties and values that changed are set
+	keyed["a"].Style.BackgroundColor = "#00ff00"
+	next = loadTestXML(t, `<window style="width: 300px; height: 400px">
+	<input id="name" value="Bob"></input>
+	<box key="c" style="height: 20px">C</box>
+	<box key="a" style="height: 50px; font-color: #ff0000">A</box>
+	<box key="d" style="height: 30px">D</box>
+	<box id="label" style="height: 20px">Bye</box>
+</window>`)
+	root.Reconcile(next)
+	if st := keyed["a"].Style; st.Height != 50 || st.FontColor != "#ff0000" || st.BackgroundColor != "#00ff00" {
+		t.Errorf("height %v, font colour %q, background %q: want the new declarations and the background kept", st.Height, st.FontColor, st.BackgroundColor)
+	}
+	if v := input.GetValue(); v != "Bob" {
+		t.Errorf("input value %q, want the new value attribute", v)
+	}
+	w.insert([]rune("by"))
+	next = loadTestXML(t, `<window style="width: 300px; height: 400px">
+	<input id="name" value="Bob"></input>
+	<box key="c" style="height: 20px">C</box>
+</window>`)
+	root.Reconcile(next)
+	if v := input.GetValue(); v != "Bobby" {
+		t.Errorf("input value %q, want the typed value kept while the attribute is unchanged", v)
+	}
+}
+
+func TestReconcileWidgets(t *testing.T) {
+	src := func(options, content string) string {
+		return `<window style="width: 300px; height: 400px">
+	<select id="s">` + options + `</select>
+	<tabs id="t"><tab title="One"></tab><tab title="Two"><input id="i"></input>` + content + `</tab></tabs>
+</window>`
+	}
+	root := loadTestXML(t, src(`<option>a</option><option>b</option>`, ""))
+	w, err := NewWindow(root, Size(300, 400), WithBackend(NewHeadless()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.repaint()
+	s, tabs := root.GetNodeByID("s"), root.GetNodeByID("t")
+	s.SetSelected(1)
+	tabs.SetSelected(1)
+	root.GetNodeByID("i").SetValue("x")
+	w.repaint()
+
+	// the content of a tab changes in place
+	root.Reconcile(loadTestXML(t, src(`<option>a</option><option>b</option>`, "<box>new</box>")))
+	if root.GetNodeByID("t") != tabs || tabs.Selected() != 1 || root.GetNodeByID("s") != s {
+		t.Error("tabs or select replaced for a change of their content")
+	}
+	if tabs.tabsState.tabs[1].LastChild.Data != "box" {
+		t.Error("tab content not updated")
+	}
+
+	// new options rebuild the select, with its state
+	root.Reconcile(loadTestXML(t, src(`<option>a</option><option>b</option><option>c</option>`, "<box>new</box>")))
+	if n := root.GetNodeByID("s"); n == s || n.Selected() != 1 || len(n.choice().options) != 3 {
+		t.Error("select not rebuilt with its chosen option")
+	}
+	if s.Parent != nil {
+		t.Error("old select still in the tree")
+	}
+	// a new tab rebuilds the tabs, with the values of their inputs
+	root.Reconcile(loadTestXML(t, `<window style="width: 300px; height: 400px">
+	<select id="s"><option>a</option><option>b</option><option>c</option></select>
+	<tabs id="t"><tab title="One"></tab><tab title="Two"><input id="i"></input><box>new</box></tab><tab title="Three"></tab></tabs>
+</window>`))
+	n := root.GetNodeByID("t")
+	if n == tabs || n.Selected() != 1 || len(n.tabs().tabs) != 3 || root.GetNodeByID("i").GetValue() != "x" {
+		t.Error("tabs not rebuilt with their state")
+	}
+	w.repaint()
+}
+
+func TestReconcileRootTag(t *testing.T) {
+	root := loadTestXML(t, `<window style="width: 300px; height: 200px"><input id="name"></input></window>`)
+	w, err := NewWindow(root, Size(300, 200), WithBackend(NewHeadless()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.repaint()
+	w.focus(root.GetNodeByID("name"))
+	w.insert([]rune("Ada"))
+	next := loadTestXML(t, `<box style="width: 300px; height: 200px"><input id="name"></input></box>`)
+	if r := root.Reconcile(next); r != next || w.node != next {
+		t.Fatal("root with another tag patched in place")
+	}
+	if st := w.node.Style; w.node.Data != "box" || st.BackgroundColor != newElementStyle("box").BackgroundColor {
+		t.Errorf("root <%s> with background %q, want a box", w.node.Data, st.BackgroundColor)
+	}
+	if v := w.node.GetNodeByID("name").GetValue(); v != "Ada" {
+		t.Errorf("input value %q, want the state kept", v)
+	}
+	w.repaint()
+}
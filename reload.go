@@ -0,0 +1,121 @@
+package geui
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// reloadInterval is how often the files of a hot reloaded window are
+// checked for changes.
+const reloadInterval = 500 * time.Millisecond
+
+// HotReload option reloads the document of the window, loaded with
+// LoadXML, when its file or a style sheet linked with <style src> changes
+// on disk. It is meant for development: the state of the nodes, like the
+// values of inputs, the focus and scroll offsets, is kept, and an error in
+// the document shows over the window instead of stopping the program.
+func HotReload() WindowOption {
+	return func(o *windowOptions) {
+		o.hotReload = true
+	}
+}
+
+// fileStamp tells whether a file changed since it was last checked.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// hotReload is the state of a hot reloaded window.
+type hotReload struct {
+	stamps map[string]fileStamp // files of the document
+	error  *Node                // overlay showing why the document did not load, or nil
+}
+
+// watch checks the files of the document of the window for changes every
+// reloadInterval.
+func (w *Window) watch() {
+	w.reload.stamps = w.node.stampFiles()
+	w.AfterFunc(reloadInterval, w.pollFiles)
+}
+
+// stampFile returns the stamp of the file described by fi, empty when
+// err tells it is missing.
+func stampFile(fi fs.FileInfo, err error) fileStamp {
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{fi.ModTime(), fi.Size()}
+}
+
+// stampFiles returns the stamps of the files of the tree of the root n:
+// its XML file, read from the working directory like LoadXML does, and its
+// linked style sheets, read like its images.
+func (n *Node) stampFiles() map[string]fileStamp {
+	stamps := map[string]fileStamp{n.source: stampFile(os.Stat(n.source))}
+	for _, c := range n.GetNodes() {
+		if src, ok := c.Attrs["src"]; ok && c.Data == "style" {
+			stamps[src] = stampFile(n.images().stat(src))
+		}
+	}
+	return stamps
+}
+
+// pollFiles reloads the document when one of its files changed, until
+// the window closes.
+func (w *Window) pollFiles() {
+	if w.backend.ShouldClose() {
+		return
+	}
+	w.AfterFunc(reloadInterval, w.pollFiles)
+	if stamps := w.node.stampFiles(); !sameStamps(stamps, w.reload.stamps) {
+		w.reloadDocument()
+	}
+}
+
+// sameStamps reports whether the files stamped a and b are unchanged.
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for p, s := range a {
+		if b[p] != s {
+			return false
+		}
+	}
+	return true
+}
+
+// reloadDocument parses the XML file of the window again and reconciles its
+// tree with the new one, or shows the error when it does not parse.
+func (w *Window) reloadDocument() {
+	// the images may have changed too
+	w.node.images().clear()
+	root, err := parseXML(w.node.source, w.node.images())
+	if err != nil {
+		w.reload.stamps = w.node.stampFiles()
+		w.showReloadError(err)
+		return
+	}
+	w.showReloadError(nil)
+	w.node.Reconcile(root)
+	w.reload.stamps = w.node.stampFiles()
+}
+
+// showReloadError shows err in an overlay at the top of the window, or
+// hides the shown error when err is nil.
+func (w *Window) showReloadError(err error) {
+	if n := w.reload.error; n != nil {
+		w.closeOverlay(n)
+		w.reload.error = nil
+	}
+	if err == nil {
+		return
+	}
+	n := &Node{Type: ElementNode, Data: "error", Style: newElementStyle("error"), Model: new(Model)}
+	AddChild(n, &Node{Type: CharDataNode, Data: err.Error(), Model: new(Model)})
+	at := &Node{Type: ElementNode, Model: &Model{Width: w.renderer.width}}
+	w.reload.error = n
+	w.openOverlay(n, at)
+}
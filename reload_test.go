@@ -0,0 +1,139 @@
+package geui
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestHotReload(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "main.xml")
+	sheet := filepath.Join(dir, "main.css")
+	write := func(name, src string) {
+		if err := ioutil.WriteFile(name, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// the file looks changed even within the resolution of its time
+		at := time.Now().Add(time.Duration(len(src)) * time.Second)
+		if err := os.Chtimes(name, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(sheet, "")
+	write(p, `<window style="width: 300px; height: 300px">
+	<style src="`+sheet+`"></style>
+	<input id="name"></input>
+	<tabs><tab title="One"></tab><tab title="Two"></tab></tabs>
+</window>`)
+	clock := NewManualClock(time.Unix(0, 0))
+	w, err := NewWindow(LoadXML(p), Size(300, 300), WithBackend(NewHeadless()), WithClock(clock), HotReload())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.repaint()
+	poll := func() image.Rectangle {
+		clock.Advance(reloadInterval)
+		w.runTimers(clock.Now())
+		return w.repaint()
+	}
+	tabs := func() *Node {
+		for _, n := range w.node.GetNodes() {
+			if n.Data == "tabs" {
+				return n
+			}
+		}
+		return nil
+	}
+	input := w.node.GetNodeByID("name")
+	w.focus(input)
+	w.insert([]rune("Ada"))
+	tabs().SetSelected(1)
+	w.repaint()
+	if r := poll(); !r.Empty() {
+		t.Fatalf("repainted %v without a change", r)
+	}
+
+	write(p, `<window style="width: 300px; height: 300px">
+	<style src="`+sheet+`"></style>
+	<box id="new" style="height: 20px"></box>
+	<input id="name" style="width: 120px"></input>
+	<tabs><tab title="One"></tab><tab title="Two"></tab></tabs>
+</window>`)
+	poll()
+	if w.node.GetNodeByID("new") == nil || input.Style.Width != 120 {
+		t.Fatal("document not reloaded")
+	}
+	if input.GetValue() != "Ada" || w.active != input || !input.focused {
+		t.Errorf("input value %q, focused %v: want its state kept", input.GetValue(), input.focused)
+	}
+	if i := tabs().Selected(); i != 1 {
+		t.Errorf("tab %d shown, want the second one", i)
+	}
+
+	// a change of the linked style sheet reloads too, with its rules
+	write(sheet, "@keyframes fade { to { opacity: 0 } } input { font-color: #ff0000; width: 80px }")
+	poll()
+	if _, ok := w.node.GetNodes()[1].stylesheet().keyframes["fade"]; !ok {
+		t.Error("style sheet not reloaded")
+	}
+	if input.Style.FontColor != "#ff0000" || input.Style.Width != 120 {
+		t.Errorf("input font colour %q, width %v: want the colour of the sheet and the width of the attribute", input.Style.FontColor, input.Style.Width)
+	}
+
+	// errors show over the window, which keeps the tree that loaded
+	write(p, `<window style="width: 300px; height: 300px"><input></window>`)
+	poll()
+	if w.node.GetNodeByID("new") == nil || w.reload.error == nil || len(w.renderer.overlays) != 1 {
+		t.Fatal("no error shown for a broken document")
+	}
+	if w.overlayAt(10, 10) != nil {
+		t.Error("the error overlay is hit by the pointer")
+	}
+	write(p, `<window style="width: 300px; height: 300px"><input id="name"></input></window>`)
+	poll()
+	if w.node.GetNodeByID("new") != nil || w.reload.error != nil || len(w.renderer.overlays) != 0 {
+		t.Error("error still shown after the document was fixed")
+	}
+	if v := w.node.GetNodeByID("name").GetValue(); v != "Ada" {
+		t.Errorf("input value %q after the error, want it kept", v)
+	}
+}
+
+func TestHotReloadFS(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "main.xml")
+	if err := ioutil.WriteFile(p, []byte(`<window><style src="main.css"></style><box id="box"></box></window>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{"main.css": {Data: []byte("box { height: 20px }"), ModTime: time.Unix(1, 0)}}
+	clock := NewManualClock(time.Unix(0, 0))
+	w, err := NewWindow(LoadXML(p), Size(300, 300), WithBackend(NewHeadless()), WithClock(clock), WithFS(fsys), HotReload())
+	if err != nil {
+		t.Fatal(err)
+	}
+	box := w.node.GetNodeByID("box")
+	if box.Style.Height != 20 {
+		t.Fatalf("box height %v, want 20 from the sheet in the file system of the window", box.Style.Height)
+	}
+	poll := func() {
+		clock.Advance(reloadInterval)
+		w.runTimers(clock.Now())
+		w.repaint()
+	}
+	fsys["main.css"] = &fstest.MapFile{Data: []byte("box { height: 30px }"), ModTime: time.Unix(2, 0)}
+	poll()
+	if box.Style.Height != 30 {
+		t.Errorf("box height %v, want 30 after the sheet changed", box.Style.Height)
+	}
+
+	// polling stops with the window
+	w.Close()
+	poll()
+	if len(w.timers) != 0 {
+		t.Errorf("%d timers left after the window closed", len(w.timers))
+	}
+}
@@ -2,96 +2,272 @@ package geui
 
 import (
 	"github.com/fogleman/gg"
-	"github.com/golang/freetype/truetype"
-	"io/ioutil"
-	"log"
+	"image"
+	"image/draw"
+	"math"
 	"os"
+	"time"
 )
 
 type Renderer struct {
-	canvas      *gg.Context
-	nodes       []*Node
-	loadedFonts map[string]*truetype.Font
+	canvas        *gg.Context
+	node          *Node
+	width, height float64              // logical size of the canvas
+	scale         float64              // device pixels per logical unit
+	clips         []image.Rectangle    // stack of regions painting is restricted to
+	mask          *image.Alpha         // mask of the clip
+	masked        image.Rectangle      // region where mask is opaque
+	painters      map[string]PaintFunc // paint functions of <canvas> elements by id
+	overlays      []*overlay           // trees painted above the node tree
+	now           time.Time            // time of the frame, for animations
 }
 
 func NewRenderer(n *Node) *Renderer {
-	return &Renderer{
-		canvas:      gg.NewContext(int(n.Model.Width), int(n.Model.Height)),
-		loadedFonts: map[string]*truetype.Font{},
-		nodes:       n.GetNodes(),
+	return newRenderer(n, n.Model.Width, n.Model.Height)
+}
+
+func newRenderer(n *Node, width, height float64) *Renderer {
+	render := &Renderer{
+		node:     n,
+		scale:    1,
+		painters: map[string]PaintFunc{},
 	}
+	render.Resize(width, height)
+	return render
 }
 
-func (render *Renderer) Render(filename string) {
+// Image returns the canvas the nodes are painted on, in device pixels.
+func (render *Renderer) Image() *image.RGBA {
+	return render.canvas.Image().(*image.RGBA)
+}
 
-	var setFontFace = func(n *Node) {
-		f, ok := render.loadedFonts[n.Parent.Style.FontFamily]
-		if !ok {
-			fontBytes, err := ioutil.ReadFile(n.Parent.Style.FontFamily)
-			if err != nil {
-				log.Println(err)
-				return
-			}
-			f, err = truetype.Parse(fontBytes)
-			if err != nil {
-				log.Println(err)
-				return
-			}
-			render.loadedFonts[n.Parent.Style.FontFamily] = f
-		}
-		face := truetype.NewFace(f, &truetype.Options{
-			Size: n.Parent.Style.FontSize,
-		})
-		render.canvas.SetFontFace(face)
+// Resize replaces the canvas with one of the given logical size, keeping
+// the pixels painted so far.
+func (render *Renderer) Resize(width, height float64) {
+	render.resize(width, height, render.scale)
+}
+
+// resize replaces the canvas with one of the given logical size and scale,
+// unless it has them already.
+func (render *Renderer) resize(width, height, scale float64) {
+	if render.canvas != nil && width == render.width && height == render.height && scale == render.scale {
+		return
 	}
-	for _, n := range render.nodes {
-		switch n.Type {
-		case ElementNode:
-			switch n.Data {
-			case "input":
-				render.canvas.SetHexColor(n.Style.BorderColor)
-				render.canvas.SetLineWidth(n.Style.BorderWidth)
-				x, y := n.Model.RelativeX+0.5, n.Model.RelativeY+0.5
-				w, h := n.Model.Width, n.Model.Height
-				render.canvas.DrawLine(x, y, x+w, y)
-				render.canvas.DrawLine(x, y, x, y+h)
-				render.canvas.DrawLine(x+w, y, x+w, y+h)
-				render.canvas.DrawLine(x, y+h, x+w, y+h)
-				fw, _ := render.canvas.MeasureString(string(n.Value))
-				fw /= 2
-				afw := fw / float64(len(n.Value))
-				v := n.Value
-				if fw > w {
-					v = n.Value[len(n.Value)-int(w/afw)-1:]
-					fw = w - 5
-				}
-				if len(n.Value) != 0 {
-					render.canvas.DrawLine(fw+x, y+5, fw+x, h+y-5)
-				}
-				render.canvas.Stroke()
-				setFontFace(n)
-				render.canvas.SetHexColor(n.Style.FontColor)
-				if len(n.Value) != 0 {
-					render.canvas.DrawStringAnchored(string(v), n.Model.RelativeX+6, n.Model.RelativeY+h/2, 0, 0.4)
-				}
-			case "checkbox":
-
-			default:
-				render.canvas.DrawRectangle(n.Model.RelativeX, n.Model.RelativeY, n.Model.Width, n.Model.Height)
-				render.canvas.SetHexColor(n.Style.BackgroundColor)
-				render.canvas.Fill()
-			}
-		case CharDataNode:
-			setFontFace(n)
-			render.canvas.SetHexColor(n.Parent.Style.FontColor)
-			w, h := n.Parent.Model.Width, n.Parent.Model.Height
-			render.canvas.DrawStringAnchored(n.Data, n.Parent.Model.RelativeX+w/2, n.Parent.Model.RelativeY+h/2, 0.5, 0.5)
-		}
+	render.width, render.height, render.scale = width, height, scale
+	canvas := gg.NewContext(int(math.Ceil(width*scale)), int(math.Ceil(height*scale)))
+	if render.canvas != nil {
+		canvas.DrawImage(render.canvas.Image(), 0, 0)
 	}
-	fi, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC, 0755)
+	canvas.Scale(scale, scale)
+	render.canvas = canvas
+}
+
+// Scale returns the number of device pixels per logical unit.
+func (render *Renderer) Scale() float64 {
+	return render.scale
+}
+
+// SetScale sets the number of device pixels per logical unit, e.g. 2 on
+// HiDPI displays. The canvas has to be repainted afterwards.
+func (render *Renderer) SetScale(scale float64) {
+	render.resize(render.width, render.height, scale)
+}
+
+// device converts a region in logical units to the device pixels covering it.
+func (render *Renderer) device(r image.Rectangle) image.Rectangle {
+	s := render.scale
+	return image.Rect(
+		int(math.Floor(float64(r.Min.X)*s)),
+		int(math.Floor(float64(r.Min.Y)*s)),
+		int(math.Ceil(float64(r.Max.X)*s)),
+		int(math.Ceil(float64(r.Max.Y)*s)),
+	)
+}
+
+func (render *Renderer) Render(filename string) {
+	render.Paint(image.Rect(0, 0, int(math.Ceil(render.width)), int(math.Ceil(render.height))))
+	fi, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		panic(err)
 	}
 	defer fi.Close()
-	_ = render.canvas.EncodePNG(fi)
+	if err := render.canvas.EncodePNG(fi); err != nil {
+		panic(err)
+	}
+}
+
+// Paint clears the damage region, given in logical units, and repaints the
+// nodes intersecting it. Pixels outside of the region are left untouched.
+// It returns the repainted region of the image.
+func (render *Renderer) Paint(damage image.Rectangle) image.Rectangle {
+	bounds := render.Image().Bounds()
+	r := render.device(damage).Intersect(bounds)
+	if r.Empty() {
+		return r
+	}
+	draw.Draw(render.Image(), r, image.Transparent, image.Point{}, draw.Src)
+	render.pushClip(r)
+	defer render.popClip()
+	render.paintTree(render.node, damage)
+	for _, o := range render.overlays {
+		render.paintTree(o.node, damage)
+	}
+	return r
+}
+
+// paintTree repaints the nodes of the tree of n intersecting damage.
+func (render *Renderer) paintTree(n *Node, damage image.Rectangle) {
+	nodes := n.GetNodes()
+	for _, n := range nodes {
+		if area := n.area(); area.Overlaps(damage) {
+			render.clipped(n, render.paint)
+			n.painted = area
+		}
+	}
+	// scrollbars are above the content they scroll
+	for _, n := range nodes {
+		if n.Type == ElementNode && n.Style.scrolls() && n.area().Overlaps(damage) {
+			render.clipped(n, render.paintScrollbars)
+		}
+	}
+}
+
+// clipped calls paint for n within the part of the canvas its scrolling
+// ancestors do not clip, with the canvas transformed as n is.
+func (render *Renderer) clipped(n *Node, paint func(n *Node)) {
+	if r, clipped := n.visible(); clipped {
+		render.pushClip(render.device(r))
+		defer render.popClip()
+	}
+	render.canvas.Push()
+	defer render.canvas.Pop()
+	n.transform(render.canvas)
+	paint(n)
+}
+
+// pushClip restricts painting to the region r of the image, within the
+// current clip, until the matching popClip.
+func (render *Renderer) pushClip(r image.Rectangle) {
+	r = r.Intersect(render.clip())
+	render.clips = append(render.clips, r)
+	render.setClip(r)
+}
+
+// popClip restores the clip before the last pushClip.
+func (render *Renderer) popClip() {
+	render.clips = render.clips[:len(render.clips)-1]
+	render.setClip(render.clip())
+}
+
+// clip returns the region of the image painting is restricted to.
+func (render *Renderer) clip() image.Rectangle {
+	if len(render.clips) == 0 {
+		return render.Image().Bounds()
+	}
+	return render.clips[len(render.clips)-1]
+}
+
+// setClip restricts painting to the region r of the image. The mask is
+// reused from clip to clip, clearing only the region of the last one.
+func (render *Renderer) setClip(r image.Rectangle) {
+	bounds := render.Image().Bounds()
+	if r == bounds {
+		render.canvas.ResetClip()
+		return
+	}
+	if render.mask == nil || render.mask.Bounds() != bounds {
+		render.mask = image.NewAlpha(bounds)
+		render.masked = image.Rectangle{}
+	}
+	draw.Draw(render.mask, render.masked, image.Transparent, image.Point{}, draw.Src)
+	draw.Draw(render.mask, r, image.Opaque, image.Point{}, draw.Src)
+	render.masked = r
+	_ = render.canvas.SetMask(render.mask)
+}
+
+// drawText draws the line s in the style st and the current colour, with
+// the start of its baseline at the logical point x, y. Text is shaped at
+// the device scale to stay sharp.
+func (render *Renderer) drawText(st *CSStyle, s string, x, y float64) {
+	render.canvas.Push()
+	render.canvas.Scale(1/render.scale, 1/render.scale)
+	styleFace(st, render.scale).draw(render.canvas, s, x*render.scale, y*render.scale)
+	render.canvas.Pop()
+}
+
+func (render *Renderer) paint(n *Node) {
+	switch n.Type {
+	case ElementNode:
+		render.paintBox(n)
+		switch n.Data {
+		case "input":
+			render.paintValue(n)
+		case "select":
+			render.paintDropArrow(n)
+		case "combobox":
+			render.paintValue(n)
+			render.paintDropArrow(n)
+		case "number":
+			render.paintValue(n)
+			render.paintSpinArrows(n)
+		case "slider":
+			render.paintSlider(n)
+		case "progress":
+			render.paintProgress(n)
+		case "image":
+			render.paintImage(n)
+		case "canvas":
+			render.paintCanvas(n)
+		case "th":
+			render.paintSortArrow(n)
+		case "summary":
+			render.paintChevron(n)
+		case "splitter":
+			render.paintDivider(n)
+		case "menuitem":
+			render.paintShortcut(n)
+		}
+	case CharDataNode:
+		p := n.Parent
+		render.pushClip(render.device(p.canvasBounds()))
+		x, y, _, h := p.content()
+		y += n.text.offset(p.Style.VerticalAlign, h)
+		if p.Style.scrolls() {
+			x -= p.scrollX
+			y -= p.scrollY
+		}
+		render.setColor(p.fontColor(), p.opacity())
+		for _, l := range n.text.lines {
+			render.drawText(p.Style, l.text, x+l.x, y+l.y)
+		}
+		render.popClip()
+	}
+}
+
+// paintValue draws the value of the input n in its content box, with the
+// caret when it is focused.
+func (render *Renderer) paintValue(n *Node) {
+	render.pushClip(render.device(n.canvasBounds()))
+	defer render.popClip()
+	x, y, _, h := n.content()
+	face, tx, carets := inputText(n)
+	ascent, descent, _ := face.metrics()
+	baseline := y + (h-ascent-descent)/2 + ascent
+	render.setColor(n.fontColor(), n.opacity())
+	if n.focused {
+		cx := x + tx + carets[n.caret]
+		render.canvas.SetLineWidth(render.scale)
+		render.canvas.DrawLine(cx, baseline-ascent, cx, baseline+descent)
+		render.canvas.Stroke()
+	}
+	render.drawText(n.Style, string(n.Value), x+tx, baseline)
+}
+
+// drawImage draws im with its top left corner at the device pixel x, y,
+// through the transform of the canvas.
+func (render *Renderer) drawImage(im image.Image, x, y int) {
+	render.canvas.Push()
+	render.canvas.Scale(1/render.scale, 1/render.scale)
+	render.canvas.DrawImage(im, x, y)
+	render.canvas.Pop()
 }
@@ -0,0 +1,79 @@
+package geui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+var magenta = color.RGBA{R: 0xff, B: 0xff, A: 0xff}
+
+func TestRendererPaintDamage(t *testing.T) {
+	n := LoadXML("testdata/main.xml")
+	r := NewRenderer(n)
+	img := r.Image()
+	draw.Draw(img, img.Bounds(), image.NewUniform(magenta), image.Point{}, draw.Src)
+
+	damage := image.Rect(20, 20, 60, 40)
+	r.Paint(damage)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			in := image.Pt(x, y).In(damage)
+			if c := img.RGBAAt(x, y); (c == magenta) == in {
+				t.Fatalf("pixel %d,%d = %v, inside damage: %v", x, y, c, in)
+			}
+		}
+	}
+}
+
+func TestRendererNodeDamage(t *testing.T) {
+	n := LoadXML("testdata/main.xml")
+	r := NewRenderer(n)
+	r.Paint(r.Image().Bounds())
+	n.clean()
+	before := image.NewRGBA(r.Image().Bounds())
+	draw.Draw(before, before.Bounds(), r.Image(), image.Point{}, draw.Src)
+
+	button := n.LastChild
+	button.SetStyle("background-color:#ff0000")
+	damage := n.damage()
+	if damage != button.Bounds() {
+		t.Fatalf("damage = %v, want %v", damage, button.Bounds())
+	}
+	r.Paint(damage)
+	img := r.Image()
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !image.Pt(x, y).In(damage) && img.RGBAAt(x, y) != before.RGBAAt(x, y) {
+				t.Fatalf("pixel %d,%d outside of the damage changed", x, y)
+			}
+		}
+	}
+	if c := img.RGBAAt(button.Bounds().Min.X+1, button.Bounds().Min.Y+1); c != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Fatalf("button pixel = %v, want red", c)
+	}
+}
+
+func TestRendererScale(t *testing.T) {
+	n := LoadXML("testdata/main.xml")
+	r := NewRenderer(n)
+	r.SetScale(2)
+	if got, want := r.Image().Bounds().Size(), image.Pt(600, 350); got != want {
+		t.Fatalf("image size = %v, want %v", got, want)
+	}
+	button := n.LastChild
+	button.SetStyle("background-color:#ff0000")
+	if got, want := r.Paint(button.Bounds()), image.Rect(20, 230, 580, 330); got != want {
+		t.Fatalf("painted %v, want %v", got, want)
+	}
+	red := color.RGBA{R: 0xff, A: 0xff}
+	if c := r.Image().RGBAAt(21, 231); c != red {
+		t.Fatalf("button pixel = %v, want red", c)
+	}
+	if c := r.Image().RGBAAt(19, 231); c == red {
+		t.Fatal("button painted outside of its scaled bounds")
+	}
+}
@@ -0,0 +1,298 @@
+package geui
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	scrollStep = 40 // logical units scrolled per notch of the wheel
+	minThumb   = 20 // shortest length of a scrollbar thumb
+)
+
+// scrolls reports whether the element clips its content, which may then
+// be scrolled.
+func (st *CSStyle) scrolls() bool {
+	return st.Overflow == "hidden" || st.Overflow == "scroll" || st.Overflow == "auto"
+}
+
+// ScrollOffset returns how far the content of the element is scrolled.
+func (n *Node) ScrollOffset() (x, y float64) {
+	return n.scrollX, n.scrollY
+}
+
+// ScrollTo scrolls the content of the element to the offset x, y, kept
+// within the content on the next layout.
+func (n *Node) ScrollTo(x, y float64) {
+	if x != n.scrollX || y != n.scrollY {
+		n.scrollX, n.scrollY = x, y
+		n.markDirty()
+	}
+}
+
+// padding returns the padding box of the element n, inside of its border,
+// which its content is clipped to.
+func (n *Node) padding() (x, y, w, h float64) {
+	b := n.Style.borderEdges()
+	return n.Model.RelativeX + b.Left, n.Model.RelativeY + b.Top,
+		n.Model.Width - b.Left - b.Right, n.Model.Height - b.Top - b.Bottom
+}
+
+// maxScroll returns the largest scroll offset of the element n.
+func (n *Node) maxScroll() (x, y float64) {
+	_, _, w, h := n.padding()
+	x = math.Max(0, n.scrollW-w)
+	if n.Style.Height != 0 {
+		y = math.Max(0, n.scrollH-h)
+	}
+	return
+}
+
+// clampScroll measures the content of the scrolling element n and keeps
+// its scroll offset within it. It reports whether the offset changed.
+func (n *Node) clampScroll() bool {
+	px, py, _, _ := n.padding()
+	p := n.Style.Padding
+	n.scrollW, n.scrollH = 0, 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == CharDataNode:
+			n.scrollW = math.Max(n.scrollW, p.Left+c.text.width+p.Right)
+			n.scrollH = math.Max(n.scrollH, p.Top+c.text.height+p.Bottom)
+		case c.Type == ElementNode && !c.positioned() && c.displayed():
+			n.scrollW = math.Max(n.scrollW, c.Model.RelativeX+c.Model.Width+c.Style.Margin.Right+n.scrollX-px+p.Right)
+			n.scrollH = math.Max(n.scrollH, c.Model.RelativeY+c.Model.Height+c.Style.Margin.Bottom+n.scrollY-py+p.Bottom)
+		}
+	}
+	mx, my := n.maxScroll()
+	x, y := math.Max(0, math.Min(n.scrollX, mx)), math.Max(0, math.Min(n.scrollY, my))
+	if x == n.scrollX && y == n.scrollY {
+		return false
+	}
+	n.scrollX, n.scrollY = x, y
+	n.markRepaint()
+	return true
+}
+
+// scrollBy scrolls the element n by dx, dy within its content and reports
+// whether it moved.
+func (n *Node) scrollBy(dx, dy float64) bool {
+	mx, my := n.maxScroll()
+	x := math.Max(0, math.Min(n.scrollX+dx, mx))
+	y := math.Max(0, math.Min(n.scrollY+dy, my))
+	if x == n.scrollX && y == n.scrollY {
+		return false
+	}
+	n.ScrollTo(x, y)
+	return true
+}
+
+// visible returns the region the node is not clipped out of by its
+// scrolling ancestors, and whether it is clipped at all.
+func (n *Node) visible() (r image.Rectangle, clipped bool) {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type != ElementNode || !p.Style.scrolls() {
+			continue
+		}
+		x, y, w, h := p.padding()
+		box := p.transformRect(image.Rect(int(math.Floor(x)), int(math.Floor(y)), int(math.Ceil(x+w)), int(math.Ceil(y+h))))
+		if clipped {
+			r = r.Intersect(box)
+		} else {
+			r, clipped = box, true
+		}
+	}
+	return
+}
+
+// A scrollbar is the track of a scrollbar, in logical units, with the
+// position and length of its thumb along the track.
+type scrollbar struct {
+	vertical      bool
+	x, y, w, h    float64
+	thumb, length float64
+}
+
+// scrollbars returns the scrollbars shown by the element n: always with
+// overflow scroll, and when the content overflows with overflow auto.
+func (n *Node) scrollbars() (bars []scrollbar) {
+	st := n.Style
+	if st.ScrollbarWidth <= 0 || (st.Overflow != "scroll" && st.Overflow != "auto") {
+		return nil
+	}
+	x, y, w, h := n.padding()
+	mx, my := n.maxScroll()
+	vertical := st.Overflow == "scroll" || my > 0
+	horizontal := st.Overflow == "scroll" || mx > 0
+	sw := st.ScrollbarWidth
+	bar := func(vertical bool, size, content, offset, max float64) scrollbar {
+		b := scrollbar{vertical: vertical, x: x, y: y + h - sw, w: size, h: sw}
+		if vertical {
+			b = scrollbar{vertical: vertical, x: x + w - sw, y: y, w: sw, h: size}
+		}
+		b.length = size
+		if content > 0 {
+			b.length = math.Min(size, math.Max(minThumb, size*(content-max)/content))
+		}
+		if max > 0 {
+			b.thumb = (size - b.length) * offset / max
+		}
+		return b
+	}
+	if vertical {
+		size := h
+		if horizontal {
+			size -= sw
+		}
+		bars = append(bars, bar(true, size, n.scrollH, n.scrollY, my))
+	}
+	if horizontal {
+		size := w
+		if vertical {
+			size -= sw
+		}
+		bars = append(bars, bar(false, size, n.scrollW, n.scrollX, mx))
+	}
+	return
+}
+
+// thumbRect returns the thumb of the scrollbar in logical units.
+func (b scrollbar) thumbRect() (x, y, w, h float64) {
+	if b.vertical {
+		return b.x, b.y + b.thumb, b.w, b.length
+	}
+	return b.x + b.thumb, b.y, b.length, b.h
+}
+
+// size returns the length of the track.
+func (b scrollbar) size() float64 {
+	if b.vertical {
+		return b.h
+	}
+	return b.w
+}
+
+// at returns the position of the point x, y along the track.
+func (b scrollbar) at(x, y float64) float64 {
+	if b.vertical {
+		return y - b.y
+	}
+	return x - b.x
+}
+
+// contains reports whether the point x, y is on the track.
+func (b scrollbar) contains(x, y float64) bool {
+	return x >= b.x && x < b.x+b.w && y >= b.y && y < b.y+b.h
+}
+
+// paintScrollbars draws the tracks and thumbs of the scrollbars of n.
+func (render *Renderer) paintScrollbars(n *Node) {
+	op := n.opacity()
+	st := n.Style
+	for _, b := range n.scrollbars() {
+		render.canvas.DrawRectangle(b.x, b.y, b.w, b.h)
+		render.setColor(st.ScrollbarTrack, op)
+		render.canvas.Fill()
+		x, y, w, h := b.thumbRect()
+		render.rectangle(x+1, y+1, w-2, h-2, math.Min(w, h)/2)
+		render.setColor(st.ScrollbarColor, op)
+		render.canvas.Fill()
+	}
+}
+
+// scrollDrag is a scrollbar thumb dragged with the pointer.
+type scrollDrag struct {
+	node     *Node
+	vertical bool
+	grab     float64 // position of the pointer along the thumb
+}
+
+// scrollbarAt returns the element whose scrollbar is at the point x, y,
+// with the scrollbar, preferring the innermost element.
+func (n *Node) scrollbarAt(x, y float64) (*Node, scrollbar, bool) {
+	nodes := n.GetNodes()
+	for i := len(nodes) - 1; i >= 0; i-- {
+		c := nodes[i]
+		if c.Type != ElementNode || !c.Style.scrolls() || c.hidden() {
+			continue
+		}
+		if r, clipped := c.visible(); clipped && !image.Pt(int(x), int(y)).In(r) {
+			continue
+		}
+		lx, ly := c.local(x, y)
+		for _, b := range c.scrollbars() {
+			if b.contains(lx, ly) {
+				return c, b, true
+			}
+		}
+	}
+	return nil, scrollbar{}, false
+}
+
+// pressScrollbar starts dragging the thumb of the scrollbar at the point
+// x, y, or scrolls by a page towards the point when it is off the thumb.
+// It reports whether there was a scrollbar.
+func (w *Window) pressScrollbar(x, y float64) bool {
+	var n *Node
+	var b scrollbar
+	ok := false
+	trees := w.trees()
+	// overlays are on top
+	for i := len(trees) - 1; i >= 0 && !ok; i-- {
+		n, b, ok = trees[i].scrollbarAt(x, y)
+	}
+	if !ok {
+		return false
+	}
+	pos := b.at(n.local(x, y))
+	if pos >= b.thumb && pos < b.thumb+b.length {
+		w.drag = &scrollDrag{node: n, vertical: b.vertical, grab: pos - b.thumb}
+		return true
+	}
+	_, _, pw, ph := n.padding()
+	page := pw
+	if b.vertical {
+		page = ph
+	}
+	if pos < b.thumb {
+		page = -page
+	}
+	if b.vertical {
+		n.scrollBy(0, page)
+	} else {
+		n.scrollBy(page, 0)
+	}
+	return true
+}
+
+// dragScrollbar moves the dragged thumb to the pointer at x, y.
+func (w *Window) dragScrollbar(x, y float64) {
+	n := w.drag.node
+	x, y = n.local(x, y)
+	for _, b := range n.scrollbars() {
+		if b.vertical != w.drag.vertical || b.length >= b.size() {
+			continue
+		}
+		mx, my := n.maxScroll()
+		k := (b.at(x, y) - w.drag.grab) / (b.size() - b.length)
+		if b.vertical {
+			n.ScrollTo(n.scrollX, math.Max(0, math.Min(my, k*my)))
+		} else {
+			n.ScrollTo(math.Max(0, math.Min(mx, k*mx)), n.scrollY)
+		}
+	}
+}
+
+// scroll scrolls the innermost element under the pointer that can scroll
+// further by dx, dy notches of the wheel, positive up and left.
+func (w *Window) scroll(dx, dy float64) {
+	n := w.nodeAt(w.mouseX, w.mouseY)
+	if n == nil {
+		n = w.node
+	}
+	for ; n != nil; n = n.Parent {
+		if n.Style.scrolls() && n.Style.Overflow != "hidden" && n.scrollBy(-dx*scrollStep, -dy*scrollStep) {
+			return
+		}
+	}
+}
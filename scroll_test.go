@@ -0,0 +1,83 @@
+package geui
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+func element(tag, style string) *Node {
+	n := &Node{Type: ElementNode, Data: tag, Style: newElementStyle(tag), Model: new(Model)}
+	n.SetStyle(style)
+	return n
+}
+
+// newScrollWindow shows a window with a box 100 high, 50 from the top,
+// scrolling five items 40 high, which are stacked 10 apart.
+func newScrollWindow(t *testing.T) (*Window, *Node) {
+	items := strings.Repeat(`
+		<item style="height: 40px; background-color: #ff0000"></item>`, 5)
+	w := newXMLWindow(t, `<window style="width: 200px; height: 300px; background-color: #ffffff; hover-color: #ffffff">
+	<box id="box" style="overflow: auto; height: 100px; margin: 50px 0 0 0; background-color: #ffffff; hover-color: #ffffff; scrollbar-color: #0000ff #00ff00">`+items+`
+	</box>
+</window>`)
+	return w, w.node.GetNodeByID("box")
+}
+
+func TestScrollWheel(t *testing.T) {
+	w, box := newScrollWindow(t)
+	first := box.FirstChild
+	if _, my := box.maxScroll(); my != 160 {
+		t.Fatalf("max scroll = %v, want 260-100", my)
+	}
+	w.dispatch(MouseMove{X: 50, Y: 100})
+	w.dispatch(MouseScroll{Y: -1})
+	if _, y := box.ScrollOffset(); y != scrollStep {
+		t.Fatalf("scroll offset = %v, want %v", y, scrollStep)
+	}
+	w.repaint()
+	if got, want := first.Bounds(), image.Rect(10, 60-scrollStep, 190, 100-scrollStep); got != want {
+		t.Errorf("first item bounds = %v, want %v", got, want)
+	}
+	if first.Focused(50, 30) {
+		t.Errorf("scrolled out part of the first item is hit")
+	}
+	if n := w.node.GetActiveNode(50, 80); n != first.NextSibling {
+		t.Errorf("active node at 50,80 is %v, want the second item", n)
+	}
+
+	w.dispatch(MouseScroll{Y: -10})
+	w.repaint()
+	if _, y := box.ScrollOffset(); y != 160 {
+		t.Errorf("scroll offset = %v, want it clamped to 160", y)
+	}
+	img := w.renderer.Image()
+	if c := img.RGBAAt(50, 160); c != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("pixel below the box = %v, want white, items are clipped", c)
+	}
+	if c := img.RGBAAt(196, 145); c != (color.RGBA{B: 0xff, A: 0xff}) {
+		t.Errorf("pixel of the thumb = %v, want blue", c)
+	}
+}
+
+func TestScrollbarDrag(t *testing.T) {
+	w, box := newScrollWindow(t)
+	bars := box.scrollbars()
+	if len(bars) != 1 || !bars[0].vertical {
+		t.Fatalf("scrollbars = %v, want one vertical", bars)
+	}
+	// the thumb is 100*100/260 long and moves along the remaining track
+	x, y, _, h := bars[0].thumbRect()
+	w.dispatch(MouseMove{X: x + 2, Y: y + 5})
+	w.dispatch(MouseDown{MouseButton: MouseLeft})
+	w.dispatch(MouseMove{X: x + 2, Y: y + 5 + (100-h)/2})
+	w.dispatch(MouseUp{MouseButton: MouseLeft})
+	if _, y := box.ScrollOffset(); math.Abs(y-80) > 1e-9 {
+		t.Errorf("scroll offset = %v, want half of 160", y)
+	}
+	if w.active != nil {
+		t.Errorf("releasing the thumb focused %v", w.active)
+	}
+}
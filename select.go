@@ -0,0 +1,339 @@
+package geui
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	maxPopup       = 200         // tallest popup of options
+	typeAheadPause = time.Second // longest pause between the keys of a search
+)
+
+// selectState is the state of a <select> or <combobox> element: its
+// <option> elements, which are kept out of the tree, and its popup.
+type selectState struct {
+	options  []*Node
+	selected int     // chosen option, or -1
+	label    *Node   // text of the chosen option of a <select>
+	popup    *Node   // open popup, or nil
+	shown    []*Node // options in the popup
+	active   int     // highlighted option in the popup, from shown
+	search   string  // typed ahead to find an option
+	typed    time.Time
+}
+
+// choice returns the state of the <select> or <combobox> element n. The
+// options are taken out of the tree to be shown in the popup; a <select>
+// shows the text of the chosen one instead, the first unless an option
+// has the selected attribute.
+func (n *Node) choice() *selectState {
+	if n.selectState != nil {
+		return n.selectState
+	}
+	s := &selectState{selected: -1}
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == ElementNode && c.Data == "option" {
+			RemoveChild(n, c)
+			if _, ok := c.Attrs["selected"]; ok {
+				s.selected = len(s.options)
+			}
+			s.options = append(s.options, c)
+		}
+		c = next
+	}
+	n.selectState = s
+	if n.Data == "select" {
+		s.label = &Node{Type: CharDataNode, Model: new(Model)}
+		AddChild(n, s.label)
+		if s.selected < 0 {
+			s.selected = 0
+		}
+	}
+	if s.selected >= 0 {
+		n.choose(s.selected)
+	}
+	return s
+}
+
+// optionLabel returns the text of the option o.
+func optionLabel(o *Node) string {
+	return o.cellText()
+}
+
+// optionValue returns the value attribute of the option o, or its text.
+func optionValue(o *Node) string {
+	if len(o.Value) > 0 {
+		return string(o.Value)
+	}
+	return optionLabel(o)
+}
+
+// GetValue returns the value of the node: the value of the chosen option
+// of a <select>, the position of the divider of a <splitter>, else its
+// value, like the text of an input or a <combobox>.
+func (n *Node) GetValue() string {
+	if n.Data == "splitter" {
+		return strconv.FormatFloat(n.Position(), 'f', -1, 64)
+	}
+	if n.Data == "select" {
+		s := n.choice()
+		if s.selected < 0 || s.selected >= len(s.options) {
+			return ""
+		}
+		return optionValue(s.options[s.selected])
+	}
+	return string(n.Value)
+}
+
+// choose makes the option i of the <select> or <combobox> element n the
+// chosen one, which a <combobox> takes the text of. It reports whether
+// the value changed.
+func (n *Node) choose(i int) bool {
+	s := n.choice()
+	if i < 0 || i >= len(s.options) {
+		return false
+	}
+	old := n.GetValue()
+	s.selected = i
+	if s.label != nil {
+		s.label.Data = optionLabel(s.options[i])
+		s.label.markDirty()
+	} else {
+		n.SetValue(optionLabel(s.options[i]))
+	}
+	n.markDirty()
+	return n.GetValue() != old
+}
+
+// highlight highlights the option i of the popup and scrolls it into view.
+func (s *selectState) highlight(i int) {
+	if len(s.shown) == 0 {
+		return
+	}
+	i = int(math.Max(0, math.Min(float64(i), float64(len(s.shown)-1))))
+	top := 0.0
+	for j, o := range s.shown {
+		if sel := j == i; sel != o.selected {
+			o.selected = sel
+			o.markRepaint()
+		}
+		if j < i {
+			top += o.Style.Height
+		}
+	}
+	s.active = i
+	_, _, _, h := s.popup.padding()
+	switch bottom := top + s.shown[i].Style.Height; {
+	case top < s.popup.scrollY:
+		s.popup.ScrollTo(0, top)
+	case bottom > s.popup.scrollY+h:
+		s.popup.ScrollTo(0, bottom-h)
+	}
+}
+
+// openPopup shows the options of the <select> or <combobox> element n in
+// a popup overlay below it, highlighting the chosen one. A <combobox>
+// shows the options containing its text only.
+func (w *Window) openPopup(n *Node) {
+	w.closePopup()
+	s := n.choice()
+	filter := ""
+	if n.Data == "combobox" {
+		filter = strings.ToLower(string(n.Value))
+	}
+	popup := &Node{Type: ElementNode, Data: "popup", Style: newElementStyle("popup"), Model: new(Model)}
+	h, active := 0.0, 0
+	for i, o := range s.options {
+		if !strings.Contains(strings.ToLower(optionLabel(o)), filter) {
+			continue
+		}
+		if i == s.selected {
+			active = len(s.shown)
+		}
+		s.shown = append(s.shown, o)
+		AddChild(popup, o)
+		h += o.Style.Height
+	}
+	if len(s.shown) == 0 {
+		return
+	}
+	b := popup.Style.borderEdges()
+	popup.Style.Height = math.Min(maxPopup, h+b.Top+b.Bottom)
+	s.popup = popup
+	w.popup = n
+	w.openOverlay(popup, n)
+	w.layoutOverlays()
+	s.highlight(active)
+}
+
+// closePopup closes the open popup of options, if any.
+func (w *Window) closePopup() {
+	n := w.popup
+	if n == nil {
+		return
+	}
+	s := n.choice()
+	w.closeOverlay(s.popup)
+	for _, o := range s.shown {
+		RemoveChild(s.popup, o)
+		o.selected, o.hovered = false, false
+	}
+	s.popup, s.shown, w.popup = nil, nil, nil
+	n.markDirty()
+}
+
+// pick chooses the option i of the popup of n and closes it.
+func (w *Window) pick(n *Node, i int) {
+	s := n.choice()
+	if i >= 0 && i < len(s.shown) {
+		for j, o := range s.options {
+			if o == s.shown[i] && n.choose(j) {
+				w.changed(n)
+			}
+		}
+	}
+	w.closePopup()
+}
+
+// popupOption returns the index of the option of the open popup the node
+// n is in, or -1.
+func (w *Window) popupOption(n *Node) int {
+	if w.popup == nil {
+		return -1
+	}
+	for ; n != nil; n = n.Parent {
+		for i, o := range w.popup.choice().shown {
+			if o == n {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// click opens the popup of the <select> or <combobox> element n.
+func (s *selectState) click(w *Window, n, hit *Node) *Node {
+	w.openPopup(n)
+	return n
+}
+
+// key handles the key k for the focused <select> or <combobox> element n
+// and reports whether it was used. With the popup closed, the arrow keys
+// choose the next or previous option of a <select> and open the popup of
+// a <combobox>.
+func (s *selectState) key(w *Window, n *Node, k Key) bool {
+	if w.active != n {
+		return false
+	}
+	if s.popup == nil {
+		switch {
+		case n.Data == "select" && (k == KeyUp || k == KeyDown):
+			i := s.selected + 1
+			if k == KeyUp {
+				i = s.selected - 1
+			}
+			if n.choose(i) {
+				w.changed(n)
+			}
+		case k == KeyDown, n.Data == "select" && (k == KeyEnter || k == KeySpace):
+			w.openPopup(n)
+		case n.Data == "combobox" && k == KeyEnter:
+			w.changed(n)
+		default:
+			return false
+		}
+		return true
+	}
+	switch {
+	case k == KeyUp:
+		s.highlight(s.active - 1)
+	case k == KeyDown:
+		s.highlight(s.active + 1)
+	case k == KeyPageUp, k == KeyPageDown:
+		_, _, _, h := s.popup.padding()
+		page := int(math.Max(1, math.Floor(h/s.shown[s.active].Style.Height)))
+		if k == KeyPageUp {
+			page = -page
+		}
+		s.highlight(s.active + page)
+	case n.Data == "select" && k == KeyHome:
+		s.highlight(0)
+	case n.Data == "select" && k == KeyEnd:
+		s.highlight(len(s.shown) - 1)
+	case k == KeyEnter:
+		w.pick(n, s.active)
+	case k == KeyEscape:
+		w.closePopup()
+	default:
+		return false
+	}
+	return true
+}
+
+// typeRune searches the options of a <select> element n for the text
+// typed, see typeAhead, and opens the popup of a <combobox> on it.
+func (s *selectState) typeRune(w *Window, n *Node, r rune) bool {
+	if n.Data == "select" {
+		w.typeAhead(n, r)
+		return true
+	}
+	w.insert([]rune{r})
+	w.openPopup(n)
+	return true
+}
+
+// typeAhead finds the next option of the <select> element n starting with
+// the keys typed in quick succession, ending with r, and highlights it in
+// the popup, or chooses it when the popup is closed.
+func (w *Window) typeAhead(n *Node, r rune) {
+	s := n.choice()
+	now := time.Now()
+	if now.Sub(s.typed) > typeAheadPause {
+		s.search = ""
+	}
+	s.typed = now
+	if s.search == "" && unicode.IsSpace(r) {
+		return
+	}
+	s.search += string(unicode.ToLower(r))
+	options, current := s.options, s.selected
+	if s.popup != nil {
+		options, current = s.shown, s.active
+	}
+	// a new search moves on from the current option
+	start := current
+	if len([]rune(s.search)) == 1 {
+		start++
+	}
+	start = int(math.Max(0, float64(start)))
+	for j := range options {
+		i := (start + j) % len(options)
+		if !strings.HasPrefix(strings.ToLower(optionLabel(options[i])), s.search) {
+			continue
+		}
+		if s.popup != nil {
+			s.highlight(i)
+		} else if n.choose(i) {
+			w.changed(n)
+		}
+		return
+	}
+}
+
+// paintDropArrow draws the arrow of a <select> or <combobox> element in
+// its right padding.
+func (render *Renderer) paintDropArrow(n *Node) {
+	x, y, w, h := n.content()
+	cx, cy := x+w+n.Style.Padding.Right/2, y+h/2
+	render.canvas.MoveTo(cx-4, cy-2)
+	render.canvas.LineTo(cx, cy+2)
+	render.canvas.LineTo(cx+4, cy-2)
+	render.setColor(n.Style.FontColor, n.opacity())
+	render.canvas.SetLineWidth(1.5 * render.scale)
+	render.canvas.Stroke()
+}
@@ -0,0 +1,128 @@
+package geui
+
+import (
+	"image/color"
+	"testing"
+)
+
+func newSelectWindow(t *testing.T) (*Window, *Node, *Node) {
+	w := newXMLWindow(t, `<window style="width: 300px; height: 300px; background-color: #ffffff; hover-color: #ffffff">
+	<box style="overflow: hidden; height: 60px; margin: 0; background-color: #ffffff; hover-color: #ffffff">
+		<select id="fruit" style="height: 30px">
+			<option value="a">Apple</option>
+			<option value="b" selected="">Banana</option>
+			<option value="c">Cherry</option>
+			<option value="c2">Coconut</option>
+		</select>
+	</box>
+	<combobox id="city" style="height: 30px">
+		<option>Berlin</option>
+		<option>Bern</option>
+		<option>Paris</option>
+	</combobox>
+</window>`)
+	return w, w.node.GetNodeByID("fruit"), w.node.GetNodeByID("city")
+}
+
+func click(w *Window, x, y float64) {
+	w.dispatch(MouseMove{X: x, Y: y})
+	w.dispatch(MouseDown{MouseButton: MouseLeft})
+	w.dispatch(MouseUp{MouseButton: MouseLeft})
+	w.repaint()
+}
+
+func TestSelect(t *testing.T) {
+	w, fruit, _ := newSelectWindow(t)
+	var changes []string
+	w.OnChange("fruit", func(v string) { changes = append(changes, v) })
+	if v := fruit.GetValue(); v != "b" || fruit.FirstChild.Data != "Banana" {
+		t.Fatalf("value = %q showing %q, want the selected option", v, fruit.FirstChild.Data)
+	}
+
+	click(w, 50, 25)
+	s := fruit.choice()
+	if s.popup == nil || len(w.renderer.overlays) != 1 {
+		t.Fatal("clicking the select did not open the popup")
+	}
+	// the popup is below the select, out of the box clipping it
+	p := s.popup.Model
+	if p.RelativeY != 40 || p.Width != fruit.Model.Width || p.Height != 4*28+2 {
+		t.Errorf("popup = %+v", *p)
+	}
+	img := w.renderer.Image()
+	if c := img.RGBAAt(280, 40+28+14); c != (color.RGBA{0x5b, 0x9d, 0xd9, 0xff}) {
+		t.Errorf("pixel of the highlighted option = %v, want the selected colour", c)
+	}
+	if n := w.nodeAt(50, 120); n != s.options[2] {
+		t.Errorf("node at the third option = %v", n)
+	}
+
+	click(w, 50, 120)
+	if v := fruit.GetValue(); v != "c" || s.popup != nil || w.active != fruit {
+		t.Errorf("value = %q after clicking the third option, popup %v", v, s.popup)
+	}
+	if len(changes) != 1 || changes[0] != "c" {
+		t.Errorf("changes = %v", changes)
+	}
+	if c := w.renderer.Image().RGBAAt(20, 120); c != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("pixel where the popup was = %v, want white", c)
+	}
+
+	w.dispatch(KbDown{Key: KeyUp})
+	if v := fruit.GetValue(); v != "b" {
+		t.Errorf("value = %q after up", v)
+	}
+	w.dispatch(KbType{rune: 'c'})
+	w.dispatch(KbType{rune: 'o'})
+	if v := fruit.GetValue(); v != "c2" {
+		t.Errorf("value = %q after typing co", v)
+	}
+	w.dispatch(KbDown{Key: KeyEnter})
+	w.dispatch(KbDown{Key: KeyHome})
+	w.dispatch(KbDown{Key: KeyDown})
+	w.dispatch(KbDown{Key: KeyEnter})
+	if v := fruit.GetValue(); v != "b" || s.popup != nil {
+		t.Errorf("value = %q after choosing the second option with the keyboard", v)
+	}
+	// typing c chose Cherry first
+	if len(changes) != 5 {
+		t.Errorf("changes = %v", changes)
+	}
+	fruit.SetValue("a")
+	if fruit.Selected() != 0 || len(changes) != 5 {
+		t.Errorf("SetValue chose %d", fruit.Selected())
+	}
+
+	click(w, 50, 25)
+	click(w, 250, 250)
+	if s.popup != nil {
+		t.Error("clicking off the popup did not close it")
+	}
+}
+
+func TestCombobox(t *testing.T) {
+	w, _, city := newSelectWindow(t)
+	var changes []string
+	w.OnChange("city", func(v string) { changes = append(changes, v) })
+	click(w, 50, 85)
+	if w.active != city {
+		t.Fatal("combobox is not focused")
+	}
+	w.dispatch(KbType{rune: 'B'})
+	w.dispatch(KbType{rune: 'e'})
+	s := city.choice()
+	if s.popup == nil || len(s.shown) != 2 {
+		t.Fatalf("popup shows %d options for Be", len(s.shown))
+	}
+	w.dispatch(KbDown{Key: KeyDown})
+	w.dispatch(KbDown{Key: KeyEnter})
+	if v := city.GetValue(); v != "Bern" || s.popup != nil {
+		t.Errorf("value = %q after choosing the second match", v)
+	}
+	w.dispatch(KbType{rune: 'e'})
+	w.dispatch(KbDown{Key: KeyEscape})
+	w.dispatch(KbDown{Key: KeyEnter})
+	if v := city.GetValue(); v != "Berne" || len(changes) != 2 || changes[1] != "Berne" {
+		t.Errorf("value = %q, changes %v", v, changes)
+	}
+}
@@ -0,0 +1,415 @@
+package geui
+
+import (
+	"math"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fogleman/gg"
+	"github.com/go-text/typesetting/di"
+	gtfont "github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/language"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// A textFace is a font at a size in which text is shaped. Faces of fonts
+// that could not be loaded have no shaper font and lay out the runes of
+// the font.Face one by one.
+type textFace struct {
+	font.Face
+	shaped *gtfont.Face
+	size   float64
+	rtl    bool // paragraphs are right-to-left
+}
+
+// glyph is a glyph of a shaped line. Glyphs are in visual order, with
+// their origin given from the start of the line.
+type glyph struct {
+	id      gtfont.GID
+	r       rune // rune drawn with the font.Face of faces without a shaper font
+	x, y    float64
+	advance float64
+	cluster int // index of the first rune shaped into the glyph
+	runes   int // number of runes shaped into the glyph
+	rtl     bool
+}
+
+var shaper struct {
+	sync.Mutex
+	shaping.HarfbuzzShaper
+}
+
+// styleFace returns the face text is laid out in with style st, at scale
+// device pixels per logical unit.
+func styleFace(st *CSStyle, scale float64) textFace {
+	f := fontFace(st.FontFamily, st.FontSize*scale)
+	f.rtl = st.Direction == "rtl"
+	return f
+}
+
+// metrics returns the ascent, descent and line height of the face.
+func (f textFace) metrics() (ascent, descent, height float64) {
+	if f.shaped != nil {
+		if e, ok := f.shaped.FontHExtents(); ok {
+			k := f.size / float64(f.shaped.Upem())
+			ascent, descent = float64(e.Ascender)*k, -float64(e.Descender)*k
+			return ascent, descent, ascent + descent + float64(e.LineGap)*k
+		}
+	}
+	m := f.Metrics()
+	return fixedFloat(m.Ascent), fixedFloat(m.Descent), fixedFloat(m.Height)
+}
+
+// measure returns the width of the line s.
+func (f textFace) measure(s string) float64 {
+	_, w := f.shape(s)
+	return w
+}
+
+// advances returns the advance of each rune of the line s, in logical
+// order. The glyphs of a cluster count for its first rune.
+func (f textFace) advances(s string) []float64 {
+	glyphs, _ := f.shape(s)
+	adv := make([]float64, utf8.RuneCountInString(s))
+	for _, g := range glyphs {
+		if g.cluster < len(adv) {
+			adv[g.cluster] += g.advance
+		}
+	}
+	return adv
+}
+
+// shape lays out the line s. The runs of the Unicode bidirectional
+// algorithm are put in visual order and shaped in their direction.
+func (f textFace) shape(s string) (glyphs []glyph, width float64) {
+	text := []rune(s)
+	runs := bidiRuns(s, f.rtl)
+	for _, run := range runs {
+		items := scriptRuns(text, run)
+		if run.rtl {
+			for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+				items[i], items[j] = items[j], items[i]
+			}
+		}
+		for _, item := range items {
+			glyphs, width = f.shapeRun(glyphs, width, text, item)
+		}
+	}
+	return
+}
+
+// shapeRun appends the glyphs of the run of text, starting at pen.
+func (f textFace) shapeRun(glyphs []glyph, pen float64, text []rune, run textRun) ([]glyph, float64) {
+	if f.shaped == nil {
+		for i := run.start; i < run.end; i++ {
+			k := i
+			if run.rtl {
+				k = run.start + run.end - 1 - i
+			}
+			r := text[k]
+			if run.rtl {
+				r = mirror(r)
+			}
+			a, _ := f.GlyphAdvance(r)
+			glyphs = append(glyphs, glyph{r: r, x: pen, advance: fixedFloat(a), cluster: k, runes: 1, rtl: run.rtl})
+			pen += fixedFloat(a)
+		}
+		return glyphs, pen
+	}
+	dir := di.DirectionLTR
+	if run.rtl {
+		dir = di.DirectionRTL
+	}
+	shaper.Lock()
+	out := shaper.Shape(shaping.Input{
+		Text:      text,
+		RunStart:  run.start,
+		RunEnd:    run.end,
+		Direction: dir,
+		Face:      f.shaped,
+		Size:      fixed.Int26_6(f.size * 64),
+		Script:    run.script,
+		Language:  language.DefaultLanguage(),
+	})
+	shaper.Unlock()
+	for _, g := range out.Glyphs {
+		glyphs = append(glyphs, glyph{
+			id:      g.GlyphID,
+			x:       pen + fixedFloat(g.XOffset),
+			y:       fixedFloat(g.YOffset),
+			advance: fixedFloat(g.XAdvance),
+			cluster: g.ClusterIndex,
+			runes:   g.RuneCount,
+			rtl:     run.rtl,
+		})
+		pen += fixedFloat(g.XAdvance)
+	}
+	return glyphs, pen
+}
+
+// textRun is a range of runes of a line shaped in one direction and script.
+type textRun struct {
+	start, end int
+	rtl        bool
+	level      int // embedding level of the bidirectional algorithm
+	script     language.Script
+}
+
+// bidiRuns returns the directional runs of the line s in visual order.
+// Lines are right-to-left when rtl is set or when their first strong
+// character is right-to-left.
+func bidiRuns(s string, rtl bool) (runs []textRun) {
+	var p bidi.Paragraph
+	var opts []bidi.Option
+	if rtl {
+		opts = append(opts, bidi.DefaultDirection(bidi.RightToLeft))
+	}
+	var o bidi.Ordering
+	_, err := p.SetString(s, opts...)
+	if err == nil {
+		o, err = p.Order()
+	}
+	if err != nil || o.NumRuns() == 0 {
+		if s == "" {
+			return nil
+		}
+		return []textRun{{end: len([]rune(s)), rtl: rtl}}
+	}
+
+	// The ordering only tells the direction of the runs, so their levels
+	// are restored: left-to-right runs are embedded in right-to-left
+	// paragraphs, like numbers following right-to-left text.
+	text := []rune(s)
+	para := 0
+	if rtl || firstStrongRTL(s) {
+		para = 1
+	}
+	for i := 0; i < o.NumRuns(); i++ {
+		run := o.Run(i)
+		start, end := run.Pos()
+		r := textRun{start: start, end: end + 1}
+		switch {
+		case run.Direction() == bidi.RightToLeft:
+			r.level = 1
+		case para == 1 || isNumber(text[start:end+1]) && prevStrongRTL(text, start):
+			r.level = 2
+		}
+		r.rtl = r.level%2 == 1
+		runs = append(runs, r)
+	}
+
+	// reverse the sequences of runs at each level or higher
+	for level := 2; level > 0; level-- {
+		for i := 0; i < len(runs); {
+			if runs[i].level < level {
+				i++
+				continue
+			}
+			j := i
+			for j < len(runs) && runs[j].level >= level {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				runs[a], runs[b] = runs[b], runs[a]
+			}
+			i = j
+		}
+	}
+	return
+}
+
+// isNumber reports whether text has no strong left-to-right character.
+func isNumber(text []rune) bool {
+	for _, r := range text {
+		if p, _ := bidi.LookupRune(r); p.Class() == bidi.L {
+			return false
+		}
+	}
+	return true
+}
+
+// prevStrongRTL reports whether the last character with a strong
+// direction before text[i] is right-to-left.
+func prevStrongRTL(text []rune, i int) bool {
+	for i--; i >= 0; i-- {
+		p, _ := bidi.LookupRune(text[i])
+		switch p.Class() {
+		case bidi.R, bidi.AL:
+			return true
+		case bidi.L:
+			return false
+		}
+	}
+	return false
+}
+
+// firstStrongRTL reports whether the first character of s with a strong
+// direction is right-to-left.
+func firstStrongRTL(s string) bool {
+	for _, r := range s {
+		p, _ := bidi.LookupRune(r)
+		switch p.Class() {
+		case bidi.R, bidi.AL:
+			return true
+		case bidi.L:
+			return false
+		}
+	}
+	return false
+}
+
+// scriptRuns splits run into runs of a single script, in logical order.
+// Common and inherited characters belong to the script around them.
+func scriptRuns(text []rune, run textRun) (runs []textRun) {
+	cur := run
+	cur.end = run.start
+	for i := run.start; i < run.end; i++ {
+		s := language.LookupScript(text[i])
+		if s == language.Common || s == language.Inherited {
+			cur.end = i + 1
+			continue
+		}
+		if cur.script != 0 && s != cur.script && cur.end > cur.start {
+			runs = append(runs, cur)
+			cur.start = i
+		}
+		cur.script = s
+		cur.end = i + 1
+	}
+	if cur.end > cur.start {
+		runs = append(runs, cur)
+	}
+	return
+}
+
+// mirror returns the mirrored form of brackets, which are drawn mirrored in
+// right-to-left runs.
+func mirror(r rune) rune {
+	switch r {
+	case '(':
+		return ')'
+	case ')':
+		return '('
+	case '[':
+		return ']'
+	case ']':
+		return '['
+	case '{':
+		return '}'
+	case '}':
+		return '{'
+	}
+	return r
+}
+
+// draw fills the glyphs of the line s on dc, with the start of the
+// baseline at x, y in device pixels.
+func (f textFace) draw(dc *gg.Context, s string, x, y float64) {
+	glyphs, _ := f.shape(s)
+	if f.shaped == nil {
+		dc.SetFontFace(f.Face)
+		for _, g := range glyphs {
+			if !unicode.IsSpace(g.r) {
+				dc.DrawString(string(g.r), x+g.x, y)
+			}
+		}
+		return
+	}
+	k := f.size / float64(f.shaped.Upem())
+	for _, g := range glyphs {
+		outline, ok := f.shaped.GlyphData(g.id).(gtfont.GlyphOutline)
+		if !ok {
+			continue
+		}
+		ox, oy := x+g.x, y-g.y
+		for _, seg := range outline.Segments {
+			a := seg.Args
+			switch seg.Op {
+			case ot.SegmentOpMoveTo:
+				dc.MoveTo(ox+float64(a[0].X)*k, oy-float64(a[0].Y)*k)
+			case ot.SegmentOpLineTo:
+				dc.LineTo(ox+float64(a[0].X)*k, oy-float64(a[0].Y)*k)
+			case ot.SegmentOpQuadTo:
+				dc.QuadraticTo(
+					ox+float64(a[0].X)*k, oy-float64(a[0].Y)*k,
+					ox+float64(a[1].X)*k, oy-float64(a[1].Y)*k,
+				)
+			case ot.SegmentOpCubeTo:
+				dc.CubicTo(
+					ox+float64(a[0].X)*k, oy-float64(a[0].Y)*k,
+					ox+float64(a[1].X)*k, oy-float64(a[1].Y)*k,
+					ox+float64(a[2].X)*k, oy-float64(a[2].Y)*k,
+				)
+			}
+		}
+		dc.ClosePath()
+	}
+	dc.Fill()
+}
+
+// carets returns the offsets from the start of the line s of the caret
+// before each rune and after the last one. In right-to-left runs the
+// caret before a rune is on its right.
+func (f textFace) carets(s string) []float64 {
+	n := len([]rune(s))
+	xs := make([]float64, n+1)
+	glyphs, width := f.shape(s)
+	if len(glyphs) == 0 {
+		return xs
+	}
+	set := make([]bool, n+1)
+	for _, g := range glyphs {
+		if g.runes == 0 || set[g.cluster] {
+			continue
+		}
+		// ligatures divide their advance among their runes
+		step := g.advance / float64(g.runes)
+		for i := 0; i < g.runes && g.cluster+i < n; i++ {
+			if g.rtl {
+				xs[g.cluster+i] = g.x + g.advance - float64(i)*step
+			} else {
+				xs[g.cluster+i] = g.x + float64(i)*step
+			}
+			set[g.cluster+i] = true
+		}
+	}
+	// after the last rune, at the end of its run
+	last := glyphs[0]
+	for _, g := range glyphs {
+		if g.cluster+g.runes >= last.cluster+last.runes {
+			last = g
+		}
+	}
+	xs[n] = last.x + last.advance
+	if last.rtl {
+		xs[n] = last.x
+	}
+	if f.rtl && n > 0 && !set[0] {
+		xs[0] = width
+	}
+	return xs
+}
+
+// moveCaret returns the caret visually next to caret i of xs, to the
+// right when right is set, or i itself when it is at the edge.
+func moveCaret(xs []float64, i int, right bool) int {
+	next := i
+	for j := range xs {
+		d := xs[j] - xs[i]
+		if !right {
+			d = -d
+		}
+		if d <= 0 || j == i {
+			continue
+		}
+		if next == i || math.Abs(xs[j]-xs[i]) < math.Abs(xs[next]-xs[i]) {
+			next = j
+		}
+	}
+	return next
+}
@@ -0,0 +1,76 @@
+package geui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBidiRuns(t *testing.T) {
+	tests := []struct {
+		text string
+		rtl  bool
+		want []string
+	}{
+		{"Hello", false, []string{"Hello"}},
+		{"Hello עולם 123! مرحبا", false, []string{"Hello ", "! مرحبا", "123", "עולם "}},
+		{"שלום abc 12", false, []string{"abc 12", "שלום "}},
+		{"abc שלום", true, []string{" שלום", "abc"}},
+	}
+	for _, test := range tests {
+		text := []rune(test.text)
+		var got []string
+		for _, run := range bidiRuns(test.text, test.rtl) {
+			got = append(got, string(text[run.start:run.end]))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("bidiRuns(%q, %v) = %q, want %q", test.text, test.rtl, got, test.want)
+		}
+	}
+}
+
+func TestCarets(t *testing.T) {
+	if got, want := face.carets("abc"), []float64{0, 7, 14, 21}; !reflect.DeepEqual(got, want) {
+		t.Errorf("carets of LTR text = %v, want %v", got, want)
+	}
+	// the first rune is on the right
+	xs := face.carets("אבג")
+	if want := []float64{21, 14, 7, 0}; !reflect.DeepEqual(xs, want) {
+		t.Errorf("carets of RTL text = %v, want %v", xs, want)
+	}
+	if got := moveCaret(xs, 0, false); got != 1 {
+		t.Errorf("caret moved left to %d, want 1", got)
+	}
+	if got := moveCaret(xs, 0, true); got != 0 {
+		t.Errorf("caret moved right to %d, want 0", got)
+	}
+}
+
+func TestWindowCaret(t *testing.T) {
+	w, h := newTestWindow(t)
+	input := w.node.FirstChild.NextSibling
+	h.Send(MouseMove{X: 20, Y: 80})
+	h.Send(MouseUp{X: 20, Y: 80})
+	h.PollEvents()
+	input.SetValue("abc")
+	h.Send(KbDown{KeyLeft, 0})
+	h.Send(KbType{'x'})
+	h.PollEvents()
+	if got := string(input.Value); got != "abxc" {
+		t.Fatalf("input value = %q, want %q", got, "abxc")
+	}
+
+	input.SetStyle("direction:rtl")
+	input.SetValue("אבג")
+	h.Send(KbDown{KeyRight, 0})
+	h.Send(KbDown{KeyBackspace, 0})
+	h.Send(KbUp{KeyBackspace, 0})
+	h.PollEvents()
+	if got := string(input.Value); got != "אג" {
+		t.Fatalf("input value = %q, want %q", got, "אג")
+	}
+	h.Send(KbRepeat{KeyBackspace, 0})
+	h.PollEvents()
+	if got := string(input.Value); got != "ג" {
+		t.Fatalf("input value = %q after a repeated backspace, want %q", got, "ג")
+	}
+}
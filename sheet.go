@@ -0,0 +1,282 @@
+package geui
+
+import (
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// A styleSheet is the CSS of a <style> element: the rules giving
+// declarations to the elements their selectors match, and the @keyframes
+// of the animations.
+type styleSheet struct {
+	rules     []styleRule
+	keyframes map[string][]keyframe
+}
+
+// A styleRule gives the declarations of a rule to the elements its
+// selector matches.
+type styleRule struct {
+	selector     selector
+	declarations string
+}
+
+// A selector matches the elements matching its last compound selector
+// that are descendants of elements matching the ones before, like
+// "tabs button.primary".
+type selector []compound
+
+// A compound selector matches elements by tag, id and classes, each
+// optional, like "button#ok.primary".
+type compound struct {
+	tag, id string
+	classes []string
+}
+
+// stylesheet returns the style sheet of the <style> element n, in the
+// file of its src attribute, then in its text.
+func (n *Node) stylesheet() *styleSheet {
+	if n.sheet == nil {
+		var css strings.Builder
+		if src, ok := n.Attrs["src"]; ok {
+			data, err := n.images().read(src)
+			if err != nil {
+				log.Println(err)
+			}
+			css.Write(data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == CharDataNode {
+				css.WriteString(c.Data)
+			}
+		}
+		n.sheet = &styleSheet{
+			rules:     parseRules(css.String()),
+			keyframes: parseKeyframes(css.String()),
+		}
+	}
+	return n.sheet
+}
+
+// parseRules returns the rules of the style sheet css, leaving out the
+// at-rules like @keyframes and the selectors it does not support.
+func parseRules(css string) (rules []styleRule) {
+	css = stripComments(css)
+	for {
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			return rules
+		}
+		prelude := strings.TrimSpace(css[:open])
+		end := blockEnd(css, open)
+		body := css[open+1 : end]
+		if end < len(css) {
+			end++
+		}
+		css = css[end:]
+		if strings.HasPrefix(prelude, "@") {
+			continue
+		}
+		for _, v := range strings.Split(prelude, ",") {
+			if sel, ok := parseSelector(v); ok {
+				rules = append(rules, styleRule{sel, body})
+			}
+		}
+	}
+}
+
+// stripComments returns css without its comments.
+func stripComments(css string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(css, "/*")
+		if i < 0 {
+			b.WriteString(css)
+			return b.String()
+		}
+		b.WriteString(css[:i])
+		j := strings.Index(css[i+2:], "*/")
+		if j < 0 {
+			return b.String()
+		}
+		css = css[i+2+j+2:]
+	}
+}
+
+// blockEnd returns the index of the brace closing the block opened at
+// open in css, or the length of css when it is not closed.
+func blockEnd(css string, open int) int {
+	depth := 0
+	for i := open; i < len(css); i++ {
+		switch css[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(css)
+}
+
+// parseSelector parses a selector like "tabs button.primary". ok is false
+// for the selectors with pseudo-classes, attributes or combinators other
+// than descendants.
+func parseSelector(v string) (sel selector, ok bool) {
+	for _, part := range strings.Fields(v) {
+		c, ok := parseCompound(part)
+		if !ok {
+			return nil, false
+		}
+		sel = append(sel, c)
+	}
+	return sel, len(sel) > 0
+}
+
+// parseCompound parses a compound selector like "button#ok.primary".
+func parseCompound(v string) (c compound, ok bool) {
+	if strings.HasPrefix(v, "*") {
+		v = v[1:]
+	}
+	for v != "" {
+		i := strings.IndexAny(v[1:], ".#") + 1
+		if i == 0 {
+			i = len(v)
+		}
+		part := v[:i]
+		v = v[i:]
+		switch part[0] {
+		case '#':
+			c.id = part[1:]
+			part = c.id
+		case '.':
+			part = part[1:]
+			c.classes = append(c.classes, part)
+		default:
+			c.tag = part
+		}
+		if !isName(part) {
+			return c, false
+		}
+	}
+	return c, true
+}
+
+// isName reports whether v is a tag, id or class name.
+func isName(v string) bool {
+	for _, r := range v {
+		if !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return v != ""
+}
+
+// specificity orders the selectors by their ids, then classes, then tags.
+func (sel selector) specificity() (s int) {
+	for _, c := range sel {
+		if c.id != "" {
+			s += 10000
+		}
+		s += 100 * len(c.classes)
+		if c.tag != "" {
+			s++
+		}
+	}
+	return s
+}
+
+// matches reports whether sel matches the element n.
+func (sel selector) matches(n *Node) bool {
+	i := len(sel) - 1
+	if !sel[i].matches(n) {
+		return false
+	}
+	i--
+	for p := n.Parent; p != nil && i >= 0; p = p.Parent {
+		if sel[i].matches(p) {
+			i--
+		}
+	}
+	return i < 0
+}
+
+// matches reports whether c matches the element n.
+func (c compound) matches(n *Node) bool {
+	if n.Type != ElementNode || c.tag != "" && c.tag != n.Data || c.id != "" && c.id != n.ID {
+		return false
+	}
+	classes := strings.Fields(n.Attrs["class"])
+	for _, want := range c.classes {
+		found := false
+		for _, class := range classes {
+			found = found || class == want
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// loadStyleSheets reads the <style> elements of the tree of the root n
+// again and gives its elements the declarations of the rules matching
+// them, by specificity then in order, before those of their attributes.
+func (n *Node) loadStyleSheets() {
+	nodes := n.GetNodes()
+	var rules []styleRule
+	for _, c := range nodes {
+		if c.Type == ElementNode && c.Data == "style" {
+			c.sheet = nil
+			rules = append(rules, c.stylesheet().rules...)
+		}
+	}
+	for _, c := range nodes {
+		if c.Type != ElementNode {
+			continue
+		}
+		var matched []styleRule
+		for _, r := range rules {
+			if r.selector.matches(c) {
+				matched = append(matched, r)
+			}
+		}
+		sort.SliceStable(matched, func(i, j int) bool {
+			return matched[i].selector.specificity() < matched[j].selector.specificity()
+		})
+		var css []string
+		for _, r := range matched {
+			css = append(css, r.declarations)
+		}
+		if m := strings.Join(css, ";"); m != c.matched {
+			c.restyle(m, c.declared)
+		}
+	}
+}
+
+// restyle sets the declarations of the element n, from the style sheets
+// and from its attributes, to matched and declared. Only the properties
+// whose declared value changes are set, the others keep the value the
+// program may have given them.
+func (n *Node) restyle(matched, declared string) {
+	from := n.declaredStyle()
+	n.matched, n.declared = matched, declared
+	to := n.declaredStyle()
+	st, a, b := reflect.ValueOf(n.Style).Elem(), reflect.ValueOf(from).Elem(), reflect.ValueOf(to).Elem()
+	for i := 0; i < st.NumField(); i++ {
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			st.Field(i).Set(b.Field(i))
+		}
+	}
+}
+
+// declaredStyle returns the style of the element n as declared, the
+// default style of its tag with its declarations.
+func (n *Node) declaredStyle() *CSStyle {
+	d := &Node{Type: ElementNode, Data: n.Data, Style: newElementStyle(n.Data), Model: new(Model)}
+	parseInlineStyle(d, n.matched+";"+n.declared)
+	d.Style.legacyBorder()
+	return d.Style
+}
@@ -0,0 +1,44 @@
+package geui
+
+import "testing"
+
+func TestStyleSheet(t *testing.T) {
+	root := loadTestXML(t, `<window style="width: 300px; height: 300px">
+	<style>
+		/* the most specific rule wins, then the last one */
+		box { height: 10px; opacity: 0.5 }
+		.tall, #other { height: 40px }
+		box.tall { height: 30px }
+		tabs box { font-color: #ff0000 }
+		box:hover { height: 99px }
+		@keyframes fade { to { opacity: 0 } }
+		box { opacity: 0.8 }
+	</style>
+	<box id="plain"></box>
+	<box id="tall" class="big tall"></box>
+	<box id="inline" class="tall" style="height: 50px"></box>
+	<tabs><tab title="One"><box id="nested"></box></tab></tabs>
+</window>`)
+	for id, want := range map[string]float64{"plain": 10, "tall": 30, "inline": 50, "nested": 10} {
+		if h := root.GetNodeByID(id).Style.Height; h != want {
+			t.Errorf("%s height %v, want %v", id, h, want)
+		}
+	}
+	if o := root.GetNodeByID("plain").Style.Opacity; o != 0.8 {
+		t.Errorf("opacity %v, want 0.8 from the last rule", o)
+	}
+	if c := root.GetNodeByID("nested").Style.FontColor; c != "#ff0000" {
+		t.Errorf("nested font colour %q, want the one of the descendant rule", c)
+	}
+	if c := root.GetNodeByID("plain").Style.FontColor; c == "#ff0000" {
+		t.Error("descendant rule matched outside its ancestor")
+	}
+
+	// loading the sheets again keeps what the program changed
+	plain := root.GetNodeByID("plain")
+	plain.Style.BackgroundColor = "#00ff00"
+	root.loadStyleSheets()
+	if plain.Style.BackgroundColor != "#00ff00" || plain.Style.Height != 10 {
+		t.Errorf("background %q, height %v after loading the sheets again", plain.Style.BackgroundColor, plain.Style.Height)
+	}
+}
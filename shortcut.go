@@ -0,0 +1,84 @@
+package geui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Shortcut is a key pressed with modifier keys held down, like Ctrl+S.
+type Shortcut struct {
+	Key      Key
+	Modifier Modifier
+}
+
+func (s Shortcut) String() string { return s.Modifier.String() + s.Key.String() }
+
+// shortcutModifiers are the names of the modifiers in shortcuts.
+var shortcutModifiers = map[string]Modifier{
+	"ctrl":    ModControl,
+	"control": ModControl,
+	"alt":     ModAlt,
+	"option":  ModAlt,
+	"shift":   ModShift,
+	"super":   ModSuper,
+	"cmd":     ModSuper,
+	"meta":    ModSuper,
+}
+
+// ParseShortcut parses a key chord written like Ctrl+Shift+S or F5: the
+// modifiers, then the name of the key, joined by +, in any case.
+func ParseShortcut(s string) (Shortcut, error) {
+	var sc Shortcut
+	parts := strings.Split(strings.TrimSpace(s), "+")
+	for _, p := range parts[:len(parts)-1] {
+		m, ok := shortcutModifiers[strings.ToLower(strings.TrimSpace(p))]
+		if !ok {
+			return Shortcut{}, fmt.Errorf("geui: unknown modifier %q in shortcut %q", p, s)
+		}
+		sc.Modifier |= m
+	}
+	name := strings.TrimSpace(parts[len(parts)-1])
+	for k := KeyA; k <= KeyRightSuper; k++ {
+		if strings.EqualFold(k.String(), name) {
+			sc.Key = k
+			return sc, nil
+		}
+	}
+	return Shortcut{}, fmt.Errorf("geui: unknown key %q in shortcut %q", name, s)
+}
+
+// SetAccelerator binds the key chord shortcut, like Ctrl+S, to f for the
+// whole window: f is called whenever the chord is pressed, before the
+// focused element gets the key. A nil f removes the binding. Bindings come
+// before the shortcuts of menu items.
+func (w *Window) SetAccelerator(shortcut string, f func()) error {
+	sc, err := ParseShortcut(shortcut)
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		delete(w.accelerators, sc)
+	} else {
+		w.accelerators[sc] = f
+	}
+	return nil
+}
+
+// accelerate runs what the chord sc is bound to, an accelerator or the
+// shortcut of a menu item, and reports whether there was one.
+func (w *Window) accelerate(sc Shortcut) bool {
+	if f := w.accelerators[sc]; f != nil {
+		f()
+		return true
+	}
+	for _, n := range w.node.GetNodes() {
+		if n.Type != ElementNode || n.Data != "menu" {
+			continue
+		}
+		if it := n.menu().shortcuts[sc]; it != nil && it.enabled() {
+			w.activate(it)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package geui
+
+import (
+	"image"
+	"math"
+	"strconv"
+)
+
+const (
+	dividerSize = 6  // thickness of the divider of a <splitter>
+	minPane     = 20 // smallest size the divider leaves to a pane
+)
+
+// splitterState is the state of a <splitter> element.
+type splitterState struct {
+	pos   float64 // offset of the divider in the content box, or -1 for the middle
+	moved float64 // position when the divider was pressed, to tell a change
+	grab  float64 // offset of the pointer in the divider when pressed
+}
+
+// splitter returns the state of the <splitter> element n, with the
+// divider at its position attribute, in pixels from the start.
+func (n *Node) splitter() *splitterState {
+	if n.splitterState == nil {
+		n.splitterState = &splitterState{pos: -1}
+		if v, err := strconv.ParseFloat(n.Attrs["position"], 64); err == nil {
+			n.splitterState.pos = v
+		}
+	}
+	return n.splitterState
+}
+
+// vertical reports whether the <splitter> element n stacks its panes, with
+// the orientation attribute set to vertical, rather than putting them side
+// by side.
+func (n *Node) vertical() bool {
+	return n.Attrs["orientation"] == "vertical"
+}
+
+// position returns the offset of the divider of the <splitter> element n
+// in its content box of the given size along the orientation, leaving
+// each pane at least minPane when it can.
+func (n *Node) position(size float64) float64 {
+	pos := n.splitter().pos
+	if pos < 0 {
+		pos = (size - dividerSize) / 2
+	}
+	pos = math.Min(pos, size-dividerSize-minPane)
+	return math.Max(0, math.Max(math.Min(minPane, size-dividerSize), pos))
+}
+
+// Position returns the offset of the divider of the <splitter> element n
+// in its content box.
+func (n *Node) Position() float64 {
+	_, _, w, h := n.content()
+	if n.vertical() {
+		return n.position(h)
+	}
+	return n.position(w)
+}
+
+// SetPosition moves the divider of the <splitter> element n to the offset
+// pos in its content box, resizing the panes.
+func (n *Node) SetPosition(pos float64) {
+	n.splitter().pos = pos
+	n.markDirty()
+}
+
+// divider returns the box of the divider of the <splitter> element n.
+func (n *Node) divider() (x, y, w, h float64) {
+	x, y, w, h = n.content()
+	if n.vertical() {
+		return x, y + n.position(h), w, dividerSize
+	}
+	return x + n.position(w), y, dividerSize, h
+}
+
+// layoutSplitter gives the first two element children of the <splitter>
+// element n the space on either side of its divider, within their
+// margins, and lays them out. Other children are not shown.
+func layoutSplitter(n *Node) {
+	x, y, w, h := n.content()
+	dx, dy, dw, dh := n.divider()
+	panes := [][4]float64{{x, y, dx - x, h}, {dx + dw, y, x + w - dx - dw, h}}
+	if n.vertical() {
+		panes = [][4]float64{{x, y, w, dy - y}, {x, dy + dh, w, y + h - dy - dh}}
+	}
+	i := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != ElementNode || !c.displayed() {
+			continue
+		}
+		if i == len(panes) {
+			c.setCollapsed(true)
+			continue
+		}
+		old := *c.Model
+		p, m := panes[i], c.Style.Margin
+		c.Model.RelativeX, c.Model.RelativeY = p[0]+m.Left, p[1]+m.Top
+		c.Model.Width = math.Max(0, p[2]-m.Left-m.Right)
+		c.Model.Height = math.Max(0, p[3]-m.Top-m.Bottom)
+		layout(c)
+		if *c.Model != old {
+			c.markRepaint()
+		}
+		i++
+	}
+}
+
+// dividerAt returns the <splitter> element of the tree of n whose divider
+// is at the point x, y.
+func (n *Node) dividerAt(x, y float64) *Node {
+	p := image.Pt(int(x), int(y))
+	for _, c := range n.GetNodes() {
+		if c.Type != ElementNode || c.Data != "splitter" || c.hidden() {
+			continue
+		}
+		if r, clipped := c.visible(); clipped && !p.In(r) {
+			continue
+		}
+		dx, dy, dw, dh := c.divider()
+		if lx, ly := c.local(x, y); lx >= dx && lx < dx+dw && ly >= dy && ly < dy+dh {
+			return c
+		}
+	}
+	return nil
+}
+
+// pressDivider starts dragging the divider of a splitter at the point x, y
+// and reports whether there was one. The splitter captures the pointer
+// until it is released.
+func (w *Window) pressDivider(x, y float64) bool {
+	n := w.node.dividerAt(x, y)
+	if n == nil {
+		return false
+	}
+	s := n.splitter()
+	x, y = n.local(x, y)
+	dx, dy, _, _ := n.divider()
+	s.moved, s.grab = n.Position(), x-dx
+	if n.vertical() {
+		s.grab = y - dy
+	}
+	w.capture = n
+	return true
+}
+
+// drag moves the divider of the <splitter> element n dragged with the
+// pointer at x, y.
+func (s *splitterState) drag(w *Window, n *Node, x, y float64) {
+	x, y = n.local(x, y)
+	cx, cy, _, _ := n.content()
+	pos := x - s.grab - cx
+	if n.vertical() {
+		pos = y - s.grab - cy
+	}
+	n.SetPosition(pos)
+	// keep the position the divider stops at
+	s.pos = n.Position()
+}
+
+// release ends the drag of the divider of the <splitter> element n,
+// calling its change function when it moved.
+func (s *splitterState) release(w *Window, n *Node) {
+	if n.Position() != s.moved {
+		w.changed(n)
+	}
+}
+
+// paintDivider draws the divider of the <splitter> element n.
+func (render *Renderer) paintDivider(n *Node) {
+	x, y, w, h := n.divider()
+	if n.vertical() {
+		render.rectangle(x, y+h/2-1, w, 2, 0)
+	} else {
+		render.rectangle(x+w/2-1, y, 2, h, 0)
+	}
+	render.setColor(n.Style.TrackColor, n.opacity())
+	render.canvas.Fill()
+}
@@ -0,0 +1,51 @@
+package geui
+
+import "testing"
+
+func TestSplitter(t *testing.T) {
+	root := loadTestXML(t, `<window style="width: 300px; height: 300px; hover-color: #ffffff">
+	<splitter id="split" orientation="horizontal" style="margin: 0; height: 100px">
+		<box id="left" style="margin: 0"></box>
+		<box id="right" style="margin: 0"></box>
+	</splitter>
+</window>`)
+	w, err := NewWindow(root, Size(300, 300), WithBackend(NewHeadless()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []string
+	w.OnChange("split", func(v string) { changes = append(changes, v) })
+	w.repaint()
+	split, left, right := root.GetNodeByID("split"), root.GetNodeByID("left").Model, root.GetNodeByID("right").Model
+	if left.Width != 147 || right.RelativeX != 153 || right.Width != 147 || left.Height != 100 {
+		t.Fatalf("panes = %+v, %+v, want them halving the splitter", *left, *right)
+	}
+
+	w.dispatch(MouseMove{X: 150, Y: 50})
+	if w.cursor != HResizeCursor {
+		t.Errorf("cursor over the divider = %v", w.cursor)
+	}
+	w.dispatch(MouseDown{MouseButton: MouseLeft})
+	w.dispatch(MouseMove{X: 100, Y: 50})
+	w.dispatch(MouseUp{MouseButton: MouseLeft})
+	w.repaint()
+	if left.Width != 97 || right.RelativeX != 103 || right.Width != 197 {
+		t.Errorf("panes = %+v, %+v after dragging the divider", *left, *right)
+	}
+	if len(changes) != 1 || changes[0] != "97" {
+		t.Errorf("changes = %v", changes)
+	}
+
+	// the panes keep their smallest size
+	split.SetPosition(1000)
+	w.repaint()
+	if right.Width != minPane {
+		t.Errorf("right pane width = %v, want %v", right.Width, minPane)
+	}
+	split.SetAttr("orientation", "vertical")
+	split.SetPosition(30)
+	w.repaint()
+	if left.Height != 30 || right.RelativeY != 36 || right.Height != 64 || right.Width != 300 {
+		t.Errorf("vertical panes = %+v, %+v", *left, *right)
+	}
+}
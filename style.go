@@ -3,7 +3,9 @@ package geui
 import (
 	"github.com/gorilla/css/scanner"
 	"image"
+	"math"
 	"strconv"
+	"strings"
 )
 
 type Align uint8
@@ -12,19 +14,94 @@ const (
 	LEFT Align = iota
 	RIGHT
 	CENTER
+	TOP
+	MIDDLE
+	BOTTOM
 )
 
 type CSStyle struct {
-	Width, Height   float64
-	LineHeight      int
-	FontFamily      string
-	FontSize        float64
-	FontColor       string
-	TextAlign       Align
-	BackgroundColor string
-	HoverColor      string
-	BorderColor     string
-	BorderWidth     float64
+	Width, Height      float64 // 0 sizes the node to its content
+	LineHeight         float64 // 0 is the line height of the font
+	FontFamily         string
+	FontSize           float64
+	FontColor          string
+	TextAlign          Align
+	VerticalAlign      Align
+	WhiteSpace         string // normal, nowrap, pre or pre-wrap
+	TextOverflow       string // clip or ellipsis
+	Direction          string // ltr or rtl
+	BackgroundColor    string
+	BackgroundImage    string // linear-gradient(), radial-gradient() or url()
+	BackgroundSize     string // auto, cover, contain or a width and height
+	BackgroundPosition string // like "center" or "10px 50%"
+	BackgroundRepeat   string // repeat, repeat-x, repeat-y or no-repeat
+	HoverColor         string
+	SelectedColor      string // background of the selected row of a list
+	AccentColor        string // filled part and thumb of sliders and progress bars
+	TrackColor         string // track of sliders and progress bars
+	InvalidColor       string // border of a number that is not valid
+	Margin             Edges
+	Padding            Edges
+	BorderTop          Border
+	BorderRight        Border
+	BorderBottom       Border
+	BorderLeft         Border
+	BorderRadius       float64
+	BoxShadow          Shadow
+	Opacity            float64
+	Outline            Border // drawn around the focused element
+	OutlineOffset      float64
+	Overflow           string  // visible, hidden, scroll or auto
+	ScrollbarWidth     float64 // 0 hides the scrollbars
+	ScrollbarColor     string  // colour of the thumb
+	ScrollbarTrack     string  // colour of the track
+	Display            string  // block, none, or grid to place the children in cells
+
+	// The columns and rows of a grid, sizes like "100px", "1fr", "auto" or
+	// "repeat(3, 1fr)", and the names of its cells, a string per row.
+	GridTemplateColumns []string
+	GridTemplateRows    []string
+	GridTemplateAreas   []string
+	RowGap, ColumnGap   float64
+
+	// The cells of a grid item: an area name, or lines like "1 / 3" or
+	// "span 2".
+	GridArea   string
+	GridRow    string
+	GridColumn string
+
+	Transition []Transition // properties changing smoothly
+	Animation  []Animation  // @keyframes run on the element
+
+	Transform       []Transform // applied when painting, in order
+	TransformOrigin string      // like "center" or "0 100%", the centre when empty
+
+	// Deprecated: set the Border fields of the sides instead. A colour or
+	// width set here is moved to the borders of all four sides when the
+	// node is next laid out.
+	BorderColor string
+	// Deprecated: see BorderColor.
+	BorderWidth float64
+}
+
+// Edges are lengths on the four sides of a box.
+type Edges struct {
+	Top, Right, Bottom, Left float64
+}
+
+// A Border is a line on a side of a box.
+type Border struct {
+	Width float64
+	Color string
+	Style string // solid, dashed or dotted
+}
+
+// A Shadow is cast by a box. A shadow without a colour is not drawn.
+type Shadow struct {
+	X, Y   float64
+	Blur   float64
+	Spread float64
+	Color  string
 }
 
 var (
@@ -32,24 +109,270 @@ var (
 	DefaultFontColor               = "#666666"
 	DefaultBackgroundColor         = "#4B4B4B"
 	DefaultBorderColor             = "#666666"
+	DefaultOutlineColor            = "#5B9DD9"
+	DefaultScrollbarColor          = "#999999"
+	DefaultScrollbarTrack          = "#00000022"
+	DefaultSelectedColor           = "#5B9DD9"
+	DefaultAccentColor             = "#5B9DD9"
+	DefaultTrackColor              = "#DDDDDD"
+	DefaultInvalidColor            = "#D9534F"
+	DefaultDisabledColor           = "#AAAAAA"
 )
 
 func NewStyle() *CSStyle {
 	return &CSStyle{
-		FontColor:       DefaultFontColor,
-		FontSize:        DefaultFontSize,
-		Height:          35,
-		Width:           0,
-		FontFamily:      DefaultFont,
-		LineHeight:      30,
-		TextAlign:       CENTER,
-		BackgroundColor: DefaultBackgroundColor,
-		HoverColor:      DefaultBackgroundColor,
-		BorderColor:     DefaultBorderColor,
-		BorderWidth:     1,
+		FontColor:        DefaultFontColor,
+		FontSize:         DefaultFontSize,
+		Height:           35,
+		Width:            0,
+		FontFamily:       DefaultFont,
+		TextAlign:        CENTER,
+		VerticalAlign:    MIDDLE,
+		WhiteSpace:       "normal",
+		TextOverflow:     "clip",
+		Direction:        "ltr",
+		BackgroundColor:  DefaultBackgroundColor,
+		BackgroundSize:   "auto",
+		BackgroundRepeat: "repeat",
+		HoverColor:       DefaultBackgroundColor,
+		SelectedColor:    DefaultSelectedColor,
+		AccentColor:      DefaultAccentColor,
+		TrackColor:       DefaultTrackColor,
+		InvalidColor:     DefaultInvalidColor,
+		Margin:           Edges{10, 10, 10, 10},
+		Opacity:          1,
+		Overflow:         "visible",
+		ScrollbarWidth:   8,
+		ScrollbarColor:   DefaultScrollbarColor,
+		ScrollbarTrack:   DefaultScrollbarTrack,
+		Display:          "block",
 	}
 }
 
+// newElementStyle returns the default style of an element with the given tag.
+func newElementStyle(tag string) *CSStyle {
+	st := NewStyle()
+	switch tag {
+	case "input", "select", "combobox", "number":
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+		st.Padding = Edges{0, 5, 0, 5}
+		st.setBorder(func(b *Border) {
+			*b = Border{Width: 1, Color: DefaultBorderColor, Style: "solid"}
+		})
+		st.Outline = Border{Width: 2, Color: DefaultOutlineColor, Style: "solid"}
+		switch tag {
+		case "number":
+			// room for the arrows
+			st.Padding.Right = 20
+		case "select", "combobox":
+			// room for the arrow
+			st.Padding.Right = 24
+			st.TextAlign = LEFT
+			st.WhiteSpace = "nowrap"
+			st.TextOverflow = "ellipsis"
+		}
+	case "slider":
+		st.Height = 20
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+		st.Outline = Border{Width: 2, Color: DefaultOutlineColor, Style: "solid"}
+	case "progress":
+		st.Height = 10
+		st.BorderRadius = 5
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+	case "popup":
+		st.Height = 0
+		st.Margin = Edges{}
+		st.Overflow = "auto"
+		st.BackgroundColor = "#FFFFFF"
+		st.HoverColor = "#FFFFFF"
+		st.setBorder(func(b *Border) {
+			*b = Border{Width: 1, Color: DefaultBorderColor, Style: "solid"}
+		})
+		st.BoxShadow = Shadow{Y: 2, Blur: 6, Color: "#00000044"}
+	case "option":
+		st.Height = 28
+		st.Margin = Edges{}
+		st.Padding = Edges{0, 8, 0, 8}
+		st.TextAlign = LEFT
+		st.WhiteSpace = "nowrap"
+		st.TextOverflow = "ellipsis"
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "#00000011"
+	case "list":
+		st.Height = 200
+		st.Overflow = "auto"
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+	case "grid", "tr":
+		st.Display = "grid"
+		st.Height = 0
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+		if tag == "tr" {
+			st.Margin = Edges{}
+		}
+	case "table":
+		st.Height = 0
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+	case "th", "td":
+		st.Height = 30
+		st.Margin = Edges{}
+		st.Padding = Edges{0, 6, 0, 6}
+		st.TextAlign = LEFT
+		st.WhiteSpace = "nowrap"
+		st.TextOverflow = "ellipsis"
+		if tag == "th" {
+			// room for the sort arrow
+			st.Padding.Right = 16
+		} else {
+			st.BackgroundColor = "transparent"
+			st.HoverColor = "transparent"
+		}
+	case "tabs", "accordion":
+		st.Height = 0
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+	case "tabbar":
+		st.Display = "grid"
+		st.Height = 0
+		st.Margin = Edges{}
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+		st.BorderBottom = Border{Width: 1, Color: DefaultBorderColor, Style: "solid"}
+	case "tabbutton":
+		st.Height = 32
+		st.Margin = Edges{}
+		st.Padding = Edges{0, 12, 0, 12}
+		st.WhiteSpace = "nowrap"
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "#00000011"
+		st.Outline = Border{Width: 2, Color: DefaultOutlineColor, Style: "solid"}
+	case "tab", "section":
+		st.Height = 0
+		st.Margin = Edges{}
+		st.Padding = Edges{10, 10, 10, 10}
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+	case "summary":
+		st.Height = 32
+		st.Margin = Edges{}
+		// room for the chevron
+		st.Padding = Edges{0, 24, 0, 10}
+		st.TextAlign = LEFT
+		st.BackgroundColor = "#F0F0F0"
+		st.HoverColor = "#E6E6E6"
+		st.BorderBottom = Border{Width: 1, Color: DefaultBorderColor, Style: "solid"}
+		st.Outline = Border{Width: 2, Color: DefaultOutlineColor, Style: "solid"}
+	case "menubar":
+		st.Display = "grid"
+		st.Height = 0
+		st.Margin = Edges{}
+		st.BackgroundColor = "#F5F5F5"
+		st.HoverColor = "#F5F5F5"
+		st.BorderBottom = Border{Width: 1, Color: DefaultBorderColor, Style: "solid"}
+	case "menu":
+		// shown in a <menubar>, else only in its popup
+		st.Display = "none"
+		st.Height = 28
+		st.Margin = Edges{}
+		st.Padding = Edges{0, 10, 0, 10}
+		st.WhiteSpace = "nowrap"
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "#00000011"
+	case "menuitem":
+		st.Height = 28
+		st.Margin = Edges{}
+		st.Padding = Edges{0, 12, 0, 12}
+		st.TextAlign = LEFT
+		st.WhiteSpace = "nowrap"
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "#00000011"
+	case "separator":
+		st.Height = 1
+		st.Margin = Edges{4, 0, 4, 0}
+		st.BackgroundColor = DefaultBorderColor
+		st.HoverColor = DefaultBorderColor
+	case "style":
+		// a style sheet, with rules and @keyframes
+		st.Display = "none"
+	case "tooltip":
+		st.Height = 0
+		st.Margin = Edges{}
+		st.Padding = Edges{4, 8, 4, 8}
+		st.TextAlign = LEFT
+		st.FontSize = 12
+		st.FontColor = "#FFFFFF"
+		st.BackgroundColor = "#333333"
+		st.HoverColor = "#333333"
+		st.BorderRadius = 4
+		st.BoxShadow = Shadow{Y: 2, Blur: 6, Color: "#00000044"}
+	case "error":
+		// a document that failed to reload
+		st.Height = 0
+		st.Margin = Edges{}
+		st.Padding = Edges{8, 10, 8, 10}
+		st.TextAlign = LEFT
+		st.FontColor = "#FFFFFF"
+		st.BackgroundColor = DefaultInvalidColor
+		st.HoverColor = DefaultInvalidColor
+	case "splitter":
+		st.Height = 200
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+	case "canvas":
+		st.Height = 150
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+	case "image":
+		st.Height = 0
+		st.BackgroundColor = "transparent"
+		st.HoverColor = "transparent"
+	}
+	return st
+}
+
+// borders returns the borders of the top, right, bottom and left sides.
+func (st *CSStyle) borders() []*Border {
+	return []*Border{&st.BorderTop, &st.BorderRight, &st.BorderBottom, &st.BorderLeft}
+}
+
+// setBorder applies f to the borders of all sides.
+func (st *CSStyle) setBorder(f func(*Border)) {
+	for _, b := range st.borders() {
+		f(b)
+	}
+}
+
+// legacyBorder moves the deprecated BorderColor and BorderWidth to the
+// borders of all sides.
+func (st *CSStyle) legacyBorder() {
+	if c := st.BorderColor; c != "" {
+		st.setBorder(func(b *Border) { b.Color = c })
+		st.BorderColor = ""
+	}
+	if w := st.BorderWidth; w != 0 {
+		st.setBorder(func(b *Border) {
+			b.Width = w
+			if b.Style == "" {
+				b.Style = "solid"
+			}
+			if b.Color == "" {
+				b.Color = DefaultBorderColor
+			}
+		})
+		st.BorderWidth = 0
+	}
+}
+
+// borderEdges returns the widths of the borders.
+func (st *CSStyle) borderEdges() Edges {
+	return Edges{st.BorderTop.Width, st.BorderRight.Width, st.BorderBottom.Width, st.BorderLeft.Width}
+}
+
 func parseInlineStyle(node *Node, v string) {
 	s := scanner.New(v)
 	for {
@@ -61,49 +384,428 @@ func parseInlineStyle(node *Node, v string) {
 		case scanner.TokenIdent:
 			switch tok.Value {
 			case "background-color":
-				s.Next()
-				tok = s.Next()
-				node.Style.BackgroundColor = tok.Value
+				node.Style.BackgroundColor = styleText(s)
+			case "background":
+				node.Style.setBackground(styleValues(s))
+			case "background-image":
+				node.Style.BackgroundImage = styleText(s)
+			case "background-size":
+				node.Style.BackgroundSize = styleText(s)
+			case "background-position":
+				node.Style.BackgroundPosition = styleText(s)
+			case "background-repeat":
+				node.Style.BackgroundRepeat = styleText(s)
+			case "selected-color":
+				node.Style.SelectedColor = styleText(s)
+			case "accent-color":
+				node.Style.AccentColor = styleText(s)
+			case "track-color":
+				node.Style.TrackColor = styleText(s)
+			case "invalid-color":
+				node.Style.InvalidColor = styleText(s)
 			case "font-color":
-				s.Next()
-				tok = s.Next()
-				node.Style.FontColor = tok.Value
+				node.Style.FontColor = styleText(s)
 			case "hover-color":
-				s.Next()
-				tok = s.Next()
-				node.Style.HoverColor = tok.Value
+				node.Style.HoverColor = styleText(s)
 			case "font-size":
 				s.Next()
 				tok = s.Next()
 				node.Style.FontSize, _ = strconv.ParseFloat(tok.Value, 32)
+			case "border":
+				b := parseBorder(styleValues(s))
+				node.Style.setBorder(func(side *Border) { *side = b })
+			case "border-top", "border-right", "border-bottom", "border-left":
+				b := parseBorder(styleValues(s))
+				*node.Style.borders()[sideIndex(tok.Value)] = b
 			case "border-width":
-				s.Next()
-				tok = s.Next()
-				node.Style.BorderWidth, _ = strconv.ParseFloat(tok.Value, 32)
+				w := styleLength(s)
+				node.Style.setBorder(func(b *Border) { b.Width = w })
 			case "border-color":
-				s.Next()
-				tok = s.Next()
-				node.Style.BorderColor = tok.Value
+				c := styleText(s)
+				node.Style.setBorder(func(b *Border) { b.Color = c })
+			case "border-style":
+				v := styleValue(s).Value
+				node.Style.setBorder(func(b *Border) { b.Style = v })
+			case "border-radius":
+				node.Style.BorderRadius = styleLength(s)
+			case "margin":
+				node.Style.Margin = parseEdges(styleValues(s))
+			case "padding":
+				node.Style.Padding = parseEdges(styleValues(s))
+			case "margin-top", "margin-right", "margin-bottom", "margin-left":
+				*node.Style.Margin.side(sideIndex(tok.Value)) = styleLength(s)
+			case "padding-top", "padding-right", "padding-bottom", "padding-left":
+				*node.Style.Padding.side(sideIndex(tok.Value)) = styleLength(s)
+			case "box-shadow":
+				node.Style.BoxShadow = parseShadow(styleValues(s))
+			case "opacity":
+				node.Style.Opacity, _ = strconv.ParseFloat(styleValue(s).Value, 64)
+			case "outline":
+				node.Style.Outline = parseBorder(styleValues(s))
+			case "outline-offset":
+				node.Style.OutlineOffset = styleLength(s)
+			case "overflow":
+				node.Style.Overflow = styleText(s)
+			case "scrollbar-width":
+				switch v := styleText(s); v {
+				case "auto":
+					node.Style.ScrollbarWidth = 8
+				case "thin":
+					node.Style.ScrollbarWidth = 4
+				default:
+					// none is 0
+					node.Style.ScrollbarWidth = parseLength(v)
+				}
+			case "scrollbar-color":
+				values := styleValues(s)
+				if len(values) > 0 {
+					node.Style.ScrollbarColor = values[0]
+				}
+				if len(values) > 1 {
+					node.Style.ScrollbarTrack = values[1]
+				}
+			case "display":
+				node.Style.Display = styleText(s)
+			case "grid-template-columns":
+				node.Style.GridTemplateColumns = styleValues(s)
+			case "grid-template-rows":
+				node.Style.GridTemplateRows = styleValues(s)
+			case "grid-template-areas":
+				node.Style.GridTemplateAreas = nil
+				for _, v := range styleValues(s) {
+					node.Style.GridTemplateAreas = append(node.Style.GridTemplateAreas, strings.Trim(v, `"'`))
+				}
+			case "grid-area":
+				node.Style.setGridArea(styleValues(s))
+			case "grid-row":
+				node.Style.GridRow = styleText(s)
+			case "grid-column":
+				node.Style.GridColumn = styleText(s)
+			case "gap", "grid-gap":
+				e := parseEdges(styleValues(s))
+				node.Style.RowGap, node.Style.ColumnGap = e.Top, e.Right
+			case "row-gap":
+				node.Style.RowGap = styleLength(s)
+			case "column-gap":
+				node.Style.ColumnGap = styleLength(s)
+			case "width":
+				node.Style.Width = styleLength(s)
+			case "height":
+				node.Style.Height = styleLength(s)
+			case "line-height":
+				v := styleValue(s).Value
+				node.Style.LineHeight = parseLength(v)
+				if !strings.HasSuffix(v, "px") {
+					// a multiple of the font size
+					node.Style.LineHeight *= node.Style.FontSize
+				}
+			case "text-align":
+				switch styleValue(s).Value {
+				case "left":
+					node.Style.TextAlign = LEFT
+				case "right":
+					node.Style.TextAlign = RIGHT
+				case "center":
+					node.Style.TextAlign = CENTER
+				}
+			case "vertical-align":
+				switch styleValue(s).Value {
+				case "top":
+					node.Style.VerticalAlign = TOP
+				case "middle":
+					node.Style.VerticalAlign = MIDDLE
+				case "bottom":
+					node.Style.VerticalAlign = BOTTOM
+				}
+			case "white-space":
+				node.Style.WhiteSpace = styleValue(s).Value
+			case "text-overflow":
+				node.Style.TextOverflow = styleValue(s).Value
+			case "direction":
+				node.Style.Direction = styleValue(s).Value
+			case "transition":
+				node.Style.Transition = parseTransitions(styleValues(s))
+			case "animation":
+				node.Style.Animation = parseAnimations(styleValues(s))
+			case "transform":
+				node.Style.Transform = parseTransforms(styleValues(s))
+			case "transform-origin":
+				node.Style.TransformOrigin = styleText(s)
 			}
 		}
 	}
 }
 
+// styleValue returns the value token of the declaration the scanner s
+// is at, skipping the colon and white space before it.
+func styleValue(s *scanner.Scanner) *scanner.Token {
+	for {
+		tok := s.Next()
+		if tok.Type != scanner.TokenS && tok.Value != ":" {
+			return tok
+		}
+	}
+}
+
+// styleValues returns the value tokens of the declaration the scanner s
+// is at, up to the semicolon ending it. Minus signs are joined to the
+// numbers they precede, and functions like "rgba(0, 0, 0, .5)" are one
+// value.
+func styleValues(s *scanner.Scanner) (values []string) {
+	minus := false
+	for {
+		tok := s.Next()
+		switch {
+		case tok.Type == scanner.TokenEOF || tok.Value == ";":
+			return
+		case tok.Type == scanner.TokenS || tok.Value == ":":
+		case tok.Value == "-":
+			minus = true
+		case tok.Type == scanner.TokenFunction:
+			values = append(values, styleFunction(s, tok.Value))
+		case minus:
+			values = append(values, "-"+tok.Value)
+			minus = false
+		default:
+			values = append(values, tok.Value)
+		}
+	}
+}
+
+// styleFunction returns the function starting with name, like "rgba(",
+// with its arguments up to the closing parenthesis.
+func styleFunction(s *scanner.Scanner, name string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	depth := 1
+	for depth > 0 {
+		tok := s.Next()
+		switch {
+		case tok.Type == scanner.TokenEOF || tok.Type == scanner.TokenError:
+			return b.String() + ")"
+		case tok.Type == scanner.TokenS:
+			b.WriteByte(' ')
+			continue
+		case tok.Type == scanner.TokenFunction:
+			depth++
+		case tok.Value == ")":
+			depth--
+		}
+		b.WriteString(tok.Value)
+	}
+	return b.String()
+}
+
+// styleText returns the values of the declaration the scanner s is at,
+// separated by spaces.
+func styleText(s *scanner.Scanner) string {
+	return strings.Join(styleValues(s), " ")
+}
+
+// styleLength returns the length the declaration the scanner s is at
+// sets, like "-2px".
+func styleLength(s *scanner.Scanner) float64 {
+	values := styleValues(s)
+	if len(values) == 0 {
+		return 0
+	}
+	return parseLength(values[0])
+}
+
+// parseEdges parses one to four lengths, given in the order of CSS:
+// top, right, bottom and left, where missing sides copy the opposite one.
+func parseEdges(values []string) (e Edges) {
+	var v [4]float64
+	for i := 0; i < len(values) && i < 4; i++ {
+		v[i] = parseLength(values[i])
+	}
+	switch len(values) {
+	case 1:
+		v[1], v[2], v[3] = v[0], v[0], v[0]
+	case 2:
+		v[2], v[3] = v[0], v[1]
+	case 3:
+		v[3] = v[1]
+	}
+	return Edges{v[0], v[1], v[2], v[3]}
+}
+
+// parseBorder parses a border like "1px dashed #666666".
+func parseBorder(values []string) (b Border) {
+	b.Style = "solid"
+	for _, v := range values {
+		switch {
+		case v == "none":
+			return Border{}
+		case v == "solid" || v == "dashed" || v == "dotted":
+			b.Style = v
+		case isLength(v):
+			b.Width = parseLength(v)
+		default:
+			b.Color = v
+		}
+	}
+	if b.Color == "" {
+		b.Color = DefaultBorderColor
+	}
+	return
+}
+
+// parseShadow parses a shadow like "2px 2px 4px #000000": the offset, the
+// blur radius and spread distance, which are optional, and the colour.
+func parseShadow(values []string) (sh Shadow) {
+	var lengths []float64
+	for _, v := range values {
+		switch {
+		case v == "none":
+			return Shadow{}
+		case isLength(v):
+			lengths = append(lengths, parseLength(v))
+		default:
+			sh.Color = v
+		}
+	}
+	lengths = append(lengths, 0, 0, 0, 0)
+	sh.X, sh.Y, sh.Blur, sh.Spread = lengths[0], lengths[1], lengths[2], lengths[3]
+	if sh.Color == "" {
+		sh.Color = "#00000080"
+	}
+	return
+}
+
+// setBackground sets the background of st from a shorthand like
+// "url(a.png) center / cover no-repeat #fff". The parts left out are reset.
+func (st *CSStyle) setBackground(values []string) {
+	st.BackgroundColor = "transparent"
+	st.BackgroundImage = ""
+	st.BackgroundSize = "auto"
+	st.BackgroundPosition = ""
+	st.BackgroundRepeat = "repeat"
+	var position, size []string
+	inSize := false
+	for _, v := range values {
+		switch {
+		case v == "none":
+		case v == "/":
+			inSize = true
+		case strings.HasPrefix(v, "url(") || strings.HasSuffix(strings.SplitN(v, "(", 2)[0], "gradient"):
+			st.BackgroundImage = v
+		case v == "repeat" || v == "repeat-x" || v == "repeat-y" || v == "no-repeat":
+			st.BackgroundRepeat = v
+		case inSize && (v == "auto" || v == "cover" || v == "contain" || isLength(v) || isPercentage(v)):
+			size = append(size, v)
+		case isPosition(v):
+			inSize = false
+			position = append(position, v)
+		default:
+			st.BackgroundColor = v
+		}
+	}
+	if size != nil {
+		st.BackgroundSize = strings.Join(size, " ")
+	}
+	st.BackgroundPosition = strings.Join(position, " ")
+}
+
+// setGridArea sets the cells of a grid item from an area name, or from
+// its lines like "1 / 2 / 3 / 4": row start, column start, row end and
+// column end.
+func (st *CSStyle) setGridArea(values []string) {
+	st.GridArea, st.GridRow, st.GridColumn = "", "", ""
+	if len(values) == 1 {
+		if _, err := strconv.Atoi(values[0]); err != nil {
+			st.GridArea = values[0]
+			return
+		}
+	}
+	var lines [4]string
+	i := 0
+	for _, v := range values {
+		switch {
+		case v == "/":
+			i++
+		case i < 4:
+			lines[i] = strings.TrimSpace(lines[i] + " " + v)
+		}
+	}
+	st.GridRow, st.GridColumn = lines[0], lines[1]
+	if lines[2] != "" {
+		st.GridRow += " / " + lines[2]
+	}
+	if lines[3] != "" {
+		st.GridColumn += " / " + lines[3]
+	}
+}
+
+// isPosition reports whether v is a part of a background position.
+func isPosition(v string) bool {
+	switch v {
+	case "left", "center", "right", "top", "bottom":
+		return true
+	}
+	return isLength(v) || isPercentage(v)
+}
+
+// isPercentage reports whether v is a number of percent, like "50%".
+func isPercentage(v string) bool {
+	return strings.HasSuffix(v, "%") && isLength(strings.TrimSuffix(v, "%"))
+}
+
+// sideIndex returns the index of the side named in a property like
+// "border-top", in the order top, right, bottom and left.
+func sideIndex(property string) int {
+	for i, side := range []string{"top", "right", "bottom", "left"} {
+		if strings.Contains(property, "-"+side) {
+			return i
+		}
+	}
+	return 0
+}
+
+// side returns the length of the side with the given index.
+func (e *Edges) side(i int) *float64 {
+	return []*float64{&e.Top, &e.Right, &e.Bottom, &e.Left}[i]
+}
+
+// isLength reports whether v is a number, with or without a unit.
+func isLength(v string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSuffix(v, "px"), 64)
+	return err == nil
+}
+
+// parseLength parses a length in logical units, like "20" or "20px".
+// Lengths that are not numbers, like "auto", are 0.
+func parseLength(v string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSuffix(v, "px"), 64)
+	return f
+}
+
+// Bounds returns the box of the node on the canvas, which moves with the
+// scroll offset of its ancestors, rounded out to the pixels it covers.
 func (n *Node) Bounds() image.Rectangle {
-	x, y, w, h := int(n.Model.RelativeX), int(n.Model.RelativeY),
-		int(n.Model.Width), int(n.Model.Height)
+	m := n.Model
 	return image.Rect(
-		x,
-		y,
-		x+w,
-		y+h,
+		int(math.Floor(m.RelativeX)),
+		int(math.Floor(m.RelativeY)),
+		int(math.Ceil(m.RelativeX+m.Width)),
+		int(math.Ceil(m.RelativeY+m.Height)),
 	)
 }
 
+// Focused reports whether the point x, y is over the node, in the part
+// of it not clipped by scrolling ancestors, when it is not hidden. The
+// point is mapped through the transforms of the node.
 func (n *Node) Focused(x, y float64) bool {
 	p := image.Point{
 		X: int(x),
 		Y: int(y),
 	}
-	return p.In(n.Bounds())
+	if r, clipped := n.visible(); (clipped && !p.In(r)) || n.hidden() {
+		return false
+	}
+	if n.transformed() {
+		x, y = n.local(x, y)
+	}
+	m := n.Model
+	return x >= m.RelativeX && x < m.RelativeX+m.Width && y >= m.RelativeY && y < m.RelativeY+m.Height
 }
@@ -0,0 +1,314 @@
+package geui
+
+import (
+	"image"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	minColumn    = 20 // narrowest width of a table column
+	columnHandle = 4  // distance from a column border it is dragged within
+)
+
+// tableState is the state of a <table> element: the widths of its columns
+// and the column its rows are sorted by.
+type tableState struct {
+	widths []float64 // 0 shares the free width with the other columns
+	sorted int       // sorted column, or -1
+	desc   bool
+}
+
+// table returns the state of the <table> element n. Columns take the
+// widths given to the cells of the header row.
+func (n *Node) table() *tableState {
+	if n.tableState != nil {
+		return n.tableState
+	}
+	t := &tableState{sorted: -1}
+	for r := n.FirstChild; r != nil; r = r.NextSibling {
+		if !r.headerRow() {
+			continue
+		}
+		for i, c := 0, r.cell(0); c != nil; i, c = i+1, r.cell(i+1) {
+			t.widths = append(t.widths, c.Style.Width)
+			c.Style.Width = 0
+		}
+		break
+	}
+	n.tableState = t
+	return t
+}
+
+// headerRow reports whether the node is a <tr> of header cells.
+func (n *Node) headerRow() bool {
+	c := n.cell(0)
+	return n.Data == "tr" && c != nil && c.Data == "th"
+}
+
+// cell returns the cell of the row n in the column i, or nil.
+func (n *Node) cell(i int) *Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != ElementNode {
+			continue
+		}
+		if i == 0 {
+			return c
+		}
+		i--
+	}
+	return nil
+}
+
+// cellText returns the text in the node n.
+func (n *Node) cellText() string {
+	if n.Type == CharDataNode {
+		return n.Data
+	}
+	var s []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if t := c.cellText(); t != "" {
+			s = append(s, t)
+		}
+	}
+	return collapseSpace(strings.Join(s, " "))
+}
+
+// columns returns the widths of the columns of a table width w wide,
+// where the columns without a width share what the others leave.
+func (t *tableState) columns(w float64) []float64 {
+	widths := make([]float64, len(t.widths))
+	fixed, flexible := 0.0, 0
+	for _, c := range t.widths {
+		if c == 0 {
+			flexible++
+		}
+		fixed += c
+	}
+	for i, c := range t.widths {
+		widths[i] = c
+		if c == 0 {
+			widths[i] = math.Max(minColumn, (w-fixed)/float64(flexible))
+		}
+	}
+	return widths
+}
+
+// layoutTable sets the columns of the rows of the <table> element n,
+// which are grids, to the widths of the columns of the table.
+func layoutTable(n *Node) {
+	t := n.table()
+	for r := n.FirstChild; r != nil; r = r.NextSibling {
+		if r.Data != "tr" {
+			continue
+		}
+		for len(t.widths) < len(r.elements()) {
+			t.widths = append(t.widths, 0)
+		}
+	}
+	_, _, w, _ := n.content()
+	tracks := make([]string, len(t.widths))
+	total := 0.0
+	for i, c := range t.columns(w) {
+		tracks[i] = strconv.FormatFloat(c, 'f', -1, 64) + "px"
+		total += c
+	}
+	for r := n.FirstChild; r != nil; r = r.NextSibling {
+		if r.Data == "tr" {
+			r.Style.GridTemplateColumns = tracks
+			r.Style.Width = total
+		}
+	}
+}
+
+// elements returns the element children of n.
+func (n *Node) elements() (nodes []*Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == ElementNode {
+			nodes = append(nodes, c)
+		}
+	}
+	return
+}
+
+// SetColumnWidth sets the width of the column col of the <table> element
+// n, 0 to share the free width.
+func (n *Node) SetColumnWidth(col int, w float64) {
+	t := n.table()
+	if col < 0 || col >= len(t.widths) {
+		return
+	}
+	if w != 0 {
+		w = math.Max(minColumn, w)
+	}
+	t.widths[col] = w
+	n.markDirty()
+}
+
+// SortColumn returns the column the rows of the <table> element n are
+// sorted by, or -1, and whether they are in descending order.
+func (n *Node) SortColumn() (col int, desc bool) {
+	t := n.table()
+	return t.sorted, t.desc
+}
+
+// SortTable sorts the rows of the <table> element n by the text of their
+// cells in the column col, in descending order when desc. Texts that are
+// numbers compare by value and come before other texts, which compare
+// ignoring case. Header rows stay on top.
+func (n *Node) SortTable(col int, desc bool) {
+	t := n.table()
+	var rows []*Node
+	for r := n.FirstChild; r != nil; r = r.NextSibling {
+		if r.Data == "tr" && !r.headerRow() {
+			rows = append(rows, r)
+		}
+	}
+	text := func(r *Node) string {
+		if c := r.cell(col); c != nil {
+			return c.cellText()
+		}
+		return ""
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := text(rows[i]), text(rows[j])
+		if desc {
+			a, b = b, a
+		}
+		fa, numA := cellNumber(a)
+		fb, numB := cellNumber(b)
+		switch {
+		case numA && numB:
+			return fa < fb
+		case numA != numB:
+			return numA
+		}
+		return strings.ToLower(a) < strings.ToLower(b)
+	})
+	for _, r := range rows {
+		RemoveChild(n, r)
+		AddChild(n, r)
+	}
+	t.sorted, t.desc = col, desc
+	n.markDirty()
+}
+
+// cellNumber returns the value of the cell text s, and whether it is a
+// number.
+func cellNumber(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil && !math.IsNaN(f)
+}
+
+// tableHeader returns the <table> element and the column of the header
+// cell the node n is in, or nil.
+func (n *Node) tableHeader() (*Node, int) {
+	for ; n != nil; n = n.Parent {
+		if n.Data != "th" || n.Parent == nil || n.Parent.Parent == nil || n.Parent.Parent.Data != "table" {
+			continue
+		}
+		for i, c := range n.Parent.elements() {
+			if c == n {
+				return n.Parent.Parent, i
+			}
+		}
+	}
+	return nil, -1
+}
+
+// click sorts the <table> element n by the column of the header cell the
+// node hit is in, see toggleSort.
+func (t *tableState) click(w *Window, n, hit *Node) *Node {
+	if table, col := hit.tableHeader(); table == n {
+		n.toggleSort(col)
+		return hit
+	}
+	return nil
+}
+
+// key leaves the keys to the cells of the <table> element n.
+func (t *tableState) key(w *Window, n *Node, k Key) bool {
+	return false
+}
+
+// toggleSort sorts the <table> element n by the column col, ascending, or
+// the other way round when it is already sorted by it.
+func (n *Node) toggleSort(col int) {
+	t := n.table()
+	n.SortTable(col, t.sorted == col && !t.desc)
+}
+
+// columnBorderAt returns the <table> element with the right border of a
+// header cell near the point x, y, with the column of the cell.
+func (n *Node) columnBorderAt(x, y float64) (*Node, int, bool) {
+	for _, c := range n.GetNodes() {
+		if c.Type != ElementNode || c.Data != "th" || c.hidden() {
+			continue
+		}
+		m := c.Model
+		lx, ly := c.local(x, y)
+		if ly < m.RelativeY || ly >= m.RelativeY+m.Height || math.Abs(lx-(m.RelativeX+m.Width)) > columnHandle {
+			continue
+		}
+		if r, clipped := c.visible(); clipped && !image.Pt(int(x), int(y)).In(r) {
+			continue
+		}
+		if table, col := c.tableHeader(); table != nil {
+			return table, col, true
+		}
+	}
+	return nil, -1, false
+}
+
+// columnDrag is the border of a table column dragged with the pointer.
+type columnDrag struct {
+	table *Node
+	col   int
+	x     float64 // position of the pointer in the table when pressed
+	width float64 // width of the column when pressed
+}
+
+// pressColumnBorder starts dragging the border of the table column at
+// the point x, y and reports whether there was one.
+func (w *Window) pressColumnBorder(x, y float64) bool {
+	table, col, ok := w.node.columnBorderAt(x, y)
+	if !ok {
+		return false
+	}
+	_, _, tw, _ := table.content()
+	x, _ = table.local(x, y)
+	w.resizing = &columnDrag{table: table, col: col, x: x, width: table.table().columns(tw)[col]}
+	return true
+}
+
+// resizeColumn sets the width of the dragged column for the pointer at x,
+// y.
+func (w *Window) resizeColumn(x, y float64) {
+	d := w.resizing
+	x, _ = d.table.local(x, y)
+	d.table.SetColumnWidth(d.col, d.width+x-d.x)
+}
+
+// paintSortArrow draws an arrow in the header cell n when the rows are
+// sorted by its column, pointing up in ascending order.
+func (render *Renderer) paintSortArrow(n *Node) {
+	table, col := n.tableHeader()
+	if table == nil {
+		return
+	}
+	if sorted, desc := table.SortColumn(); sorted == col {
+		x, y, w, h := n.content()
+		cx, cy, s := x+w+n.Style.Padding.Right/2, y+h/2, 4.0
+		if desc {
+			s = -s
+		}
+		render.canvas.MoveTo(cx-4, cy+s/2)
+		render.canvas.LineTo(cx+4, cy+s/2)
+		render.canvas.LineTo(cx, cy-s/2)
+		render.canvas.ClosePath()
+		render.setColor(n.Style.FontColor, n.opacity())
+		render.canvas.Fill()
+	}
+}
@@ -0,0 +1,102 @@
+package geui
+
+import (
+	"testing"
+)
+
+func newTableWindow(t *testing.T) (*Window, *Node) {
+	w := newXMLWindow(t, `<window style="width: 300px; height: 300px; background-color: #ffffff; hover-color: #ffffff">
+	<table id="people" style="margin: 0">
+		<tr><th style="width: 100px">Name</th><th>Age</th></tr>
+		<tr><td>bob</td><td>31</td></tr>
+		<tr><td>Alice with a very long name that does not fit</td><td>7</td></tr>
+		<tr><td>carol</td><td>120</td></tr>
+	</table>
+</window>`)
+	return w, w.node.GetNodeByID("people")
+}
+
+func names(table *Node, col int) (s []string) {
+	for _, r := range table.elements()[1:] {
+		s = append(s, r.cell(col).cellText())
+	}
+	return
+}
+
+func TestTableLayout(t *testing.T) {
+	_, table := newTableWindow(t)
+	rows := table.elements()
+	if m := rows[2].cell(1).Model; m.RelativeX != 100 || m.Width != 200 || m.RelativeY != 60 || m.Height != 30 {
+		t.Errorf("cell in the second column of the third row = %+v", *m)
+	}
+	long := rows[2].cell(0)
+	if l := long.FirstChild.text.lines; len(l) != 1 || l[0].width > 100-12 || l[0].text[len(l[0].text)-len(ellipsis):] != ellipsis {
+		t.Errorf("long cell text laid out as %+v, want truncated", l)
+	}
+}
+
+func TestTableSort(t *testing.T) {
+	w, table := newTableWindow(t)
+	click := func(x, y float64) {
+		w.dispatch(MouseMove{X: x, Y: y})
+		w.dispatch(MouseDown{MouseButton: MouseLeft})
+		w.dispatch(MouseUp{MouseButton: MouseLeft})
+		w.repaint()
+	}
+	click(150, 15)
+	if got := names(table, 1); got[0] != "7" || got[1] != "31" || got[2] != "120" {
+		t.Errorf("ages sorted as %v, want by value", got)
+	}
+	click(150, 15)
+	if col, desc := table.SortColumn(); col != 1 || !desc {
+		t.Errorf("sorted by %d descending %v after the second click", col, desc)
+	}
+	if got := names(table, 1); got[0] != "120" {
+		t.Errorf("ages sorted as %v, want descending", got)
+	}
+	click(50, 15)
+	if got := names(table, 0); got[0][:5] != "Alice" || got[1] != "bob" || got[2] != "carol" {
+		t.Errorf("names sorted as %v, want ignoring case", got)
+	}
+	if m := table.elements()[1].Model; m.RelativeY != 30 {
+		t.Errorf("first row at %v after sorting", m.RelativeY)
+	}
+
+	// numbers come before texts, bob being the second row
+	table.elements()[2].cell(1).FirstChild.Data = "n/a"
+	table.SortTable(1, false)
+	if got := names(table, 1); got[0] != "7" || got[1] != "120" || got[2] != "n/a" {
+		t.Errorf("ages sorted as %v, want the numbers first", got)
+	}
+	table.SortTable(1, true)
+	if got := names(table, 1); got[0] != "n/a" || got[1] != "120" || got[2] != "7" {
+		t.Errorf("ages sorted as %v, want the text first in descending order", got)
+	}
+}
+
+func TestTableResize(t *testing.T) {
+	w, table := newTableWindow(t)
+	w.dispatch(MouseMove{X: 101, Y: 15})
+	if w.cursor != HResizeCursor {
+		t.Errorf("cursor over a column border = %v", w.cursor)
+	}
+	w.dispatch(MouseDown{MouseButton: MouseLeft})
+	w.dispatch(MouseMove{X: 151, Y: 15})
+	w.dispatch(MouseUp{MouseButton: MouseLeft})
+	w.repaint()
+	if col, _ := table.SortColumn(); col != -1 {
+		t.Errorf("resizing sorted the table by %d", col)
+	}
+	cell := table.elements()[1].cell(1)
+	if m := cell.Model; m.RelativeX != 150 || m.Width != 150 {
+		t.Errorf("cell of the second column = %+v after resizing the first", *m)
+	}
+	w.dispatch(MouseMove{X: 151, Y: 15})
+	w.dispatch(MouseDown{MouseButton: MouseLeft})
+	w.dispatch(MouseMove{X: 0, Y: 15})
+	w.dispatch(MouseUp{MouseButton: MouseLeft})
+	w.repaint()
+	if m := cell.Model; m.RelativeX != minColumn {
+		t.Errorf("first column shrunk to %v, want at least %v", m.RelativeX, minColumn)
+	}
+}
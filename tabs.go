@@ -0,0 +1,235 @@
+package geui
+
+// tabsState is the state of a <tabs> element: the bar of buttons with the
+// titles of its <tab> children, of which it shows one at a time.
+type tabsState struct {
+	bar      *Node   // <tabbar> inserted before the tabs
+	tabs     []*Node // <tab> children
+	selected int     // shown tab
+}
+
+// tabs returns the state of the <tabs> element n, inserting its bar of
+// buttons first. The first tab is shown unless one has the selected
+// attribute.
+func (n *Node) tabs() *tabsState {
+	if n.tabsState != nil {
+		return n.tabsState
+	}
+	s := &tabsState{}
+	bar := &Node{Type: ElementNode, Data: "tabbar", Style: newElementStyle("tabbar"), Model: new(Model)}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != ElementNode || c.Data != "tab" {
+			continue
+		}
+		if _, ok := c.Attrs["selected"]; ok {
+			s.selected = len(s.tabs)
+		}
+		b := &Node{Type: ElementNode, Data: "tabbutton", Style: newElementStyle("tabbutton"), Model: new(Model)}
+		AddChild(b, &Node{Type: CharDataNode, Data: c.Attrs["title"], Model: new(Model)})
+		AddChild(bar, b)
+		bar.Style.GridTemplateColumns = append(bar.Style.GridTemplateColumns, "auto")
+		s.tabs = append(s.tabs, c)
+	}
+	// the rest of the bar is left empty
+	bar.Style.GridTemplateColumns = append(bar.Style.GridTemplateColumns, "1fr")
+	InsertBefore(n, bar, n.FirstChild)
+	s.bar = bar
+	n.tabsState = s
+	n.selectTab(s.selected)
+	return s
+}
+
+// selectTab shows the tab i of the <tabs> element n and hides the others.
+// It reports whether another tab was shown before.
+func (n *Node) selectTab(i int) bool {
+	s := n.tabs()
+	if i < 0 || i >= len(s.tabs) {
+		return false
+	}
+	changed := i != s.selected
+	s.selected = i
+	for j, b := range s.bar.elements() {
+		s.tabs[j].setCollapsed(j != i)
+		if sel := j == i; sel != b.selected {
+			b.selected = sel
+			b.markRepaint()
+		}
+	}
+	n.markDirty()
+	return changed
+}
+
+// tabButton returns the <tabs> element whose bar has the button n, with
+// the index of its tab.
+func (n *Node) tabButton() (*Node, int) {
+	if n == nil || n.Data != "tabbutton" || n.Parent == nil || n.Parent.Parent == nil {
+		return nil, -1
+	}
+	tabs := n.Parent.Parent
+	for i, b := range tabs.tabs().bar.elements() {
+		if b == n {
+			return tabs, i
+		}
+	}
+	return nil, -1
+}
+
+// click shows the tab of the button hit of the <tabs> element n.
+func (s *tabsState) click(w *Window, n, hit *Node) *Node {
+	tabs, i := hit.tabButton()
+	if tabs != n {
+		return nil
+	}
+	if n.selectTab(i) {
+		w.selected(n, i)
+	}
+	return hit
+}
+
+// key handles the key k for the focused tab button of the <tabs> element
+// n and reports whether it was used. The left and right arrows show the
+// previous and next tab, moving the focus to its button.
+func (s *tabsState) key(w *Window, n *Node, k Key) bool {
+	tabs, i := w.active.tabButton()
+	switch {
+	case tabs != n:
+		return false
+	case k == KeyLeft:
+		i--
+	case k == KeyRight:
+		i++
+	default:
+		return false
+	}
+	if n.selectTab(i) {
+		w.focus(s.bar.elements()[i])
+		w.selected(n, i)
+	}
+	return true
+}
+
+// accordionState is the state of an <accordion> element: the headers
+// inserted before its <section> children, which collapse on their own or,
+// with the single attribute, when another opens.
+type accordionState struct {
+	sections []*Node
+	headers  []*Node // <summary> before each section
+	single   bool
+}
+
+// accordion returns the state of the <accordion> element n, inserting a
+// header with the title of each section before it. Sections with the open
+// attribute are expanded.
+func (n *Node) accordion() *accordionState {
+	if n.accordionState != nil {
+		return n.accordionState
+	}
+	s := &accordionState{}
+	_, s.single = n.Attrs["single"]
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != ElementNode || c.Data != "section" {
+			continue
+		}
+		h := &Node{Type: ElementNode, Data: "summary", Style: newElementStyle("summary"), Model: new(Model)}
+		AddChild(h, &Node{Type: CharDataNode, Data: c.Attrs["title"], Model: new(Model)})
+		InsertBefore(n, h, c)
+		_, open := c.Attrs["open"]
+		c.setCollapsed(!open)
+		s.sections = append(s.sections, c)
+		s.headers = append(s.headers, h)
+	}
+	n.accordionState = s
+	return s
+}
+
+// Expanded reports whether the section i of the <accordion> element n is
+// open.
+func (n *Node) Expanded(i int) bool {
+	s := n.accordion()
+	return i >= 0 && i < len(s.sections) && !s.sections[i].collapsed
+}
+
+// SetExpanded opens or collapses the section i of the <accordion> element
+// n. Opening a section of a single accordion collapses the others.
+func (n *Node) SetExpanded(i int, open bool) {
+	s := n.accordion()
+	if i < 0 || i >= len(s.sections) {
+		return
+	}
+	for j, c := range s.sections {
+		switch {
+		case j == i:
+			c.setCollapsed(!open)
+		case open && s.single:
+			c.setCollapsed(true)
+		default:
+			continue
+		}
+		s.headers[j].markRepaint()
+	}
+	n.markDirty()
+}
+
+// accordionHeader returns the <accordion> element with the header n, with
+// the index of its section.
+func (n *Node) accordionHeader() (*Node, int) {
+	if n == nil || n.Data != "summary" || n.Parent == nil || n.Parent.Data != "accordion" {
+		return nil, -1
+	}
+	for i, h := range n.Parent.accordion().headers {
+		if h == n {
+			return n.Parent, i
+		}
+	}
+	return nil, -1
+}
+
+// click opens or collapses the section of the header hit of the
+// <accordion> element n.
+func (s *accordionState) click(w *Window, n, hit *Node) *Node {
+	if acc, i := hit.accordionHeader(); acc == n {
+		w.toggleSection(n, i)
+		return hit
+	}
+	return nil
+}
+
+// key opens or collapses the section of the focused header of the
+// <accordion> element n with enter or space.
+func (s *accordionState) key(w *Window, n *Node, k Key) bool {
+	if acc, i := w.active.accordionHeader(); acc == n && (k == KeyEnter || k == KeySpace) {
+		w.toggleSection(n, i)
+		return true
+	}
+	return false
+}
+
+// toggleSection opens the section i of the <accordion> element n when it
+// is collapsed and collapses it otherwise.
+func (w *Window) toggleSection(n *Node, i int) {
+	n.SetExpanded(i, !n.Expanded(i))
+	w.selected(n, i)
+}
+
+// paintChevron draws an arrow in the right padding of the header n of an
+// accordion, pointing down when its section is open.
+func (render *Renderer) paintChevron(n *Node) {
+	acc, i := n.accordionHeader()
+	if acc == nil {
+		return
+	}
+	x, y, w, h := n.content()
+	cx, cy := x+w+n.Style.Padding.Right/2, y+h/2
+	if acc.Expanded(i) {
+		render.canvas.MoveTo(cx-4, cy-2)
+		render.canvas.LineTo(cx, cy+2)
+		render.canvas.LineTo(cx+4, cy-2)
+	} else {
+		render.canvas.MoveTo(cx-2, cy-4)
+		render.canvas.LineTo(cx+2, cy)
+		render.canvas.LineTo(cx-2, cy+4)
+	}
+	render.setColor(n.Style.FontColor, n.opacity())
+	render.canvas.SetLineWidth(1.5 * render.scale)
+	render.canvas.Stroke()
+}
@@ -0,0 +1,96 @@
+package geui
+
+import "testing"
+
+func TestTabs(t *testing.T) {
+	root := loadTestXML(t, `<window style="width: 300px; height: 300px; hover-color: #ffffff">
+	<tabs id="tabs" style="margin: 0">
+		<tab title="One"><box id="one" style="height: 50px"></box></tab>
+		<tab title="Two" selected=""><box id="two" style="height: 80px"></box></tab>
+	</tabs>
+	<box id="below" style="height: 20px; margin: 0"></box>
+</window>`)
+	w, err := NewWindow(root, Size(300, 300), WithBackend(NewHeadless()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var selects []int
+	w.OnSelect("tabs", func(i int) { selects = append(selects, i) })
+	w.repaint()
+	tabs, one, two := root.GetNodeByID("tabs"), root.GetNodeByID("one"), root.GetNodeByID("two")
+	if tabs.Selected() != 1 || !one.hidden() || two.hidden() {
+		t.Fatalf("selected tab = %d, want the one with the selected attribute", tabs.Selected())
+	}
+	// the hidden tab takes no space
+	bar := tabs.tabs().bar
+	if h, want := tabs.Model.Height, bar.Model.Height+10+10+80+10+10; h != want {
+		t.Errorf("tabs height = %v, want %v", h, want)
+	}
+	if y := root.GetNodeByID("below").Model.RelativeY; y != tabs.Model.Height {
+		t.Errorf("next element at %v, want %v", y, tabs.Model.Height)
+	}
+	if !one.area().Empty() || one.Focused(20, bar.Model.Height+20) {
+		t.Error("the hidden tab paints or is under the pointer")
+	}
+
+	b := bar.elements()[0].Model
+	click(w, b.RelativeX+b.Width/2, b.RelativeY+b.Height/2)
+	if tabs.Selected() != 0 || one.hidden() || !two.hidden() || len(selects) != 1 || selects[0] != 0 {
+		t.Fatalf("after a click on the first tab, selected = %d, events %v", tabs.Selected(), selects)
+	}
+	if h, want := tabs.Model.Height, bar.Model.Height+10+10+50+10+10; h != want {
+		t.Errorf("tabs height = %v, want %v", h, want)
+	}
+
+	// the arrows move between the tabs of the focused button
+	w.keyDown(KeyRight)
+	w.keyDown(KeyRight)
+	if tabs.Selected() != 1 || w.active != bar.elements()[1] || len(selects) != 2 {
+		t.Errorf("after the right arrows, selected = %d, events %v", tabs.Selected(), selects)
+	}
+	tabs.SetSelected(0)
+	if tabs.Selected() != 0 || len(selects) != 2 {
+		t.Error("SetSelected did not show the tab or called the select function")
+	}
+}
+
+func TestAccordion(t *testing.T) {
+	root := loadTestXML(t, `<window style="width: 300px; height: 300px; hover-color: #ffffff">
+	<accordion id="acc" single="" style="margin: 0">
+		<section title="A" open=""><box style="height: 40px"></box></section>
+		<section title="B"><box style="height: 60px"></box></section>
+	</accordion>
+</window>`)
+	w, err := NewWindow(root, Size(300, 300), WithBackend(NewHeadless()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var selects []int
+	w.OnSelect("acc", func(i int) { selects = append(selects, i) })
+	w.repaint()
+	acc := root.GetNodeByID("acc")
+	s := acc.accordion()
+	if len(s.headers) != 2 || s.headers[0].NextSibling != s.sections[0] {
+		t.Fatal("no header before each section")
+	}
+	if !acc.Expanded(0) || acc.Expanded(1) {
+		t.Fatal("only the open section should be expanded")
+	}
+	if h, want := acc.Model.Height, 32.0+10+10+40+10+10+32; h != want {
+		t.Errorf("accordion height = %v, want %v", h, want)
+	}
+
+	// opening a section of a single accordion collapses the others
+	h := s.headers[1].Model
+	click(w, h.RelativeX+10, h.RelativeY+h.Height/2)
+	if acc.Expanded(0) || !acc.Expanded(1) || len(selects) != 1 || selects[0] != 1 {
+		t.Fatalf("after a click on the second header, events %v", selects)
+	}
+	if h, want := acc.Model.Height, 32.0+32+10+10+60+10+10; h != want {
+		t.Errorf("accordion height = %v, want %v", h, want)
+	}
+	w.keyDown(KeySpace)
+	if acc.Expanded(1) || len(selects) != 2 {
+		t.Error("space on the focused header did not collapse its section")
+	}
+}
@@ -0,0 +1,314 @@
+package geui
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	gtfont "github.com/go-text/typesetting/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const ellipsis = "…"
+
+var fonts = struct {
+	sync.Mutex
+	files map[string]*gtfont.Face
+}{
+	files: map[string]*gtfont.Face{},
+}
+
+// fontFace returns the face of the font file family at size pixels. Fonts
+// that fail to load fall back to a fixed 7x13 face.
+func fontFace(family string, size float64) textFace {
+	fonts.Lock()
+	defer fonts.Unlock()
+	f, ok := fonts.files[family]
+	if !ok {
+		fontBytes, err := ioutil.ReadFile(family)
+		if err == nil {
+			var faces []*gtfont.Face
+			faces, err = gtfont.ParseTTC(bytes.NewReader(fontBytes))
+			if err == nil {
+				f = faces[0]
+			}
+		}
+		if err != nil {
+			log.Println(err)
+		}
+		fonts.files[family] = f
+	}
+	if f == nil {
+		return textFace{Face: basicfont.Face7x13, size: size}
+	}
+	return textFace{shaped: f, size: size}
+}
+
+// textLine is a line of laid out text. The origin is relative to the top
+// left corner of the text block, with y on the baseline.
+type textLine struct {
+	text  string
+	x, y  float64
+	width float64
+}
+
+// textBlock is text broken into lines to fit the box of its element.
+type textBlock struct {
+	source        textSource
+	lines         []textLine
+	width, height float64 // intrinsic size of the text
+}
+
+// textSource holds what a textBlock was laid out from, to skip the layout
+// when nothing changed.
+type textSource struct {
+	text         string
+	family       string
+	size         float64
+	lineHeight   float64
+	align        Align
+	whiteSpace   string
+	textOverflow string
+	direction    string
+	width        float64
+	height       float64
+}
+
+// layoutText breaks the text node n into lines within the box of its
+// parent, following the text-align, line-height, white-space and
+// text-overflow styles of the parent. The node is marked dirty when its
+// lines changed.
+func layoutText(n *Node) {
+	p := n.Parent
+	_, _, w, h := p.content()
+	if p.Style.Height == 0 {
+		h = 0
+	}
+	src := textSource{
+		text:         n.Data,
+		family:       p.Style.FontFamily,
+		size:         p.Style.FontSize,
+		lineHeight:   p.Style.LineHeight,
+		align:        p.Style.TextAlign,
+		whiteSpace:   p.Style.WhiteSpace,
+		textOverflow: p.Style.TextOverflow,
+		direction:    p.Style.Direction,
+		width:        w,
+		height:       h,
+	}
+	if n.text.source == src && n.text.lines != nil {
+		return
+	}
+	n.text = breakText(styleFace(p.Style, 1), src)
+	n.markRepaint()
+}
+
+// breakText lays out the text of src with face.
+func breakText(face textFace, src textSource) textBlock {
+	var lines []string
+	switch src.whiteSpace {
+	case "pre":
+		lines = strings.Split(src.text, "\n")
+	case "pre-wrap":
+		for _, l := range strings.Split(src.text, "\n") {
+			lines = append(lines, wrapLine(face, l, src.width)...)
+		}
+	case "nowrap":
+		lines = []string{collapseSpace(src.text)}
+	default:
+		lines = wrapLine(face, collapseSpace(src.text), src.width)
+	}
+
+	ascent, descent, lineHeight := face.metrics()
+	if src.lineHeight != 0 {
+		lineHeight = src.lineHeight
+	}
+	if src.textOverflow == "ellipsis" {
+		// keep the lines fitting in a box of fixed height
+		if src.height != 0 && len(lines) > 1 && float64(len(lines))*lineHeight > src.height {
+			fit := int(src.height / lineHeight)
+			if fit < 1 {
+				fit = 1
+			}
+			lines = lines[:fit]
+			lines[fit-1] += ellipsis
+		}
+		for i, l := range lines {
+			lines[i] = truncate(face, l, src.width)
+		}
+	}
+
+	b := textBlock{source: src, lines: make([]textLine, len(lines))}
+	for i, l := range lines {
+		w := face.measure(l)
+		x := 0.0
+		switch src.align {
+		case CENTER:
+			x = (src.width - w) / 2
+		case RIGHT:
+			x = src.width - w
+		}
+		b.lines[i] = textLine{
+			text:  l,
+			x:     x,
+			y:     float64(i)*lineHeight + (lineHeight-ascent-descent)/2 + ascent,
+			width: w,
+		}
+		if w > b.width {
+			b.width = w
+		}
+	}
+	b.height = float64(len(lines)) * lineHeight
+	return b
+}
+
+// offset returns the distance from the top of a box of height h to the
+// top of the text block, aligned vertically as in valign.
+func (b *textBlock) offset(valign Align, h float64) float64 {
+	switch valign {
+	case TOP:
+		return 0
+	case BOTTOM:
+		return h - b.height
+	}
+	return (h - b.height) / 2
+}
+
+// inputText returns the face of the value of the input n, the offset of
+// the value from the left of the content box, scrolled to keep the caret in
+// view, and the offsets of the carets of the value from its start.
+func inputText(n *Node) (face textFace, x float64, carets []float64) {
+	face = styleFace(n.Style, 1)
+	s := string(n.Value)
+	carets = face.carets(s)
+	_, _, w, _ := n.content()
+	if face.rtl {
+		x = w - face.measure(s)
+	}
+	switch c := x + carets[n.caret]; {
+	case c > w:
+		x -= c - w
+	case c < 0:
+		x -= c
+	}
+	return
+}
+
+// collapseSpace replaces each sequence of white space in s by a single space.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// wrapLine breaks s into lines no wider than width, between words and
+// around CJK characters. Words wider than width are broken anywhere. s is
+// shaped once, and the width of each part summed from its runes.
+func wrapLine(face textFace, s string, width float64) (lines []string) {
+	runes := []rune(s)
+	// x[i] is the width of the first i runes
+	x := make([]float64, len(runes)+1)
+	for i, a := range face.advances(s) {
+		x[i+1] = x[i] + a
+	}
+	// trim returns the end of the runes from start to end without the
+	// trailing spaces
+	trim := func(start, end int) int {
+		for end > start && runes[end-1] == ' ' {
+			end--
+		}
+		return end
+	}
+	var spans [][2]int
+	start, end := 0, 0
+	for _, seg := range segments(s) {
+		next := end + utf8.RuneCountInString(seg)
+		if end > start && x[trim(start, next)]-x[start] > width {
+			spans = append(spans, [2]int{start, trim(start, end)})
+			start = end
+			for start < next && runes[start] == ' ' {
+				start++
+			}
+		}
+		end = next
+	}
+	spans = append(spans, [2]int{start, trim(start, end)})
+
+	for _, sp := range spans {
+		i, j := sp[0], sp[1]
+		// break the words that do not fit on a line of their own
+		for x[j]-x[i] > width && j-i > 1 {
+			k := i + 1
+			for k < j && x[k+1]-x[i] <= width {
+				k++
+			}
+			lines = append(lines, string(runes[i:k]))
+			i = k
+		}
+		lines = append(lines, string(runes[i:j]))
+	}
+	return lines
+}
+
+// segments splits s into the units a line may break between: words with
+// their trailing spaces and single CJK characters.
+func segments(s string) (segs []string) {
+	start := 0
+	var prev rune
+	for i, r := range s {
+		if i > start && canBreak(prev, r) {
+			segs = append(segs, s[start:i])
+			start = i
+		}
+		prev = r
+	}
+	if start < len(s) {
+		segs = append(segs, s[start:])
+	}
+	return
+}
+
+// canBreak reports whether a line may break between the runes a and b.
+func canBreak(a, b rune) bool {
+	switch {
+	case b == ' ' || noBreakBefore(b):
+		return false
+	case a == ' ':
+		return true
+	}
+	return isCJK(a) || isCJK(b)
+}
+
+// isCJK reports whether lines may break around r like between words.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r >= 0x3000 && r <= 0x303f || r >= 0xff00 && r <= 0xffef
+}
+
+// noBreakBefore reports whether r must not start a line.
+func noBreakBefore(r rune) bool {
+	return strings.ContainsRune("，。、；：！？）」』】》〉,.;:!?)]}", r)
+}
+
+// truncate shortens s with an ellipsis to be no wider than width.
+func truncate(face textFace, s string, width float64) string {
+	if face.measure(s) <= width {
+		return s
+	}
+	s = strings.TrimSuffix(s, ellipsis)
+	for s != "" {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+		if face.measure(s+ellipsis) <= width {
+			break
+		}
+	}
+	return strings.TrimRight(s, " ") + ellipsis
+}
+
+func fixedFloat(x fixed.Int26_6) float64 {
+	return float64(x) / 64
+}
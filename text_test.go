@@ -0,0 +1,85 @@
+package geui
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// monoFace is a face 13 units high in which every character, including
+// those without a glyph, is 7 units wide.
+type monoFace struct {
+	font.Face
+}
+
+func (monoFace) GlyphAdvance(rune) (fixed.Int26_6, bool) {
+	return fixed.I(7), true
+}
+
+var face = textFace{Face: monoFace{basicfont.Face7x13}}
+
+func lineTexts(b textBlock) (lines []string) {
+	for _, l := range b.lines {
+		lines = append(lines, l.text)
+	}
+	return
+}
+
+func TestBreakText(t *testing.T) {
+	tests := []struct {
+		src  textSource
+		want []string
+	}{
+		{textSource{text: "the quick  brown\nfox", width: 70}, []string{"the quick", "brown fox"}},
+		{textSource{text: "unbreakable", width: 35}, []string{"unbre", "akabl", "e"}},
+		{textSource{text: "中文文本，换行", width: 28}, []string{"中文文", "本，换行"}},
+		{textSource{text: "mixed中文", width: 42}, []string{"mixed中", "文"}},
+		{textSource{text: "the quick brown", width: 70, whiteSpace: "nowrap"}, []string{"the quick brown"}},
+		{textSource{text: "a  b\nc", width: 70, whiteSpace: "pre"}, []string{"a  b", "c"}},
+		{textSource{text: "the quick brown", width: 70, textOverflow: "ellipsis", whiteSpace: "nowrap"}, []string{"the quick…"}},
+		{textSource{text: "the quick brown fox", width: 56, height: 30, textOverflow: "ellipsis"}, []string{"the", "quick…"}},
+	}
+	for _, test := range tests {
+		if got := lineTexts(breakText(face, test.src)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("breakText(%q, width %v) = %q, want %q", test.src.text, test.src.width, got, test.want)
+		}
+	}
+}
+
+func TestBreakTextAlign(t *testing.T) {
+	src := textSource{text: "ab", width: 70, lineHeight: 20}
+	for align, want := range map[Align]float64{LEFT: 0, CENTER: 28, RIGHT: 56} {
+		src.align = align
+		b := breakText(face, src)
+		if b.lines[0].x != want {
+			t.Errorf("align %d: x = %v, want %v", align, b.lines[0].x, want)
+		}
+	}
+	b := breakText(face, textSource{text: "one two", width: 21, lineHeight: 20})
+	if b.height != 40 || b.width != 21 {
+		t.Errorf("text size = %vx%v, want 21x40", b.width, b.height)
+	}
+	if got, want := b.lines[1].y-b.lines[0].y, 20.0; got != want {
+		t.Errorf("line distance = %v, want %v", got, want)
+	}
+	if got := b.offset(BOTTOM, 100); got != 60 {
+		t.Errorf("bottom offset = %v, want 60", got)
+	}
+}
+
+func TestLayoutAutoHeight(t *testing.T) {
+	n := LoadXML("testdata/main.xml")
+	label := n.FirstChild
+	label.SetStyle("height:auto; line-height:40px")
+	layoutRoot(n, n.Model.Width, n.Model.Height)
+	if label.Model.Height != 40 {
+		t.Fatalf("label height = %v, want its line height 40", label.Model.Height)
+	}
+	input := label.NextSibling
+	if input.Model.RelativeY != 60 {
+		t.Fatalf("input is at y %v, want 60", input.Model.RelativeY)
+	}
+}
@@ -0,0 +1,100 @@
+package geui
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// A Clock tells the time to the animations and timers of a window.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the clock of the system.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// A ManualClock is a Clock that only moves when advanced, to check
+// animations frame by frame.
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManualClock returns a clock stopped at t.
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{now: t}
+}
+
+// Now returns the time of the clock.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock on by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// A Timer calls a function on the goroutine showing a window, see
+// Window.AfterFunc.
+type Timer struct {
+	w    *Window
+	at   time.Time
+	f    func()
+	wake *time.Timer // wakes the window up at the deadline
+}
+
+// AfterFunc calls f after the duration d on the goroutine running Show,
+// between the events of the window, where f can change the node tree. It
+// may be called from any goroutine.
+func (w *Window) AfterFunc(d time.Duration, f func()) *Timer {
+	t := &Timer{w: w, at: w.clock.Now().Add(d), f: f}
+	w.timerMu.Lock()
+	w.timers = append(w.timers, t)
+	w.timerMu.Unlock()
+	t.wake = time.AfterFunc(d, w.Wake)
+	return t
+}
+
+// Stop cancels the timer. It reports whether it stopped it before its
+// function was called.
+func (t *Timer) Stop() bool {
+	w := t.w
+	w.timerMu.Lock()
+	defer w.timerMu.Unlock()
+	for i, o := range w.timers {
+		if o == t {
+			w.timers = append(w.timers[:i:i], w.timers[i+1:]...)
+			t.wake.Stop()
+			return true
+		}
+	}
+	return false
+}
+
+// runTimers calls the functions of the timers due at now, in the order of
+// their deadlines.
+func (w *Window) runTimers(now time.Time) {
+	w.timerMu.Lock()
+	var due, pending []*Timer
+	for _, t := range w.timers {
+		if t.at.After(now) {
+			pending = append(pending, t)
+		} else {
+			due = append(due, t)
+		}
+	}
+	w.timers = pending
+	w.timerMu.Unlock()
+	sort.SliceStable(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+	for _, t := range due {
+		t.f()
+	}
+}
@@ -0,0 +1,92 @@
+package geui
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	defaultTooltipDelay = 500 * time.Millisecond
+	maxTooltip          = 300 // widest tooltip, longer text wraps
+	tooltipOffset       = 20  // distance of a tooltip below the pointer
+)
+
+// TooltipDelay option sets how long the pointer rests on an element before
+// its tooltip shows.
+func TooltipDelay(d time.Duration) WindowOption {
+	return func(o *windowOptions) {
+		o.tooltipDelay = d
+	}
+}
+
+// tooltip is the tooltip of the element the pointer is over.
+type tooltip struct {
+	target *Node  // element the pointer is over, with a tooltip
+	timer  *Timer // shows the tooltip after the delay, or nil
+	node   *Node  // shown tooltip, or nil
+}
+
+// tooltipText returns the text of the tooltip of n: its tooltip attribute,
+// or its title attribute unless the title is the label of n, like the
+// title of a <tab>.
+func (n *Node) tooltipText() (string, bool) {
+	if s, ok := n.Attrs["tooltip"]; ok {
+		return s, true
+	}
+	switch n.Data {
+	case "tab", "section", "menu":
+		return "", false
+	}
+	s, ok := n.Attrs["title"]
+	return s, ok
+}
+
+// hoverTooltip follows the element with a tooltip under the pointer. When
+// the pointer enters another one, the shown tooltip hides and the tooltip
+// of the new element shows after the delay.
+func (w *Window) hoverTooltip() {
+	var target *Node
+	for n := w.nodeAt(w.mouseX, w.mouseY); n != nil; n = n.Parent {
+		if _, ok := n.tooltipText(); ok {
+			target = n
+			break
+		}
+	}
+	if target == w.tip.target {
+		return
+	}
+	w.hideTooltip()
+	w.tip.target = target
+	if target != nil {
+		w.tip.timer = w.AfterFunc(w.tooltipDelay, w.showTooltip)
+	}
+}
+
+// showTooltip shows the tooltip of the element under the pointer in an
+// overlay below the pointer, or above it when there is no room.
+func (w *Window) showTooltip() {
+	w.tip.timer = nil
+	s, _ := w.tip.target.tooltipText()
+	if s == "" {
+		return
+	}
+	n := &Node{Type: ElementNode, Data: "tooltip", Style: newElementStyle("tooltip"), Model: new(Model)}
+	AddChild(n, &Node{Type: CharDataNode, Data: s, Model: new(Model)})
+	n.Style.Width = math.Min(maxTooltip, math.Ceil(n.preferredWidth()))
+	at := &Node{Type: ElementNode, Model: &Model{RelativeX: w.mouseX, RelativeY: w.mouseY, Height: tooltipOffset}}
+	w.tip.node = n
+	w.openOverlay(n, at)
+}
+
+// hideTooltip hides the shown tooltip, or cancels the one about to show.
+// It shows again when the pointer enters another element.
+func (w *Window) hideTooltip() {
+	if t := w.tip.timer; t != nil {
+		t.Stop()
+		w.tip.timer = nil
+	}
+	if n := w.tip.node; n != nil {
+		w.closeOverlay(n)
+		w.tip.node = nil
+	}
+}
@@ -0,0 +1,86 @@
+package geui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimers(t *testing.T) {
+	w, _ := newTestWindow(t)
+	var got []int
+	w.AfterFunc(20*time.Millisecond, func() { got = append(got, 2) })
+	w.AfterFunc(10*time.Millisecond, func() { got = append(got, 1) })
+	stopped := w.AfterFunc(15*time.Millisecond, func() { got = append(got, 3) })
+	later := w.AfterFunc(time.Hour, func() { got = append(got, 4) })
+	if !stopped.Stop() {
+		t.Error("Stop of a pending timer returned false")
+	}
+	w.runTimers(time.Now())
+	if len(got) != 0 {
+		t.Fatalf("timers ran early: %v", got)
+	}
+	w.runTimers(time.Now().Add(time.Second))
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("timers ran %v, want 1 then 2", got)
+	}
+	if !later.Stop() || later.Stop() {
+		t.Error("Stop did not cancel the timer once")
+	}
+}
+
+func TestTooltip(t *testing.T) {
+	root := loadTestXML(t, `<window style="width: 300px; height: 300px; hover-color: #ffffff">
+	<box id="tip" tooltip="Saves the file" style="height: 50px; margin: 0"></box>
+	<box id="titled" title="Opens a file" style="height: 50px; margin: 0"></box>
+	<tabs><tab title="Tab"></tab></tabs>
+</window>`)
+	w, err := NewWindow(root, Size(300, 300), WithBackend(NewHeadless()), TooltipDelay(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.repaint()
+	w.dispatch(MouseMove{X: 100, Y: 20})
+	w.runTimers(time.Now())
+	if len(w.renderer.overlays) != 0 {
+		t.Fatal("tooltip shown before the delay")
+	}
+	w.runTimers(time.Now().Add(2 * time.Second))
+	w.repaint()
+	tip := w.tip.node
+	if tip == nil || len(w.renderer.overlays) != 1 || tip.FirstChild.Data != "Saves the file" {
+		t.Fatal("tooltip not shown after the delay")
+	}
+	if m := tip.Model; m.RelativeX != 100 || m.RelativeY != 20+tooltipOffset || m.Width > maxTooltip {
+		t.Errorf("tooltip at %+v, want below the pointer", *m)
+	}
+	// the tooltip is not hit by the pointer
+	w.dispatch(MouseMove{X: 110, Y: 45})
+	if w.tip.node != tip {
+		t.Fatal("tooltip hidden while the pointer is over its element")
+	}
+
+	// moving out hides it, the title of another element shows
+	w.dispatch(MouseMove{X: 100, Y: 70})
+	if w.tip.node != nil || len(w.renderer.overlays) != 0 {
+		t.Fatal("tooltip not hidden on move-out")
+	}
+	w.runTimers(time.Now().Add(2 * time.Second))
+	if w.tip.node == nil || w.tip.node.FirstChild.Data != "Opens a file" {
+		t.Fatal("title attribute not shown as a tooltip")
+	}
+	// a click hides it until the pointer enters another element
+	w.dispatch(MouseDown{MouseButton: MouseLeft})
+	w.dispatch(MouseMove{X: 101, Y: 71})
+	w.runTimers(time.Now().Add(2 * time.Second))
+	if w.tip.node != nil {
+		t.Fatal("tooltip not hidden by a click")
+	}
+
+	// the title of a tab is its label
+	bar := root.GetNodeByID("titled").NextSibling.tabs().bar.Model
+	w.dispatch(MouseMove{X: 100, Y: bar.RelativeY + 5})
+	w.dispatch(MouseMove{X: 100, Y: bar.RelativeY + bar.Height + 10})
+	if w.tip.target != nil {
+		t.Errorf("tooltip target %v, want none", w.tip.target.Data)
+	}
+}
@@ -0,0 +1,295 @@
+package geui
+
+import (
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/gorilla/css/scanner"
+)
+
+// A Transform is a function of the transform property, like
+// "rotate(45deg)", with its arguments as written.
+type Transform struct {
+	Function string // translate, translateX, translateY, scale, scaleX, scaleY or rotate
+	Args     []string
+}
+
+// parseTransforms parses a transform declaration, like
+// "translate(10px, 50%) rotate(.25turn)". None is no transform.
+func parseTransforms(values []string) (ts []Transform) {
+	for _, v := range values {
+		i := strings.IndexByte(v, '(')
+		if i < 0 || !strings.HasSuffix(v, ")") {
+			continue
+		}
+		t := Transform{Function: v[:i]}
+		for _, a := range strings.FieldsFunc(v[i+1:len(v)-1], func(r rune) bool { return r == ',' || r == ' ' }) {
+			t.Args = append(t.Args, a)
+		}
+		if len(t.Args) > 0 {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+// parseTransformText parses the text of a transform declaration, like an
+// animated value.
+func parseTransformText(v string) []Transform {
+	return parseTransforms(styleValues(scanner.New(v)))
+}
+
+// formatTransforms returns the text of the transform declaration ts.
+func formatTransforms(ts []Transform) string {
+	var fs []string
+	for _, t := range ts {
+		fs = append(fs, t.Function+"("+strings.Join(t.Args, ", ")+")")
+	}
+	return strings.Join(fs, " ")
+}
+
+// interpolateTransforms returns the transform declaration at t, from 0 to
+// 1, between a and b: the arguments of their functions are interpolated
+// in turn, the functions missing from one of them being the identity.
+// When the functions differ, a is kept until half way, then b.
+func interpolateTransforms(a, b string, t float64) string {
+	x, y := parseTransformText(a), parseTransformText(b)
+	for len(x) < len(y) {
+		x = append(x, identityTransform(y[len(x)]))
+	}
+	for len(y) < len(x) {
+		y = append(y, identityTransform(x[len(y)]))
+	}
+	ts := make([]Transform, len(x))
+	for i := range x {
+		f, ok := lerpTransform(x[i], y[i], t)
+		if !ok {
+			if t < 0.5 {
+				return a
+			}
+			return b
+		}
+		ts[i] = f
+	}
+	return formatTransforms(ts)
+}
+
+// identityTransform returns the function of f that transforms nothing.
+func identityTransform(f Transform) Transform {
+	v := "0"
+	if strings.HasPrefix(f.Function, "scale") {
+		v = "1"
+	}
+	return Transform{Function: f.Function, Args: []string{v}}
+}
+
+// lerpTransform interpolates the arguments of the functions a and b at t.
+// It reports false when they are different functions, or have lengths
+// in different units.
+func lerpTransform(a, b Transform, t float64) (Transform, bool) {
+	if a.Function != b.Function {
+		return Transform{}, false
+	}
+	f := Transform{Function: a.Function}
+	for i := 0; i < len(a.Args) || i < len(b.Args); i++ {
+		u, v := transformArg(a, i), transformArg(b, i)
+		if f.Function == "rotate" {
+			x, y := parseAngle(u), parseAngle(v)
+			f.Args = append(f.Args, formatLength(x+(y-x)*t)+"rad")
+			continue
+		}
+		x, xu := splitUnit(u)
+		y, yu := splitUnit(v)
+		// a bare 0 is in any unit
+		switch {
+		case x == 0 && xu == "":
+			xu = yu
+		case y == 0 && yu == "":
+			yu = xu
+		}
+		if xu != yu {
+			return Transform{}, false
+		}
+		f.Args = append(f.Args, formatLength(x+(y-x)*t)+xu)
+	}
+	return f, true
+}
+
+// transformArg returns the argument i of the function f. The y of a scale
+// is its x when missing, of a translate 0.
+func transformArg(f Transform, i int) string {
+	switch {
+	case i < len(f.Args):
+		return f.Args[i]
+	case strings.HasPrefix(f.Function, "scale"):
+		return f.Args[0]
+	}
+	return "0"
+}
+
+// splitUnit splits a length, like "-50%", into its number and unit.
+func splitUnit(v string) (float64, string) {
+	i := len(v) - len(strings.TrimLeft(v, "+-.0123456789"))
+	f, _ := strconv.ParseFloat(v[:i], 64)
+	return f, v[i:]
+}
+
+// transforms returns the transforms of the element, as animated or else
+// as in its style.
+func (n *Node) transforms() []Transform {
+	if v, ok := n.animated("transform"); ok {
+		return parseTransformText(v)
+	}
+	return n.Style.Transform
+}
+
+// parseAngle parses an angle, like "45deg", ".5turn" or "1rad", in
+// radians.
+func parseAngle(v string) float64 {
+	for _, u := range []struct {
+		unit string
+		k    float64
+	}{{"deg", math.Pi / 180}, {"grad", math.Pi / 200}, {"rad", 1}, {"turn", 2 * math.Pi}} {
+		if strings.HasSuffix(v, u.unit) {
+			f, _ := strconv.ParseFloat(strings.TrimSuffix(v, u.unit), 64)
+			return f * u.k
+		}
+	}
+	f, _ := strconv.ParseFloat(v, 64)
+	return f
+}
+
+// transformer is what transforms are applied to: the canvas when
+// painting, or a matrix for hit-testing.
+type transformer interface {
+	Translate(x, y float64)
+	Scale(x, y float64)
+	Rotate(angle float64)
+}
+
+// matrix is a transformer building a gg.Matrix.
+type matrix struct {
+	gg.Matrix
+}
+
+func (m *matrix) Translate(x, y float64) { m.Matrix = m.Matrix.Translate(x, y) }
+func (m *matrix) Scale(x, y float64)     { m.Matrix = m.Matrix.Scale(x, y) }
+func (m *matrix) Rotate(angle float64)   { m.Matrix = m.Matrix.Rotate(angle) }
+
+// transformed reports whether the element or one of its ancestors has a
+// transform.
+func (n *Node) transformed() bool {
+	for ; n != nil; n = n.Parent {
+		if n.Type == ElementNode && len(n.transforms()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// transform applies the transforms of the ancestors of the element, then
+// its own, to t, each about its transform-origin. It reports whether
+// there was any.
+func (n *Node) transform(t transformer) bool {
+	if n == nil {
+		return false
+	}
+	applied := n.Parent.transform(t)
+	if n.Type != ElementNode {
+		return applied
+	}
+	ts := n.transforms()
+	if len(ts) == 0 {
+		return applied
+	}
+	w, h := n.Model.Width, n.Model.Height
+	px, py := positionValues(n.Style.TransformOrigin)
+	ox := n.Model.RelativeX + positionOffset(px, w, 0, 1)
+	oy := n.Model.RelativeY + positionOffset(py, h, 0, 1)
+	t.Translate(ox, oy)
+	for _, f := range ts {
+		a := f.Args
+		length := func(i int, area float64) float64 {
+			if i >= len(a) {
+				return 0
+			}
+			if isPercentage(a[i]) {
+				return area * parseLength(strings.TrimSuffix(a[i], "%")) / 100
+			}
+			return parseLength(a[i])
+		}
+		factor := func(i int) float64 {
+			if i >= len(a) {
+				return parseLength(a[0])
+			}
+			return parseLength(a[i])
+		}
+		switch f.Function {
+		case "translate":
+			t.Translate(length(0, w), length(1, h))
+		case "translateX":
+			t.Translate(length(0, w), 0)
+		case "translateY":
+			t.Translate(0, length(0, h))
+		case "scale":
+			t.Scale(factor(0), factor(1))
+		case "scaleX":
+			t.Scale(factor(0), 1)
+		case "scaleY":
+			t.Scale(1, factor(0))
+		case "rotate":
+			t.Rotate(parseAngle(a[0]))
+		}
+	}
+	t.Translate(-ox, -oy)
+	return true
+}
+
+// matrix returns the matrix mapping the element to the canvas, with the
+// transforms of its ancestors, and whether it has any transform.
+func (n *Node) matrix() (gg.Matrix, bool) {
+	m := &matrix{gg.Identity()}
+	ok := n.transform(m)
+	return m.Matrix, ok
+}
+
+// local maps the point x, y of the canvas into the element, before its
+// transforms. A transform scaling to 0 maps every point away.
+func (n *Node) local(x, y float64) (float64, float64) {
+	m, ok := n.matrix()
+	if !ok {
+		return x, y
+	}
+	det := m.XX*m.YY - m.XY*m.YX
+	if det == 0 {
+		return math.Inf(-1), math.Inf(-1)
+	}
+	x, y = x-m.X0, y-m.Y0
+	return (m.YY*x - m.XY*y) / det, (m.XX*y - m.YX*x) / det
+}
+
+// transformRect returns the box around the region r of the element once
+// transformed on the canvas.
+func (n *Node) transformRect(r image.Rectangle) image.Rectangle {
+	m, ok := n.matrix()
+	if !ok || r.Empty() {
+		return r
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range []image.Point{r.Min, {r.Max.X, r.Min.Y}, r.Max, {r.Min.X, r.Max.Y}} {
+		x, y := m.TransformPoint(float64(p.X), float64(p.Y))
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
+
+// canvasBounds returns the box around the element once transformed on the
+// canvas.
+func (n *Node) canvasBounds() image.Rectangle {
+	return n.transformRect(n.Bounds())
+}
@@ -0,0 +1,118 @@
+package geui
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseTransform(t *testing.T) {
+	n := element("box", "transform: translate(10px, -50%) rotate(.25turn) scaleX(2); transform-origin: left top")
+	tr := n.Style.Transform
+	if len(tr) != 3 || tr[0].Function != "translate" || len(tr[0].Args) != 2 || tr[0].Args[1] != "-50%" ||
+		tr[1].Function != "rotate" || tr[2].Function != "scaleX" || tr[2].Args[0] != "2" {
+		t.Errorf("transforms = %+v", tr)
+	}
+	if n.Style.TransformOrigin != "left top" {
+		t.Errorf("origin = %q", n.Style.TransformOrigin)
+	}
+	if a := parseAngle(tr[1].Args[0]); math.Abs(a-math.Pi/2) > 1e-9 {
+		t.Errorf("angle = %v, want π/2", a)
+	}
+	if n.SetStyle("transform: none"); n.Style.Transform != nil {
+		t.Errorf("none left %+v", n.Style.Transform)
+	}
+}
+
+func TestInterpolateTransforms(t *testing.T) {
+	for _, c := range []struct{ a, b, want string }{
+		{"translate(10px, -50%)", "translate(30px, 50%)", "translate(15px, -25%)"},
+		{"", "scale(3) translateX(40px)", "scale(1.5) translateX(10px)"},
+		{"rotate(0)", "rotate(.5turn)", "rotate(" + formatLength(math.Pi/4) + "rad)"},
+		{"translateX(10px)", "translateX(50%)", "translateX(10px)"},
+		{"scale(2)", "rotate(1rad)", "scale(2)"},
+	} {
+		if got := interpolateTransforms(c.a, c.b, 0.25); got != c.want {
+			t.Errorf("%q to %q a quarter of the way = %q, want %q", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	root := loadTestXML(t, `<window style="width: 300px; height: 300px; background-color: #ffffff">
+	<button id="b" style="margin: 100px 50px; width: 200px; height: 40px; background-color: #ff0000; hover-color: #ff0000; transform: rotate(90deg)">
+		<box id="c" style="margin: 0; width: 20px; height: 20px"></box>
+	</button>
+</window>`)
+	w, err := NewWindow(root, Size(300, 300), WithBackend(NewHeadless()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.repaint()
+	b := root.GetNodeByID("b")
+	// the button turns about its centre, at 150, 120
+	if n := w.node.GetActiveNode(60, 120); n == b {
+		t.Error("hit the button where it was before turning")
+	}
+	if n := w.node.GetActiveNode(150, 200); n != b {
+		t.Errorf("hit %v below the centre, want the turned button", n)
+	}
+	// its child turns with it, from the top left corner to the top right
+	if n := w.node.GetActiveNode(160, 30); n != root.GetNodeByID("c") {
+		t.Errorf("hit %v, want the child of the button", n)
+	}
+	img := w.renderer.Image()
+	if c := img.RGBAAt(150, 200); c.R != 0xff || c.G != 0 {
+		t.Errorf("pixel below the centre = %v, want the button", c)
+	}
+	if c := img.RGBAAt(60, 120); c.G != 0xff {
+		t.Errorf("pixel left of the centre = %v, want the window", c)
+	}
+	if r := b.area(); r.Min.Y > 20 || r.Max.Y < 220 || r.Min.X > 130 || r.Max.X < 170 {
+		t.Errorf("area = %v, want around the turned button", r)
+	}
+
+	// scaled to nothing, it is not hit
+	b.SetStyle("transform: scale(0)")
+	if n := w.node.GetActiveNode(150, 120); n == b {
+		t.Error("hit the button scaled to nothing")
+	}
+	b.SetStyle("transform: translate(-50%, 10px) scale(.5); transform-origin: 0 0")
+	if n := w.node.GetActiveNode(0, 115); n != b {
+		t.Errorf("hit %v, want the moved button", n)
+	}
+	if n := w.node.GetActiveNode(110, 115); n == b {
+		t.Error("hit the button beyond its scaled width")
+	}
+}
+
+func TestTransformHandles(t *testing.T) {
+	// the handles of dividers, column borders and scrollbars move with
+	// the transform
+	w := newXMLWindow(t, `<window style="width: 400px; height: 300px; hover-color: #ffffff">
+	<box style="margin: 0; height: 250px; transform: translateX(100px)">
+		<splitter id="split" style="margin: 0; width: 200px; height: 50px"><box style="margin: 0"/><box style="margin: 0"/></splitter>
+		<table id="people" style="margin: 0; width: 200px"><tr><th id="name" style="width: 100px">Name</th><th>Age</th></tr></table>
+		<box id="scroll" style="margin: 0; width: 200px; height: 50px; overflow: scroll"></box>
+	</box>
+</window>`)
+	root := w.node
+	split := root.GetNodeByID("split")
+	dx, dy, dw, dh := split.divider()
+	if root.dividerAt(dx+dw/2+100, dy+dh/2) != split || root.dividerAt(dx+dw/2, dy+dh/2) != nil {
+		t.Error("divider not found where it is painted")
+	}
+	m := root.GetNodeByID("name").Model
+	if _, col, ok := root.columnBorderAt(m.RelativeX+m.Width+100, m.RelativeY+5); !ok || col != 0 {
+		t.Error("column border not found where it is painted")
+	}
+	if _, _, ok := root.columnBorderAt(m.RelativeX+m.Width, m.RelativeY+5); ok {
+		t.Error("column border found where it was before the transform")
+	}
+	b := root.GetNodeByID("scroll").scrollbars()[0]
+	if n, _, ok := root.scrollbarAt(b.x+b.w/2+100, b.y+b.h/2); !ok || n.ID != "scroll" {
+		t.Error("scrollbar not found where it is painted")
+	}
+	if _, _, ok := root.scrollbarAt(b.x+b.w/2, b.y+b.h/2); ok {
+		t.Error("scrollbar found where it was before the transform")
+	}
+}
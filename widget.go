@@ -0,0 +1,92 @@
+package geui
+
+// A widget is the state of an element that handles the input of the user
+// itself, like the options of a <select> or the rows of a <list>. The
+// element gets the clicks on it and its descendants, and the keys while
+// it or one of its descendants has the focus.
+type widget interface {
+	// click handles a click on the node hit in the element n, and returns
+	// the node taking the focus, or nil when the click is not for n.
+	click(w *Window, n, hit *Node) *Node
+	// key handles the key k for the element n and reports whether it was
+	// used.
+	key(w *Window, n *Node, k Key) bool
+}
+
+// A presser is a widget that handles the pointer pressed on it.
+type presser interface {
+	// press handles the pointer pressed at x, y on the element n.
+	press(w *Window, n *Node, x, y float64)
+}
+
+// A typer is a widget that handles the text typed in it.
+type typer interface {
+	// typeRune handles the rune r typed in the focused element n and
+	// reports whether it was used, or is to be inserted in its value.
+	typeRune(w *Window, n *Node, r rune) bool
+}
+
+// A dragger is the state of an element that captures the pointer while
+// it is pressed on it, like a <slider>.
+type dragger interface {
+	// drag handles the pointer moved to x, y.
+	drag(w *Window, n *Node, x, y float64)
+	// release handles the pointer released.
+	release(w *Window, n *Node)
+}
+
+// dragger returns the state of the element n capturing the pointer, or
+// nil.
+func (n *Node) dragger() dragger {
+	switch {
+	case n.splitterState != nil:
+		return n.splitterState
+	case n.rangeState != nil:
+		return n.rangeState
+	}
+	return nil
+}
+
+// widget returns the state of the element n when it handles the input of
+// the user, or nil.
+func (n *Node) widget() widget {
+	switch {
+	case n.selectState != nil:
+		return n.selectState
+	case n.rangeState != nil:
+		return n.rangeState
+	case n.listState != nil:
+		return n.listState
+	case n.tableState != nil:
+		return n.tableState
+	case n.tabsState != nil:
+		return n.tabsState
+	case n.accordionState != nil:
+		return n.accordionState
+	}
+	return nil
+}
+
+// click passes a click on the node hit to the widgets it is in, from the
+// innermost, until one uses it, and returns the node taking the focus.
+func (w *Window) click(hit *Node) *Node {
+	for n := hit; n != nil; n = n.Parent {
+		if s := n.widget(); s != nil {
+			if f := s.click(w, n, hit); f != nil {
+				return f
+			}
+		}
+	}
+	return hit
+}
+
+// widgetKey passes the key k to the widgets the focused node is in, from
+// the innermost, until one uses it. It reports whether one did.
+func (w *Window) widgetKey(k Key) bool {
+	for n := w.active; n != nil; n = n.Parent {
+		if s := n.widget(); s != nil && s.key(w, n, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,346 +1,578 @@
 package geui
 
 import (
-	"github.com/fogleman/gg"
-	"github.com/golang/freetype/truetype"
+	"errors"
 	"image"
-	"io/ioutil"
-	"log"
-	"runtime"
+	"io/fs"
+	"math"
+	"sync"
 	"time"
-	"unsafe"
-
-	"github.com/go-gl/gl/v2.1/gl"
-	"github.com/go-gl/glfw/v3.2/glfw"
 )
 
-func init() {
-	runtime.LockOSThread()
-}
-
 type WindowOption func(*windowOptions)
 
 type windowOptions struct {
-	title         string
-	width, height float64
-	resizable     bool
-	borderless    bool
-	maximized     bool
+	Surface
+	backend      Backend
+	fsys         fs.FS
+	tooltipDelay time.Duration
+	clock        Clock
+	hotReload    bool
 }
 
 func Title(title string) WindowOption {
 	return func(o *windowOptions) {
-		o.title = title
+		o.Title = title
 	}
 }
 
 // Size option sets the width and height of the window.
 func Size(width, height float64) WindowOption {
 	return func(o *windowOptions) {
-		o.width = width
-		o.height = height
+		o.Width = width
+		o.Height = height
 	}
 }
 
 // Resizable option makes the window resizable by the user.
 func Resizable() WindowOption {
 	return func(o *windowOptions) {
-		o.resizable = true
+		o.Resizable = true
 	}
 }
 
 // Borderless option makes the window borderless.
 func Borderless() WindowOption {
 	return func(o *windowOptions) {
-		o.borderless = true
+		o.Borderless = true
 	}
 }
 
 // Maximized option makes the window start maximized.
 func Maximized() WindowOption {
 	return func(o *windowOptions) {
-		o.maximized = true
+		o.Maximized = true
+	}
+}
+
+// WithBackend option shows the window through the given backend instead
+// of GLFW. Builds with the nogl tag, which leave out GLFW and OpenGL, need
+// this option.
+func WithBackend(b Backend) WindowOption {
+	return func(o *windowOptions) {
+		o.backend = b
+	}
+}
+
+// WithClock option makes the animations and timers of the window follow
+// the clock c, like a ManualClock in tests.
+func WithClock(c Clock) WindowOption {
+	return func(o *windowOptions) {
+		o.clock = c
+	}
+}
+
+// WithFS option loads the images of the window, like the src of <image>
+// elements, and the style sheets linked with <style src> from fsys
+// instead of the working directory, e.g. an embed.FS.
+func WithFS(fsys fs.FS) WindowOption {
+	return func(o *windowOptions) {
+		o.fsys = fsys
 	}
 }
 
 func NewWindow(n *Node, options ...WindowOption) (*Window, error) {
 	o := windowOptions{
-		title:      "",
-		width:      640,
-		height:     480,
-		resizable:  false,
-		borderless: false,
-		maximized:  false,
+		Surface: Surface{
+			Title:      "",
+			Width:      640,
+			Height:     480,
+			Resizable:  false,
+			Borderless: false,
+			Maximized:  false,
+		},
+		tooltipDelay: defaultTooltipDelay,
+		clock:        systemClock{},
 	}
 	for _, opt := range options {
 		opt(&o)
 	}
-
-	w := &Window{
-		events:      make(chan Event, 16),
-		node:        n,
-		newSize:     make(chan image.Rectangle),
-		loadedFonts: make(map[string]*truetype.Font),
+	if o.backend == nil {
+		o.backend = defaultBackend()
 	}
-
-	var err error
-	w.ctx, err = initGLFW(&o)
-	if err != nil {
-		panic(err)
+	if o.backend == nil {
+		return nil, errors.New("geui: no backend, use the WithBackend option")
 	}
 
-	w.canvas = gg.NewContext(int(o.width), int(o.height))
-
-	w.initEvent()
-	return w, nil
-}
-
-func initGLFW(o *windowOptions) (*glfw.Window, error) {
-	err := glfw.Init()
-	if err != nil {
-		return nil, err
-	}
-	glfw.WindowHint(glfw.DoubleBuffer, glfw.False)
-	if o.resizable {
-		glfw.WindowHint(glfw.Resizable, glfw.True)
-	} else {
-		glfw.WindowHint(glfw.Resizable, glfw.False)
+	if o.fsys != nil || n.imageStore == nil {
+		n.imageStore = newImageStore(o.fsys)
 	}
-	if o.borderless {
-		glfw.WindowHint(glfw.Decorated, glfw.False)
+	if o.fsys != nil {
+		// the linked style sheets are in fsys too
+		n.loadStyleSheets()
 	}
-	if o.maximized {
-		glfw.WindowHint(glfw.Maximized, glfw.True)
+	w := &Window{
+		backend:  o.backend,
+		node:     n,
+		renderer: newRenderer(n, o.Width, o.Height),
+
+		accelerators: map[Shortcut]func(){},
+		tooltipDelay: o.tooltipDelay,
+		clock:        o.clock,
 	}
-	w, err := glfw.CreateWindow(int(o.width), int(o.height), o.title, nil, nil)
-	if err != nil {
+	if err := w.backend.Create(o.Surface, w.dispatch); err != nil {
 		return nil, err
 	}
-	if o.maximized {
-		w, h := w.GetFramebufferSize()
-		o.width, o.height = float64(w), float64(h)
+	n.window = w
+	w.resize()
+	if o.hotReload && n.source != "" {
+		w.watch()
 	}
 	return w, nil
 }
 
 type Window struct {
-	ctx            *glfw.Window
-	events         chan Event
-	canvas         *gg.Context
+	backend        Backend
+	renderer       *Renderer
 	node           *Node
-	newSize        chan image.Rectangle
-	loadedFonts    map[string]*truetype.Font
 	mouseX, mouseY float64
 	active         *Node
+	drag           *scrollDrag         // scrollbar thumb dragged with the pointer
+	resizing       *columnDrag         // table column border dragged with the pointer
+	popup          *Node               // <select> or <combobox> whose popup is open
+	accelerators   map[Shortcut]func() // functions bound to key chords
+	menu           *Node               // <menu> whose popup is open
+	capture        *Node               // element getting the pointer until released
+	ticking        bool                // a frame of animations is scheduled
+	tip            tooltip
+	tooltipDelay   time.Duration
+	clock          Clock
+	timers         []*Timer // pending functions of AfterFunc
+	reload         hotReload
+	timerMu        sync.Mutex
+	cursor         Cursor
+	damage         image.Rectangle // region to repaint besides the dirty nodes
+	frames         int
 }
 
-func (w *Window) initEvent() {
-	var mx, my float64
+// resize adapts the canvas to the size and content scale of the surface.
+func (w *Window) resize() {
+	width, height := w.backend.Size()
+	w.renderer.resize(width, height, w.backend.ContentScale())
+	w.invalidate()
+}
 
-	w.ctx.SetCursorPosCallback(func(_ *glfw.Window, x, y float64) {
-		w.mouseX, w.mouseY = x, y
-		go func() {
-			w.events <- MouseMove{
-				X: mx,
-				Y: my,
+// dispatch applies an event to the window state. Nodes whose appearance
+// changes are marked dirty and get repainted on the next frame.
+func (w *Window) dispatch(e Event) {
+	switch e := e.(type) {
+	case MouseMove:
+		w.mouseX, w.mouseY = e.X, e.Y
+		if w.drag != nil {
+			w.dragScrollbar(e.X, e.Y)
+			break
+		}
+		if w.resizing != nil {
+			w.resizeColumn(e.X, e.Y)
+			break
+		}
+		if n := w.capture; n != nil {
+			n.dragger().drag(w, n, e.X, e.Y)
+			break
+		}
+		w.updateHover()
+		w.hoverMenubar()
+		w.hoverTooltip()
+	case MouseDown:
+		w.hideTooltip()
+		if e.MouseButton == MouseRight {
+			w.contextMenu(w.mouseX, w.mouseY)
+		}
+		if e.MouseButton == MouseLeft {
+			if w.pressScrollbar(w.mouseX, w.mouseY) || w.pressColumnBorder(w.mouseX, w.mouseY) ||
+				w.pressDivider(w.mouseX, w.mouseY) {
+				break
 			}
-		}()
-	})
-
-	w.ctx.SetMouseButtonCallback(func(_ *glfw.Window, button glfw.MouseButton, action glfw.Action, mod glfw.ModifierKey) {
-		switch action {
-		case glfw.Press:
-			go func() {
-				w.events <- MouseDown{
-					X:           mx,
-					Y:           my,
-					MouseButton: button,
-				}
-			}()
-		case glfw.Release:
-			// set active node
-			w.active = w.node.GetActiveNode(w.mouseX, w.mouseY)
-			go func() {
-				w.events <- MouseUp{
-					X:           mx,
-					Y:           my,
-					MouseButton: button,
+			if n := w.nodeAt(w.mouseX, w.mouseY); n != nil {
+				if p, ok := n.widget().(presser); ok {
+					p.press(w, n, w.mouseX, w.mouseY)
 				}
-			}()
-		}
-	})
-
-	w.ctx.SetScrollCallback(func(_ *glfw.Window, xoff, yoff float64) {
-		go func() {
-			w.events <- MouseScroll{
-				X: xoff,
-				Y: yoff,
 			}
-		}()
-	})
-
-	w.ctx.SetCharCallback(func(_ *glfw.Window, r rune) {
-		if w.active != nil {
-			w.active.Value = append(w.active.Value, r)
 		}
-		go func() {
-			w.events <- KbType{
-				r,
+	case MouseUp:
+		if e.MouseButton != MouseLeft {
+			break
+		}
+		if w.drag != nil || w.resizing != nil {
+			w.drag, w.resizing = nil, nil
+			break
+		}
+		if n := w.capture; n != nil {
+			w.capture = nil
+			n.dragger().release(w, n)
+			break
+		}
+		n := w.nodeAt(w.mouseX, w.mouseY)
+		if m := w.menu; m != nil {
+			// a click activates an item or closes the menu
+			if i := w.menuItem(n); i >= 0 {
+				w.activate(m.menu().items[i])
+			} else if n == nil || n.root() != m.menu().popup {
+				w.closeMenu()
 			}
-		}()
-	})
-
-	w.ctx.SetKeyCallback(func(_ *glfw.Window, key glfw.Key, _ int, action glfw.Action, _ glfw.ModifierKey) {
-		switch action {
-		case glfw.Press:
-			go func() { w.events <- KbDown{key} }()
-		case glfw.Release:
-			if w.active != nil && key == glfw.KeyBackspace {
-				if len(w.active.Value) != 0 {
-					w.active.Value = w.active.Value[:len(w.active.Value)-1]
+			return
+		}
+		if n != nil && n.inMenubar() {
+			w.openMenu(n, n)
+			return
+		}
+		if owner := w.popup; owner != nil {
+			switch i := w.popupOption(n); {
+			case i >= 0:
+				// a click picks an option or closes the popup
+				w.pick(owner, i)
+				w.focus(owner)
+				w.clicked(n)
+				return
+			case n != nil && n.root() == owner.choice().popup:
+				// on the popup, off the options
+				return
+			default:
+				w.closePopup()
+				if n == owner {
+					w.focus(n)
+					return
 				}
 			}
-			go func() { w.events <- KbUp{key} }()
-		case glfw.Repeat:
-			go func() { w.events <- KbRepeat{key} }()
 		}
-	})
-
-	w.ctx.SetFramebufferSizeCallback(func(_ *glfw.Window, width, height int) {
-		go func() {
-			w.newSize <- image.Rect(0, 0, width, height)
-			w.events <- Resize{
-				0, 0,
-				float64(width), float64(height),
+		w.focus(w.click(n))
+		w.clicked(n)
+	case MouseScroll:
+		w.scroll(e.X, e.Y)
+	case KbType:
+		if n := w.active; n != nil {
+			if t, ok := n.widget().(typer); ok && t.typeRune(w, n, e.rune) {
+				break
 			}
-		}()
-	})
+		}
+		w.insert([]rune{e.rune})
+	case KbDown:
+		if w.accelerate(Shortcut{e.Key, e.Modifier}) {
+			break
+		}
+		if e.Key == KeyV && e.Modifier == ModControl {
+			w.insert([]rune(w.Clipboard()))
+		}
+		w.keyDown(e.Key)
+	case KbRepeat:
+		w.keyDown(e.Key)
+	case Resize:
+		w.resize()
+	}
 }
 
-func (w *Window) Show() {
-	w.ctx.MakeContextCurrent()
-	_ = gl.Init()
-	w.flush()
-	var box image.Rectangle
-	for !w.ctx.ShouldClose() {
-		select {
-		case r := <-w.newSize:
-			old := w.canvas.Image()
-			w.canvas = gg.NewContextForRGBA(image.NewRGBA(r))
-			w.canvas.DrawImage(old, 0, 0)
-			box = box.Union(r)
-		case <-w.events:
-			w.flush()
-		default:
-			glfw.PollEvents()
-			w.ctx.SwapBuffers()
-			time.Sleep(time.Second / 60)
-		}
+// focus makes n the node receiving the keyboard input, with the caret at
+// the end of its value.
+func (w *Window) focus(n *Node) {
+	if w.popup != nil && w.popup != n {
+		w.closePopup()
+	}
+	if a := w.active; a != nil && a != n && a.Data == "number" {
+		w.commit(a)
+	}
+	if w.active != nil {
+		w.active.focused = false
+		w.active.markRepaint()
+	}
+	w.active = n
+	if n != nil {
+		n.focused = true
+		n.caret = len(n.Value)
+		n.markRepaint()
 	}
 }
 
-func (w *Window) flush() {
-	w.render()
-
-	img := w.canvas.Image().(*image.RGBA)
-	bounds := img.Bounds()
-	defer func() {
-		img = nil
-	}()
-	gl.DrawBuffer(gl.FRONT)
-	gl.Viewport(
-		int32(bounds.Min.X),
-		int32(bounds.Min.Y),
-		int32(bounds.Dx()),
-		int32(bounds.Dy()),
-	)
-	gl.RasterPos2d(
-		-1+2*float64(bounds.Min.X)/float64(bounds.Dx()),
-		+1-2*float64(bounds.Min.Y)/float64(bounds.Dy()),
-	)
-	gl.PixelZoom(1, -1)
-	gl.DrawPixels(
-		int32(bounds.Dx()),
-		int32(bounds.Dy()),
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
-		unsafe.Pointer(&img.Pix[0]),
-	)
-	gl.Flush()
-}
-
-func (w *Window) render() {
-	var setFontFace = func(n *Node) {
-		f, ok := w.loadedFonts[n.Parent.Style.FontFamily]
-		if !ok {
-			fontBytes, err := ioutil.ReadFile(n.Parent.Style.FontFamily)
-			if err != nil {
-				log.Println(err)
-				return
+// insert inserts text in the value of the active node at the caret.
+func (w *Window) insert(text []rune) {
+	n := w.active
+	if n == nil || n.Data == "slider" {
+		return
+	}
+	v := append([]rune{}, n.Value[:n.caret]...)
+	n.Value = append(append(v, text...), n.Value[n.caret:]...)
+	n.caret += len(text)
+	n.markDirty()
+	w.inputted(n)
+}
+
+// deleteBack deletes the rune before the caret in the value of the active
+// node.
+func (w *Window) deleteBack() {
+	n := w.active
+	if n == nil || n.Data == "slider" || n.caret == 0 {
+		return
+	}
+	n.Value = append(n.Value[:n.caret-1], n.Value[n.caret:]...)
+	n.caret--
+	n.markDirty()
+	w.inputted(n)
+	if n.Data == "combobox" {
+		w.openPopup(n)
+	}
+}
+
+// keyDown handles the key k for the open menu or the focused element,
+// like moving the selection of a list or the caret of an input.
+func (w *Window) keyDown(k Key) {
+	if w.menu != nil && w.menuKey(k) {
+		return
+	}
+	if k == KeyBackspace {
+		w.deleteBack()
+		return
+	}
+	if w.widgetKey(k) {
+		return
+	}
+	w.moveCaret(k)
+}
+
+// moveCaret moves the caret of the active node for the key k. The arrow
+// keys move it in visual order, which differs from the order of the value
+// in right-to-left text.
+func (w *Window) moveCaret(k Key) {
+	n := w.active
+	if n == nil {
+		return
+	}
+	caret := n.caret
+	switch k {
+	case KeyLeft, KeyRight:
+		_, _, carets := inputText(n)
+		caret = moveCaret(carets, caret, k == KeyRight)
+	case KeyHome:
+		caret = 0
+	case KeyEnd:
+		caret = len(n.Value)
+	}
+	if caret != n.caret {
+		n.caret = caret
+		n.markRepaint()
+	}
+}
+
+// updateHover refreshes the hover state of the element nodes after the
+// pointer moved, marking the nodes that entered or left it.
+func (w *Window) updateHover() {
+	cursor := ArrowCursor
+	over := w.overlayAt(w.mouseX, w.mouseY)
+	for _, t := range w.trees() {
+		for _, n := range t.GetNodes() {
+			if n.Type != ElementNode {
+				continue
 			}
-			f, err = truetype.Parse(fontBytes)
-			if err != nil {
-				log.Println(err)
-				return
+			// overlays hide what is below them
+			h := n.Focused(w.mouseX, w.mouseY) && (over == nil || t == over)
+			if h != n.hovered {
+				n.hovered = h
+				n.markRepaint()
+			}
+			if h && (n.Data == "input" || n.Data == "combobox") {
+				cursor = IBeamCursor
 			}
-			w.loadedFonts[n.Parent.Style.FontFamily] = f
 		}
-		face := truetype.NewFace(f, &truetype.Options{
-			Size: n.Parent.Style.FontSize,
-		})
-		w.canvas.SetFontFace(face)
-	}
-	var f func(*Node)
-	f = func(n *Node) {
-		if n != nil {
-			switch n.Type {
-			case ElementNode:
-				switch n.Data {
-				case "input":
-					w.canvas.SetHexColor(n.Style.BorderColor)
-					w.canvas.SetLineWidth(n.Style.BorderWidth)
-					x, y := n.Model.RelativeX+0.5, n.Model.RelativeY+0.5
-					nw, nh := n.Model.Width, n.Model.Height
-					w.canvas.DrawLine(x, y, x+nw, y)
-					w.canvas.DrawLine(x, y, x, y+nh)
-					w.canvas.DrawLine(x+nw, y, x+nw, y+nh)
-					w.canvas.DrawLine(x, y+nh, x+nw, y+nh)
-					fw, _ := w.canvas.MeasureString(string(n.Value))
-					fw /= 2
-					afw := fw / float64(len(n.Value))
-					v := n.Value
-					if fw > nw {
-						v = n.Value[len(n.Value)-int(nw/afw)-1:]
-						fw = nw - 5
-					}
-					if n.Focused(w.mouseX, w.mouseY) {
-						w.canvas.DrawLine(fw+x+5, y+5, fw+x+5, nh+y-5)
-					}
-					w.canvas.Stroke()
-					setFontFace(n)
-					w.canvas.SetHexColor(n.Style.FontColor)
-					if len(n.Value) != 0 {
-						w.canvas.DrawStringAnchored(string(v), n.Model.RelativeX+6, n.Model.RelativeY+nh/2, 0, 0.4)
-					}
-				default:
-					w.canvas.DrawRectangle(n.Model.RelativeX, n.Model.RelativeY, n.Model.Width, n.Model.Height)
-					if n.Focused(w.mouseX, w.mouseY) {
-						w.canvas.SetHexColor(n.Style.HoverColor)
-					} else {
-						w.canvas.SetHexColor(n.Style.BackgroundColor)
-					}
-					w.canvas.Fill()
+	}
+	if _, _, ok := w.node.columnBorderAt(w.mouseX, w.mouseY); ok {
+		cursor = HResizeCursor
+	}
+	if n := w.node.dividerAt(w.mouseX, w.mouseY); n != nil {
+		cursor = HResizeCursor
+		if n.vertical() {
+			cursor = VResizeCursor
+		}
+	}
+	if cursor != w.cursor {
+		w.cursor = cursor
+		w.backend.SetCursor(cursor)
+	}
+}
+
+// OnClick sets the click function of the element with the given id in
+// the document of the window, see Node.OnClick. The element keeps it
+// when the document is reconciled or reloaded.
+func (w *Window) OnClick(id string, f func()) {
+	if n := w.element(id); n != nil {
+		n.OnClick(f)
+	}
+}
+
+// OnChange sets the change function of the element with the given id in
+// the document of the window, see Node.OnChange.
+func (w *Window) OnChange(id string, f func(value string)) {
+	if n := w.element(id); n != nil {
+		n.OnChange(f)
+	}
+}
+
+// OnInput sets the input function of the element with the given id in
+// the document of the window, see Node.OnInput.
+func (w *Window) OnInput(id string, f func(value string)) {
+	if n := w.element(id); n != nil {
+		n.OnInput(f)
+	}
+}
+
+// OnSelect sets the selection function of the element with the given id
+// in the document of the window, see Node.OnSelect.
+func (w *Window) OnSelect(id string, f func(index int)) {
+	if n := w.element(id); n != nil {
+		n.OnSelect(f)
+	}
+}
+
+// OnActivate sets the activate function of the menu item with the given
+// id in the document of the window, see Node.OnActivate.
+func (w *Window) OnActivate(id string, f func()) {
+	if n := w.element(id); n != nil {
+		n.OnActivate(f)
+	}
+}
+
+// element returns the element of the document of the window with the
+// given id, looking also at the items of menus, which are kept out of the
+// tree, or nil.
+func (w *Window) element(id string) *Node {
+	for _, n := range w.node.GetNodes() {
+		if n.ID == id {
+			return n
+		}
+		if n.menuState != nil {
+			for _, it := range n.menuState.items {
+				if it.ID == id {
+					return it
 				}
-			case CharDataNode:
-				setFontFace(n)
-				w.canvas.SetHexColor(n.Parent.Style.FontColor)
-				width, height := n.Parent.Model.Width, n.Parent.Model.Height
-				w.canvas.DrawStringAnchored(n.Data, n.Parent.Model.RelativeX+width/2, n.Parent.Model.RelativeY+height/2, 0.5, 0.5)
 			}
-			for c := n.FirstChild; c != nil; c = c.NextSibling {
-				f(c)
+		}
+	}
+	return nil
+}
+
+// clicked calls the click function of n, or else of its nearest ancestor
+// with one.
+func (w *Window) clicked(n *Node) {
+	for ; n != nil; n = n.Parent {
+		if n.on != nil && n.on.click != nil {
+			n.on.click()
+			return
+		}
+	}
+}
+
+// changed calls the change function of n.
+func (w *Window) changed(n *Node) {
+	if n.on != nil && n.on.change != nil {
+		n.on.change(n.GetValue())
+	}
+}
+
+// selected calls the selection function of n with the index i.
+func (w *Window) selected(n *Node, i int) {
+	if n.on != nil && n.on.selected != nil {
+		n.on.selected(i)
+	}
+}
+
+// inputted calls the input function of n.
+func (w *Window) inputted(n *Node) {
+	if n.on != nil && n.on.input != nil {
+		n.on.input(n.GetValue())
+	}
+}
+
+// Clipboard returns the text on the clipboard.
+func (w *Window) Clipboard() string {
+	return w.backend.Clipboard()
+}
+
+// SetClipboard puts the text s on the clipboard.
+func (w *Window) SetClipboard(s string) {
+	w.backend.SetClipboard(s)
+}
+
+// Wake interrupts the wait for events of the goroutine running Show. It
+// may be called from any goroutine, but the node tree must only be changed
+// on the goroutine running Show: other goroutines pass their changes with
+// AfterFunc and a zero duration, which wakes the window up by itself.
+func (w *Window) Wake() {
+	w.backend.Wake()
+}
+
+// Frames returns the number of frames painted so far.
+func (w *Window) Frames() int {
+	return w.frames
+}
+
+func (w *Window) Show() {
+	for !w.backend.ShouldClose() {
+		w.update()
+		w.backend.WaitEvents()
+	}
+}
+
+// Close destroys the window and stops its timers.
+func (w *Window) Close() {
+	w.timerMu.Lock()
+	for _, t := range w.timers {
+		t.wake.Stop()
+	}
+	w.timers = nil
+	w.timerMu.Unlock()
+	w.backend.Close()
+}
+
+// update runs the timers that are due, then paints and presents the
+// changes made since the last frame.
+func (w *Window) update() {
+	w.runTimers(w.clock.Now())
+	if r := w.repaint(); !r.Empty() {
+		w.backend.Present(w.renderer.Image(), r)
+	}
+}
+
+// invalidate schedules the whole canvas for repainting.
+func (w *Window) invalidate() {
+	r := w.renderer
+	w.damage = image.Rect(0, 0, int(math.Ceil(r.width)), int(math.Ceil(r.height)))
+	w.node.relayout = true
+}
+
+// repaint moves the animations on, lays out the node tree to fill the
+// canvas and the overlays when one of them or the size of the canvas
+// changed, renders the part of them that changed since the last frame and
+// returns the repainted region of the canvas, which is empty when nothing
+// changed.
+func (w *Window) repaint() image.Rectangle {
+	w.animate()
+	trees := w.trees()
+	for _, t := range trees {
+		if t.relayout {
+			layoutRoot(w.node, w.renderer.width, w.renderer.height)
+			w.layoutOverlays()
+			for _, t := range trees {
+				t.relayout = false
 			}
+			break
 		}
 	}
-	f(w.node)
+	w.renderer.now = w.clock.Now()
+	r := w.damage
+	for _, t := range trees {
+		r = r.Union(t.damage())
+		t.clean()
+	}
+	w.damage = image.Rectangle{}
+	if r = w.renderer.Paint(r); !r.Empty() {
+		w.frames++
+	}
+	return r
 }
@@ -1,6 +1,9 @@
 package geui
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+)
 
 func TestWindow(t *testing.T) {
 	node := LoadXML("testdata/main.xml")
@@ -14,3 +17,172 @@ func TestWindow(t *testing.T) {
 	}
 	w.Show()
 }
+
+// newTestWindow shows the node tree of testdata/main.xml on a headless backend.
+func newTestWindow(t *testing.T) (*Window, *Headless) {
+	node := LoadXML("testdata/main.xml")
+	h := NewHeadless()
+	w, err := NewWindow(
+		node,
+		Size(node.Model.Width, node.Model.Height),
+		WithBackend(h),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, h
+}
+
+// newXMLWindow shows the document src in a headless window of the size of
+// its root, and paints the first frame.
+func newXMLWindow(t *testing.T, src string, opts ...WindowOption) *Window {
+	root := loadTestXML(t, src)
+	opts = append([]WindowOption{Size(root.Model.Width, root.Model.Height), WithBackend(NewHeadless())}, opts...)
+	w, err := NewWindow(root, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.repaint()
+	return w
+}
+
+func TestWindowRepaint(t *testing.T) {
+	w, _ := newTestWindow(t)
+	node := w.node
+	if w.repaint().Empty() {
+		t.Fatal("first frame was not painted")
+	}
+	if !w.repaint().Empty() {
+		t.Fatal("frame painted without changes")
+	}
+	// pointer enters the window but no child
+	w.dispatch(MouseMove{X: 1, Y: 1})
+	w.repaint()
+	w.dispatch(MouseMove{X: 2, Y: 2})
+	if !w.repaint().Empty() {
+		t.Fatal("frame painted while hover state is unchanged")
+	}
+	// pointer enters the label, which is repainted without a layout
+	node.FirstChild.Model.Height--
+	w.dispatch(MouseMove{X: 20, Y: 20})
+	if w.repaint().Empty() {
+		t.Fatal("hover change was not painted")
+	}
+	if node.relayout || node.FirstChild.Model.Height == node.FirstChild.Style.Height {
+		t.Error("tree laid out for a change of the hover state")
+	}
+	node.FirstChild.Model.Height++
+	w.dispatch(MouseScroll{X: 0, Y: 1})
+	if !w.repaint().Empty() {
+		t.Fatal("frame painted for an event without visual change")
+	}
+	node.FirstChild.SetStyle("background-color:#ff0000")
+	if w.repaint().Empty() {
+		t.Fatal("style change was not painted")
+	}
+	node.FirstChild.SetStyle("background-color:#00ff00")
+	if r := w.repaint(); r != node.FirstChild.Bounds() {
+		t.Fatalf("repainted %v, want the label bounds %v", r, node.FirstChild.Bounds())
+	}
+	if w.Frames() != 5 {
+		t.Fatalf("painted %d frames, want 5", w.Frames())
+	}
+}
+
+func TestWindowScale(t *testing.T) {
+	w, h := newTestWindow(t)
+	h.SetContentScale(1.5)
+	h.PollEvents()
+	w.update()
+	if got, want := h.Frame().Bounds().Dx(), 450; got != want {
+		t.Fatalf("frame width = %d, want %d", got, want)
+	}
+	// events are in logical units
+	h.Send(MouseMove{X: 20, Y: 20})
+	h.PollEvents()
+	if !w.node.FirstChild.hovered {
+		t.Fatal("label is not hovered")
+	}
+}
+
+func TestWindowHeadless(t *testing.T) {
+	w, h := newTestWindow(t)
+	done := make(chan struct{})
+	go func() {
+		w.Show()
+		close(done)
+	}()
+	h.Send(MouseMove{X: 20, Y: 130})
+	h.Send(MouseUp{X: 20, Y: 130})
+	h.Close()
+	<-done
+	if w.active != w.node.LastChild {
+		t.Fatalf("active node is %v, want the button", w.active)
+	}
+	if h.Presents() == 0 {
+		t.Fatal("no frame was presented")
+	}
+}
+
+func TestWindowClipboard(t *testing.T) {
+	w, h := newTestWindow(t)
+	h.SetClipboard("pasted")
+	input := w.node.FirstChild.NextSibling
+	h.Send(MouseMove{X: 20, Y: 80})
+	h.Send(MouseUp{X: 20, Y: 80})
+	h.Send(KbDown{KeyV, ModControl})
+	h.PollEvents()
+	if h.Cursor() != IBeamCursor {
+		t.Fatal("cursor over the input is not an I-beam")
+	}
+	if got := string(input.Value); got != "输入框pasted" {
+		t.Fatalf("input value = %q", got)
+	}
+}
+
+func TestRepaintFractionalEdges(t *testing.T) {
+	w := newXMLWindow(t, `<window style="width: 100px; height: 40px">
+	<grid style="grid-template-columns: 1fr 1fr 1fr; height: 40px; margin: 0">
+		<box style="height: 40px; margin: 0; background-color: #ff9191; hover-color: #ff9191"></box>
+		<box id="mid" style="height: 40px; margin: 0; background-color: #ff9191; hover-color: #ff9191"></box>
+		<box style="height: 40px; margin: 0; background-color: #ff9191; hover-color: #ff9191"></box>
+	</grid>
+</window>`)
+	mid := w.node.GetNodeByID("mid")
+	mid.Style.BackgroundColor, mid.Style.HoverColor = "#9191ff", "#9191ff"
+	mid.markRepaint()
+	if r := w.repaint(); r.Dx() >= 100 {
+		t.Fatalf("repainted %v, want a part of the canvas", r)
+	}
+	partial := append([]byte(nil), w.renderer.Image().Pix...)
+	w.invalidate()
+	w.repaint()
+	if !bytes.Equal(partial, w.renderer.Image().Pix) {
+		t.Error("partial repaint differs from a full one")
+	}
+}
+
+func TestHeadlessResizeWhileRunning(t *testing.T) {
+	h := NewHeadless()
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			h.Resize(300+float64(i), 400)
+			h.SetContentScale(1 + float64(i%2))
+		}
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		// read by the window on its own goroutine
+		h.Size()
+		h.ContentScale()
+	}
+	if w, _ := h.Size(); w != 399 {
+		t.Errorf("width %v, want the last size", w)
+	}
+}